@@ -0,0 +1,315 @@
+// Package atom provides types for Atom feeds, and converts them to and from
+// podcasts.
+//
+// See [RFC 4287]
+//
+// [RFC 4287]: https://www.rfc-editor.org/rfc/rfc4287
+package atom
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+const Namespace = "http://www.w3.org/2005/Atom"
+
+// Feed is the root element of an Atom document
+type Feed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+
+	// ID (required) is a permanent, universally unique identifier for the
+	// feed, as an IRI.
+	ID string `xml:"id"`
+
+	// Title (required) is a human readable title for the feed.
+	Title Text `xml:"title"`
+
+	// Subtitle (optional) is a human readable description of the feed.
+	Subtitle *Text `xml:"subtitle,omitempty"`
+
+	// Updated (required) is the last time the feed was modified in a
+	// significant way, as an RFC 3339 timestamp.
+	Updated string `xml:"updated"`
+
+	// Authors (recommended) of the feed. Every entry must have an author if
+	// the feed doesn't.
+	Authors []Person `xml:"author,omitempty"`
+
+	// Links (recommended) to related resources, including an alternate link to
+	// the website and a self link to the feed.
+	Links []Link `xml:"link,omitempty"`
+
+	Categories []Category `xml:"category,omitempty"`
+
+	// Icon (optional) is a small square image, like a favicon.
+	Icon string `xml:"icon,omitempty"`
+
+	// Logo (optional) is a larger image, with an aspect ratio of 2:1.
+	Logo string `xml:"logo,omitempty"`
+
+	// Rights (optional) is the copyright of the feed.
+	Rights *Text `xml:"rights,omitempty"`
+
+	Generator *Generator `xml:"generator,omitempty"`
+
+	Entries []Entry `xml:"entry"`
+}
+
+// Entry is an item of a feed
+type Entry struct {
+	XMLName xml.Name `xml:"entry"`
+
+	// ID (required) is a permanent, universally unique identifier for the
+	// entry, as an IRI.
+	ID string `xml:"id"`
+
+	// Title (required) is a human readable title for the entry.
+	Title Text `xml:"title"`
+
+	// Updated (required) is the last time the entry was modified in a
+	// significant way, as an RFC 3339 timestamp.
+	Updated string `xml:"updated"`
+
+	// Published (optional) is when the entry was first made available, as an
+	// RFC 3339 timestamp.
+	Published string `xml:"published,omitempty"`
+
+	Authors    []Person   `xml:"author,omitempty"`
+	Links      []Link     `xml:"link,omitempty"`
+	Categories []Category `xml:"category,omitempty"`
+
+	// Summary (optional) is a short summary or excerpt of the entry.
+	Summary *Text `xml:"summary,omitempty"`
+
+	// Content (optional) is the content of the entry, or a link to it.
+	Content *Content `xml:"content,omitempty"`
+}
+
+// Text is a human readable text construct
+type Text struct {
+	// Type is text (the default), html or xhtml.
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// Content is the content of an entry, either inline or linked with Src
+type Content struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Src   string `xml:"src,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// Person is an author or contributor
+type Person struct {
+	Name  string `xml:"name"`
+	URI   string `xml:"uri,omitempty"`
+	Email string `xml:"email,omitempty"`
+}
+
+// Link is a reference to a web resource
+type Link struct {
+	XMLName xml.Name `xml:"link"`
+	Href    string   `xml:"href,attr"`
+
+	// Rel is the link relation: alternate (the default), self, enclosure,
+	// related or via.
+	Rel      string `xml:"rel,attr,omitempty"`
+	Type     string `xml:"type,attr,omitempty"`
+	HrefLang string `xml:"hreflang,attr,omitempty"`
+	Title    string `xml:"title,attr,omitempty"`
+
+	// Length is the size of an enclosure in bytes
+	Length int `xml:"length,attr,omitempty"`
+}
+
+// Category is a category of a feed or entry
+type Category struct {
+	Term   string `xml:"term,attr"`
+	Scheme string `xml:"scheme,attr,omitempty"`
+	Label  string `xml:"label,attr,omitempty"`
+}
+
+// Generator is the software that generated the feed
+type Generator struct {
+	Value   string `xml:",chardata"`
+	URI     string `xml:"uri,attr,omitempty"`
+	Version string `xml:"version,attr,omitempty"`
+}
+
+// FromPodcast converts a podcast into an Atom feed. Enclosures become
+// enclosure links, and the feed's podcast:guid becomes its ID as a urn:uuid:
+// URN. Tags without an Atom equivalent, such as most itunes: and podcast:
+// tags, are dropped.
+func FromPodcast(pod *podcast.RSSPodcast) *Feed {
+	channel := &pod.Channel
+	feed := &Feed{
+		ID:    channel.Link,
+		Title: Text{Value: channel.Title},
+		Logo:  channel.ItunesImage.Href,
+	}
+	if channel.PodcastGUID != "" {
+		feed.ID = "urn:uuid:" + channel.PodcastGUID
+	}
+	if channel.Description.Value != "" {
+		feed.Subtitle = &Text{Type: "html", Value: channel.Description.Value}
+	}
+	if channel.Link != "" {
+		feed.Links = append(feed.Links, Link{Href: channel.Link, Rel: "alternate"})
+	}
+	if author := channel.ItunesAuthor; author != "" {
+		feed.Authors = []Person{{Name: author}}
+	}
+	for _, category := range channel.ItunesCategory {
+		feed.Categories = append(feed.Categories, Category{Term: category.Text})
+	}
+	if channel.Copyright != "" {
+		feed.Rights = &Text{Value: channel.Copyright}
+	}
+	if channel.Generator != "" {
+		feed.Generator = &Generator{Value: channel.Generator}
+	}
+
+	var newest time.Time
+	for _, item := range channel.Items {
+		entry := Entry{
+			ID:    item.Link,
+			Title: Text{Value: item.Title},
+		}
+		if item.GUID != nil {
+			entry.ID = item.GUID.Value
+		}
+		if published, err := item.PubDate.Time(); err == nil {
+			entry.Published = published.Format(time.RFC3339)
+			entry.Updated = entry.Published
+			if published.After(newest) {
+				newest = published
+			}
+		}
+		if item.Link != "" {
+			entry.Links = append(entry.Links, Link{Href: item.Link, Rel: "alternate"})
+		}
+		if item.Enclosure != nil {
+			entry.Links = append(entry.Links, Link{
+				Href:   item.Enclosure.URL,
+				Rel:    "enclosure",
+				Type:   item.Enclosure.Type,
+				Length: item.Enclosure.Length,
+			})
+		}
+		if item.Author != "" {
+			entry.Authors = []Person{{Name: item.Author}}
+		}
+		if item.Description != nil && item.Description.Value != "" {
+			entry.Summary = &Text{Type: "html", Value: item.Description.Value}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	feed.Updated = rfc3339(channel.LastBuildDate)
+	if feed.Updated == "" {
+		feed.Updated = rfc3339(channel.PubDate)
+	}
+	if feed.Updated == "" && !newest.IsZero() {
+		feed.Updated = newest.Format(time.RFC3339)
+	}
+	return feed
+}
+
+// Podcast converts the Atom feed into a podcast, the reverse of
+// [FromPodcast]. Entries with an enclosure link become episodes with an
+// enclosure, others become episodes without one.
+func (f *Feed) Podcast() *podcast.RSSPodcast {
+	pod := &podcast.RSSPodcast{Version: rss.RSSVersion}
+	channel := &pod.Channel
+
+	channel.Title = f.Title.Value
+	channel.Link = alternate(f.Links)
+	if f.Subtitle != nil {
+		channel.Description.Value = f.Subtitle.Value
+	}
+	if f.Rights != nil {
+		channel.Copyright = f.Rights.Value
+	}
+	if f.Generator != nil {
+		channel.Generator = f.Generator.Value
+	}
+	channel.LastBuildDate = rfc2822(f.Updated)
+	channel.ItunesImage.Href = f.Logo
+	if channel.ItunesImage.Href == "" {
+		channel.ItunesImage.Href = f.Icon
+	}
+	if len(f.Authors) > 0 {
+		channel.ItunesAuthor = f.Authors[0].Name
+	}
+	for _, category := range f.Categories {
+		channel.ItunesCategory = append(channel.ItunesCategory, podcast.ItunesCategory{Text: category.Term})
+	}
+	if guid, ok := strings.CutPrefix(f.ID, "urn:uuid:"); ok {
+		channel.PodcastGUID = guid
+	}
+
+	for _, entry := range f.Entries {
+		var episode podcast.Episode
+		episode.Title = entry.Title.Value
+		episode.Link = alternate(entry.Links)
+
+		isPermaLink := entry.ID == episode.Link
+		episode.GUID = &rss.GUID{Value: entry.ID, IsPermaLink: &isPermaLink}
+
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		episode.PubDate = rfc2822(published)
+
+		switch {
+		case entry.Summary != nil:
+			episode.Description = &rss.Description{Value: entry.Summary.Value}
+		case entry.Content != nil && entry.Content.Src == "":
+			episode.Description = &rss.Description{Value: entry.Content.Value}
+		}
+		if len(entry.Authors) > 0 {
+			episode.Author = entry.Authors[0].Name
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				episode.Enclosure = &rss.Enclosure{URL: link.Href, Length: link.Length, Type: link.Type}
+				break
+			}
+		}
+
+		channel.Items = append(channel.Items, episode)
+	}
+
+	return pod
+}
+
+// alternate returns the first alternate link
+func alternate(links []Link) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+func rfc3339(date rss.RFC2822Date) string {
+	t, err := date.Time()
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func rfc2822(date string) rss.RFC2822Date {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(date))
+	if err != nil {
+		return ""
+	}
+	return rss.NewRFC2822Date(t)
+}
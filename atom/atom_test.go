@@ -0,0 +1,87 @@
+package atom
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func testPodcast() *podcast.RSSPodcast {
+	isPermaLink := false
+	pod := &podcast.RSSPodcast{Version: rss.RSSVersion}
+	pod.Channel.Title = "The Go Podcast"
+	pod.Channel.Link = "https://example.com/"
+	pod.Channel.Description = rss.Description{Value: "A <b>show</b> about Go."}
+	pod.Channel.Copyright = "© 2024 Jayden"
+	pod.Channel.LastBuildDate = "Tue, 02 Jan 2024 10:00:00 +0000"
+	pod.Channel.ItunesAuthor = "Jayden"
+	pod.Channel.ItunesImage.Href = "https://example.com/cover.jpg"
+	pod.Channel.ItunesCategory = []podcast.ItunesCategory{{Text: "Technology"}}
+	pod.Channel.PodcastGUID = "917393e3-1b1e-5cef-ace4-edaa54e1f810"
+	pod.Channel.Items = []podcast.Episode{
+		{
+			Item: rss.Item{
+				Title:       "Episode 1",
+				Link:        "https://example.com/1",
+				Description: &rss.Description{Value: "The first one."},
+				Enclosure:   &rss.Enclosure{URL: "https://example.com/1.mp3", Length: 1000, Type: "audio/mpeg"},
+				GUID:        &rss.GUID{Value: "episode-1", IsPermaLink: &isPermaLink},
+				PubDate:     "Mon, 01 Jan 2024 00:00:00 +0000",
+			},
+		},
+	}
+	return pod
+}
+
+func TestFromPodcast(t *testing.T) {
+	expected := &Feed{
+		ID:       "urn:uuid:917393e3-1b1e-5cef-ace4-edaa54e1f810",
+		Title:    Text{Value: "The Go Podcast"},
+		Subtitle: &Text{Type: "html", Value: "A <b>show</b> about Go."},
+		Updated:  "2024-01-02T10:00:00Z",
+		Authors:  []Person{{Name: "Jayden"}},
+		Links:    []Link{{Href: "https://example.com/", Rel: "alternate"}},
+		Categories: []Category{
+			{Term: "Technology"},
+		},
+		Logo:   "https://example.com/cover.jpg",
+		Rights: &Text{Value: "© 2024 Jayden"},
+		Entries: []Entry{
+			{
+				ID:        "episode-1",
+				Title:     Text{Value: "Episode 1"},
+				Updated:   "2024-01-01T00:00:00Z",
+				Published: "2024-01-01T00:00:00Z",
+				Links: []Link{
+					{Href: "https://example.com/1", Rel: "alternate"},
+					{Href: "https://example.com/1.mp3", Rel: "enclosure", Type: "audio/mpeg", Length: 1000},
+				},
+				Summary: &Text{Type: "html", Value: "The first one."},
+			},
+		},
+	}
+
+	actual := FromPodcast(testPodcast())
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("FromPodcast() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	encoded, err := xml.Marshal(FromPodcast(testPodcast()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Feed
+	if err := xml.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(testPodcast(), decoded.Podcast(), cmpopts.IgnoreTypes(xml.Name{})); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/jaydenmilne/podcast/atom"
+	"github.com/jaydenmilne/podcast/jsonfeed"
+	"github.com/jaydenmilne/podcast/opml"
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+func runConvert(args []string, stdout io.Writer) error {
+	flags := newFlagSet("convert", "[feed]")
+	to := flags.String("to", "", "convert to `format`: rss, atom, json (JSON Feed) or opml")
+	feedURL := flags.String("feed-url", "", "the `url` the converted feed is published at, for JSON Feed and OPML")
+	output := flags.String("o", "", "write the result to `file` instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 || *to == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	feed, _, err := readAnyFeed(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	converted, err := convert(feed, *to, *feedURL)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, stdout, converted)
+}
+
+// convert encodes the feed in format
+func convert(feed *podcast.RSSPodcast, format, feedURL string) ([]byte, error) {
+	var encoded bytes.Buffer
+	var err error
+	switch format {
+	case formatRSS:
+		err = podcast.Encode(&encoded, feed)
+	case formatAtom:
+		err = encodeXML(&encoded, atom.FromPodcast(feed))
+	case formatJSON:
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		err = encoder.Encode(jsonfeed.FromPodcast(feed, feedURL))
+	case formatOPML:
+		err = encodeXML(&encoded, opml.FromPodcast(feed, feedURL))
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected rss, atom, json or opml", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+func encodeXML(w io.Writer, v any) error {
+	io.WriteString(w, xml.Header)
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
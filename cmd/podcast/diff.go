@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+func runDiff(args []string, stdout io.Writer) error {
+	flags := newFlagSet("diff", "old.xml new.xml")
+	text := flags.Bool("text", false, "print a unified diff of the formatted feeds instead of the changes")
+	breaking := flags.Bool("breaking", false, "only fail on breaking changes, such as a changed guid or enclosure URL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errUsage
+	}
+
+	var feeds [2]*podcast.RSSPodcast
+	for i, name := range flags.Args() {
+		feed, _, err := readAnyFeed(name)
+		if err != nil {
+			return err
+		}
+		feeds[i] = feed
+	}
+
+	changes := podcast.Diff(feeds[0], feeds[1])
+	if *text {
+		// Compare the canonical encodings, so formatting doesn't show up
+		var texts [2]string
+		for i, feed := range feeds {
+			var encoded bytes.Buffer
+			if err := podcast.Encode(&encoded, feed); err != nil {
+				return err
+			}
+			texts[i] = encoded.String()
+		}
+		writeUnified(stdout, flags.Arg(0), flags.Arg(1), diffLines(splitLines(texts[0]), splitLines(texts[1])))
+	} else {
+		io.WriteString(stdout, changes.String())
+	}
+
+	// Like diff(1), differences are a failure so scripts can check for them
+	if changes.Breaking() || !*breaking && !changes.Empty() {
+		return errFailed
+	}
+	return nil
+}
+
+// splitLines splits text into lines, keeping their line endings
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdit is a line of a diff: kept (' '), deleted ('-') or inserted ('+')
+type lineEdit struct {
+	op   byte
+	line string
+}
+
+// diffLines finds the shortest edit script from a to b with Myers' algorithm
+func diffLines(a, b []string) []lineEdit {
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+2)
+
+	// trace[d] is the part of v in use before round d, v[max-d : max+d+1]
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[max-d:max+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int) []lineEdit {
+	var edits []lineEdit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		if d == 0 {
+			for x > 0 {
+				x--
+				edits = append(edits, lineEdit{' ', a[x]})
+			}
+			break
+		}
+
+		v := trace[d]
+		k := x - y
+		var previousK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			previousK = k + 1
+		} else {
+			previousK = k - 1
+		}
+		previousX := v[d+previousK]
+		previousY := previousX - previousK
+
+		for x > previousX && y > previousY {
+			x--
+			y--
+			edits = append(edits, lineEdit{' ', a[x]})
+		}
+		if x == previousX {
+			y--
+			edits = append(edits, lineEdit{'+', b[y]})
+		} else {
+			x--
+			edits = append(edits, lineEdit{'-', a[x]})
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// writeUnified writes the edits in unified diff format with three lines of
+// context, and reports if there were any changes
+func writeUnified(w io.Writer, oldName, newName string, edits []lineEdit) bool {
+	const context = 3
+
+	// The line numbers in each file before each edit
+	oldLines := make([]int, len(edits)+1)
+	newLines := make([]int, len(edits)+1)
+	for i, edit := range edits {
+		oldLines[i+1], newLines[i+1] = oldLines[i], newLines[i]
+		if edit.op != '+' {
+			oldLines[i+1]++
+		}
+		if edit.op != '-' {
+			newLines[i+1]++
+		}
+	}
+
+	changed := false
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		if !changed {
+			fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+			changed = true
+		}
+
+		// Extend the hunk over changes separated by little enough context
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		stop := end + context + 1
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		oldStart, oldCount := oldLines[start], oldLines[stop]-oldLines[start]
+		newStart, newCount := newLines[start], newLines[stop]-newLines[start]
+		if oldCount > 0 {
+			oldStart++
+		}
+		if newCount > 0 {
+			newStart++
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, edit := range edits[start:stop] {
+			line := edit.line
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			fmt.Fprintf(w, "%c%s", edit.op, line)
+		}
+		i = stop
+	}
+	return changed
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jaydenmilne/podcast/atom"
+	"github.com/jaydenmilne/podcast/jsonfeed"
+	"github.com/jaydenmilne/podcast/opml"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// The formats convert reads and writes
+const (
+	formatRSS  = "rss"
+	formatAtom = "atom"
+	formatJSON = "json"
+	formatOPML = "opml"
+)
+
+// readInput reads the named file, or standard input for "" or -
+func readInput(name string) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// detectFormat tells RSS, Atom, JSON Feed and OPML documents apart
+func detectFormat(data []byte) (string, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\uFEFF")))
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return formatJSON, nil
+	}
+
+	start, err := rss.RootElement(xml.NewDecoder(bytes.NewReader(trimmed)))
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case start.Name.Local == "rss" || rss.IsRDF(start):
+		return formatRSS, nil
+	case start.Name.Local == "feed":
+		return formatAtom, nil
+	case start.Name.Local == "opml":
+		return formatOPML, nil
+	}
+	return "", fmt.Errorf("unknown document type <%s>", start.Name.Local)
+}
+
+// readFeed reads an RSS feed from the named file, or standard input
+func readFeed(name string) (*podcast.RSSPodcast, error) {
+	data, err := readInput(name)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := podcast.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", displayName(name), err)
+	}
+	return feed, nil
+}
+
+// readAnyFeed reads a feed in any of the formats convert supports, returning
+// it as a podcast along with the format it was in
+func readAnyFeed(name string) (*podcast.RSSPodcast, string, error) {
+	data, err := readInput(name)
+	if err != nil {
+		return nil, "", err
+	}
+	feed, format, err := decodeAnyFeed(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", displayName(name), err)
+	}
+	return feed, format, nil
+}
+
+func decodeAnyFeed(data []byte) (*podcast.RSSPodcast, string, error) {
+	format, err := detectFormat(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case formatAtom:
+		var feed atom.Feed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, "", err
+		}
+		return feed.Podcast(), format, nil
+	case formatJSON:
+		var feed jsonfeed.Feed
+		if err := json.Unmarshal(data, &feed); err != nil {
+			return nil, "", err
+		}
+		return feed.Podcast(), format, nil
+	case formatOPML:
+		var list opml.OPML
+		if err := xml.Unmarshal(data, &list); err != nil {
+			return nil, "", err
+		}
+		return list.Podcast(), format, nil
+	}
+
+	feed, err := podcast.Decode(bytes.NewReader(data))
+	return feed, format, err
+}
+
+func displayName(name string) string {
+	if name == "" || name == "-" {
+		return "<stdin>"
+	}
+	return name
+}
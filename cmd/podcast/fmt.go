@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+func runFmt(args []string, stdout io.Writer) error {
+	flags := newFlagSet("fmt", "[feed.xml ...]")
+	write := flags.Bool("w", false, "write the result back to the file instead of standard output")
+	list := flags.Bool("l", false, "list files whose formatting differs, and fail if there are any")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		if *write {
+			fmt.Fprintln(flags.Output(), "cannot use -w with standard input")
+			return errUsage
+		}
+		files = []string{"-"}
+	}
+
+	unformatted := false
+	for _, name := range files {
+		original, err := readInput(name)
+		if err != nil {
+			return err
+		}
+		feed, err := podcast.Decode(bytes.NewReader(original))
+		if err != nil {
+			return fmt.Errorf("%s: %w", displayName(name), err)
+		}
+		var formatted bytes.Buffer
+		if err := podcast.Encode(&formatted, feed); err != nil {
+			return fmt.Errorf("%s: %w", displayName(name), err)
+		}
+
+		changed := !bytes.Equal(original, formatted.Bytes())
+		switch {
+		case *list:
+			if changed {
+				fmt.Fprintln(stdout, displayName(name))
+				unformatted = true
+			}
+		case *write:
+			if changed {
+				if err := os.WriteFile(name, formatted.Bytes(), 0o644); err != nil {
+					return err
+				}
+			}
+		default:
+			if _, err := stdout.Write(formatted.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if unformatted {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jaydenmilne/podcast/dirfeed"
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+func runGenerate(args []string, stdout io.Writer) error {
+	flags := newFlagSet("generate", "[directory]")
+	output := flags.String("o", "", "write the feed to `file` instead of standard output")
+	baseURL := flags.String("base-url", "", "publish the media under `url`, overriding the manifest's base_url")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errUsage
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	fsys := os.DirFS(dir)
+	manifest, err := dirfeed.FindManifest(fsys)
+	if err != nil {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	if *baseURL != "" {
+		manifest.BaseURL = *baseURL
+	}
+
+	feed, err := dirfeed.Build(fsys, manifest)
+	if err != nil {
+		return err
+	}
+	var encoded bytes.Buffer
+	if err := podcast.Encode(&encoded, feed); err != nil {
+		return err
+	}
+	return writeOutput(*output, stdout, encoded.Bytes())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+func runInspect(args []string, stdout io.Writer) error {
+	flags := newFlagSet("inspect", "[feed]")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	feed, format, err := readAnyFeed(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	inspect(stdout, feed, format)
+	return nil
+}
+
+// inspect prints a summary of the feed and a table of its episodes
+func inspect(w io.Writer, feed *podcast.RSSPodcast, format string) {
+	channel := &feed.Channel
+
+	var total time.Duration
+	var size int64
+	for i := range channel.Items {
+		duration, _ := channel.Items[i].Duration()
+		total += duration
+		if enclosure := channel.Items[i].Enclosure; enclosure != nil {
+			size += int64(enclosure.Length)
+		}
+	}
+
+	fmt.Fprintf(w, "Title:    %s\n", channel.Title)
+	fmt.Fprintf(w, "Format:   %s\n", format)
+	if channel.ItunesAuthor != "" {
+		fmt.Fprintf(w, "Author:   %s\n", channel.ItunesAuthor)
+	}
+	if channel.PodcastGUID != "" {
+		fmt.Fprintf(w, "GUID:     %s\n", channel.PodcastGUID)
+	}
+	fmt.Fprintf(w, "Episodes: %d, %s, %s\n\n", len(channel.Items), formatDuration(total), formatSize(size))
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tPUBLISHED\tDURATION\tSIZE\tTITLE\tMISSING")
+	for i := range channel.Items {
+		episode := &channel.Items[i]
+
+		published := "-"
+		if t, err := episode.PubDate.Time(); err == nil {
+			published = t.UTC().Format(time.DateOnly)
+		}
+		duration := "-"
+		if d, err := episode.Duration(); err == nil && d > 0 {
+			duration = formatDuration(d)
+		}
+		size := "-"
+		if episode.Enclosure != nil && episode.Enclosure.Length > 0 {
+			size = formatSize(int64(episode.Enclosure.Length))
+		}
+
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n", i, published, duration, size, truncate(episode.Title, 50), strings.Join(missingFields(episode), ", "))
+	}
+	table.Flush()
+}
+
+// missingFields lists the fields an episode should have but doesn't
+func missingFields(episode *podcast.Episode) []string {
+	var missing []string
+	if episode.Title == "" {
+		missing = append(missing, "title")
+	}
+	if episode.Enclosure == nil || episode.Enclosure.URL == "" {
+		missing = append(missing, "enclosure")
+	} else if episode.Enclosure.Length == 0 {
+		missing = append(missing, "length")
+	}
+	if episode.GUID == nil || episode.GUID.Value == "" {
+		missing = append(missing, "guid")
+	}
+	if episode.PubDate == "" {
+		missing = append(missing, "pubDate")
+	}
+	if episode.Description == nil || strings.TrimSpace(episode.Description.Value) == "" {
+		missing = append(missing, "description")
+	}
+	if episode.ItunesDuration == "" {
+		missing = append(missing, "duration")
+	}
+	return missing
+}
+
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+func formatSize(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/1e6)
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+// Command podcast works with podcast feeds from the command line.
+//
+// Usage:
+//
+//	podcast <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	validate  check feeds against the Apple Podcasts and podcast namespace rules
+//	fmt       re-encode feeds canonically, with the usual namespace prefixes
+//	convert   convert between RSS, Atom, JSON Feed and OPML
+//	inspect   summarize a feed and its episodes
+//	diff      show the differences between two feeds
+//	generate  build a feed from a directory of media files and a manifest
+//
+// Run podcast <command> -h for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+func commands() []command {
+	return []command{
+		{"validate", "check feeds against the Apple Podcasts and podcast namespace rules", runValidate},
+		{"fmt", "re-encode feeds canonically, with the usual namespace prefixes", runFmt},
+		{"convert", "convert between RSS, Atom, JSON Feed and OPML", runConvert},
+		{"inspect", "summarize a feed and its episodes", runInspect},
+		{"diff", "show the differences between two feeds", runDiff},
+		{"generate", "build a feed from a directory of media files and a manifest", runGenerate},
+	}
+}
+
+var (
+	// errUsage is returned by commands given bad arguments, after they've
+	// printed their usage.
+	errUsage = errors.New("usage")
+
+	// errFailed is returned by commands that have already reported why they
+	// failed, such as validate finding problems.
+	errFailed = errors.New("failed")
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command in args, returning the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+
+	for _, c := range commands() {
+		if c.name != args[0] {
+			continue
+		}
+		err := c.run(args[1:], stdout)
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+			return 2
+		case errors.Is(err, errFailed):
+			return 1
+		default:
+			fmt.Fprintf(stderr, "podcast %s: %v\n", c.name, err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(stderr, "podcast: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage:\n\n\tpodcast <command> [flags] [arguments]\n\nThe commands are:\n\n")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "\t%-9s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun podcast <command> -h for the flags of a command.\n")
+}
+
+// newFlagSet returns a flag set for the command name, whose usage shows
+// arguments after the flags.
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: podcast %s [flags] %s\n\nFlags:\n", name, arguments)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// writeOutput writes data to the named file, or to stdout if name is "" or -
+func writeOutput(name string, stdout io.Writer, data []byte) error {
+	if name == "" || name == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
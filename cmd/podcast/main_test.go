@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"nope"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("expected the usage, got %q", stderr.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	manifest := "title: The Go Podcast\nbase_url: https://example.com/\n"
+	if err := os.WriteFile(filepath.Join(dir, "podcast.yaml"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"generate", "-base-url", "https://cdn.example.com/show/", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	feed, err := podcast.Decode(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Channel.Title != "The Go Podcast" || feed.Channel.Link != "https://cdn.example.com/show/" {
+		t.Errorf("unexpected channel %+v", feed.Channel.Channel)
+	}
+
+	if code := run([]string{"generate", t.TempDir()}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 without a manifest, got %d", code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"validate", "-json", "../../podcast/samples/apple.rss"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d: %s", code, stderr.String())
+	}
+	var results []validation
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Valid || len(results[0].Problems) == 0 {
+		t.Errorf("expected problems, got %+v", results)
+	}
+
+	// Formatting a valid feed mustn't make it invalid
+	dir := t.TempDir()
+	name := filepath.Join(dir, "feed.xml")
+	if err := os.WriteFile(name, []byte(validFeed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"validate", name}, {"fmt", "-w", name}, {"validate", name}} {
+		stdout.Reset()
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d: %s%s", args, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestFmt(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "feed.xml")
+	if err := os.WriteFile(name, []byte(validFeed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"fmt", "-l", name}, &stdout, &stderr); code != 1 || stdout.String() != name+"\n" {
+		t.Errorf("expected the file listed, got %d %q", code, stdout.String())
+	}
+	if code := run([]string{"fmt", "-w", name}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := run([]string{"fmt", "-l", name}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("expected no files listed after formatting, got %d %q", code, stdout.String())
+	}
+
+	formatted, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(formatted), "<itunes:author>") {
+		t.Errorf("expected the itunes prefix, got\n%s", formatted)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	for _, format := range []string{formatRSS, formatAtom, formatJSON, formatOPML} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			name := filepath.Join(dir, "feed."+format)
+			var stdout, stderr bytes.Buffer
+			args := []string{"convert", "-to", format, "-feed-url", "https://example.com/feed.xml", "-o", name, "../../podcast/samples/example.xml"}
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+			}
+
+			feed, detected, err := readAnyFeed(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detected != format {
+				t.Errorf("expected %s, detected %s", format, detected)
+			}
+			want := "Podcasting 2.0 Namespace Example"
+			if format == formatOPML {
+				// OPML only lists the feed itself
+				if len(feed.Channel.PodcastRemoteItems) != 1 || feed.Channel.PodcastRemoteItems[0].FeedURL != "https://example.com/feed.xml" {
+					t.Errorf("unexpected remote items %+v", feed.Channel.PodcastRemoteItems)
+				}
+				return
+			}
+			if feed.Channel.Title != want || len(feed.Channel.Items) != 3 {
+				t.Errorf("expected %q with 3 episodes, got %q with %d", want, feed.Channel.Title, len(feed.Channel.Items))
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"convert", "../../podcast/samples/example.xml"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 without -to, got %d", code)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"inspect", "../../podcast/samples/apple.rss"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{
+		"Title:    Hiking Treks",
+		"Episodes: 9, 3:46:15, 23.0 MB",
+		"1  2019-05-07  0:17:04   8.7 MB  S02 EP04 Mt. Hood, Oregon",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	oldName := filepath.Join(dir, "old.xml")
+	newName := filepath.Join(dir, "new.xml")
+	if err := os.WriteFile(oldName, []byte(validFeed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed := strings.Replace(validFeed, "<title>Episode 1</title>", "<title>Episode One</title>", 1)
+	if err := os.WriteFile(newName, []byte(changed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"diff", oldName, oldName}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("expected no differences, got %d %q", code, stdout.String())
+	}
+	if code := run([]string{"diff", oldName, newName}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if want := "modified \"Episode One\" (episode-1):\n\ttitle: \"Episode 1\" -> \"Episode One\"\n"; stdout.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, stdout.String())
+	}
+
+	// Only breaking changes fail with -breaking
+	stdout.Reset()
+	if code := run([]string{"diff", "-breaking", oldName, newName}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit code 0 with -breaking, got %d", code)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"diff", "-text", oldName, newName}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	for _, want := range []string{"--- " + oldName, "-\t\t\t<title>Episode 1</title>", "+\t\t\t<title>Episode One</title>"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want []string
+	}{
+		{"", "", nil},
+		{"abc", "abc", []string{" a", " b", " c"}},
+		{"", "ab", []string{"+a", "+b"}},
+		{"ab", "", []string{"-a", "-b"}},
+		{"abcabba", "cbabac", []string{"-a", "-b", " c", "+b", " a", " b", "-b", " a", "+c"}},
+	} {
+		var got []string
+		for _, edit := range diffLines(splitChars(test.a), splitChars(test.b)) {
+			got = append(got, string(edit.op)+edit.line)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func splitChars(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "")
+}
+
+const validFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:podcast="https://podcastindex.org/namespace/1.0">
+<channel>
+<title>The Go Podcast</title>
+<link>https://example.com/</link>
+<description>A show about Go.</description>
+<language>en</language>
+<itunes:author>Gophers</itunes:author>
+<itunes:image href="https://example.com/cover.jpg"/>
+<itunes:category text="Technology"/>
+<itunes:explicit>false</itunes:explicit>
+<podcast:guid>917393e3-1b1e-5cef-ace4-edaa54e1f810</podcast:guid>
+<item>
+<title>Episode 1</title>
+<description>The first episode.</description>
+<guid isPermaLink="false">episode-1</guid>
+<pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+<enclosure url="https://example.com/1.mp3" length="1024" type="audio/mpeg"/>
+<itunes:duration>60</itunes:duration>
+</item>
+</channel>
+</rss>
+`
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// validation is the JSON output of validate for one feed
+type validation struct {
+	File     string            `json:"file"`
+	Valid    bool              `json:"valid"`
+	Problems []podcast.Problem `json:"problems"`
+}
+
+func runValidate(args []string, stdout io.Writer) error {
+	flags := newFlagSet("validate", "[feed.xml ...]")
+	asJSON := flags.Bool("json", false, "print the problems as JSON")
+	strict := flags.Bool("strict", false, "fail on warnings as well as errors")
+	quiet := flags.Bool("q", false, "only print errors, not warnings")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var results []validation
+	failed := false
+	for _, name := range files {
+		feed, err := readFeed(name)
+		if err != nil {
+			return err
+		}
+
+		result := validation{File: displayName(name), Valid: true, Problems: []podcast.Problem{}}
+		for _, problem := range podcast.Validate(feed) {
+			if problem.Severity == podcast.SeverityError || *strict {
+				result.Valid = false
+			}
+			if *quiet && problem.Severity != podcast.SeverityError {
+				continue
+			}
+			result.Problems = append(result.Problems, problem)
+		}
+		failed = failed || !result.Valid
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		for _, result := range results {
+			for _, problem := range result.Problems {
+				fmt.Fprintf(stdout, "%s: %s\n", result.File, problem)
+			}
+		}
+	}
+
+	if failed {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+// Package crawl keeps a set of podcast feeds up to date, fetching each as
+// often as its publishing history and hints call for, without overloading
+// the hosts they are on.
+package crawl
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jaydenmilne/podcast/fetch"
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// Clock tells the time, so tests can control it
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Event is the outcome of a fetch that found something: new episodes, a
+// feed that moved, or an error
+type Event struct {
+	// URL is the feed's URL as it was added
+	URL string
+
+	// Feed is the fetched feed, or nil for errors
+	Feed *podcast.RSSPodcast
+
+	// NewEpisodes are the episodes that weren't in the feed last time. On the
+	// first fetch, Initial is set and every episode is new.
+	NewEpisodes []podcast.Episode
+	Initial     bool
+
+	// MovedTo is where the feed moved to, see [fetch.Result.MovedTo]. The
+	// crawler fetches the new URL from now on.
+	MovedTo string
+
+	// Err is why the fetch failed. Feeds that are gone are removed, others
+	// are retried with [Backoff].
+	Err error
+
+	// NextFetch is when the feed will be fetched next
+	NextFetch time.Time
+}
+
+// Crawler fetches feeds when they are due. The zero value is ready to use.
+type Crawler struct {
+	// Client fetches the feeds. Defaults to a zero [fetch.Client].
+	Client *fetch.Client
+
+	// Clock defaults to the system clock
+	Clock Clock
+
+	// Policy bounds how often each feed is fetched
+	Policy Policy
+
+	// Workers is how many feeds are fetched at once. Defaults to 4.
+	Workers int
+
+	// HostInterval is the shortest time between requests to the same host,
+	// which also only has one request at a time. Defaults to a second.
+	HostInterval time.Duration
+
+	mu    sync.Mutex
+	feeds map[string]*feedState
+	wake  chan struct{}
+}
+
+// feedState is what the crawler knows about a feed
+type feedState struct {
+	url string
+
+	// fetchURL is where the feed is fetched from, after it moved
+	fetchURL   string
+	next       time.Time
+	fetching   bool
+	removed    bool
+	failures   int
+	validators fetch.Validators
+	feed       *podcast.RSSPodcast
+	seen       map[string]bool
+	observed   []time.Time
+}
+
+type fetched struct {
+	state  *feedState
+	result *fetch.Result
+	err    error
+}
+
+// Add schedules the feed at feedURL to be fetched now, and then as often as
+// it needs to be. Adding a feed that is already being crawled does nothing.
+func (c *Crawler) Add(feedURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.feeds == nil {
+		c.feeds = map[string]*feedState{}
+	}
+	if _, ok := c.feeds[feedURL]; ok {
+		return
+	}
+	c.feeds[feedURL] = &feedState{url: feedURL, fetchURL: feedURL, seen: map[string]bool{}}
+	c.wakeLocked()
+}
+
+// Remove stops crawling the feed at feedURL
+func (c *Crawler) Remove(feedURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state, ok := c.feeds[feedURL]; ok {
+		state.removed = true
+		delete(c.feeds, feedURL)
+	}
+}
+
+// wakeLocked makes Run look for due feeds
+func (c *Crawler) wakeLocked() {
+	if c.wake == nil {
+		c.wake = make(chan struct{}, 1)
+	}
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Crawler) wakeChannel() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.wake == nil {
+		c.wake = make(chan struct{}, 1)
+	}
+	return c.wake
+}
+
+// Run fetches feeds as they fall due and sends events for what it finds,
+// until ctx is done. Run must only be called once at a time.
+func (c *Crawler) Run(ctx context.Context, events chan<- Event) error {
+	client := c.Client
+	if client == nil {
+		client = &fetch.Client{}
+	}
+	clock := c.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	workers := c.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+	hostInterval := c.HostInterval
+	if hostInterval <= 0 {
+		hostInterval = time.Second
+	}
+
+	type host struct {
+		busy bool
+		last time.Time
+	}
+	hosts := map[string]*host{}
+	results := make(chan fetched, workers)
+	inFlight := 0
+	wake := c.wakeChannel()
+
+	for {
+		now := clock.Now()
+		wait := time.Duration(-1)
+		waitFor := func(d time.Duration) {
+			if wait < 0 || d < wait {
+				wait = d
+			}
+		}
+
+		c.mu.Lock()
+		var due []*feedState
+		for _, state := range c.feeds {
+			switch {
+			case state.fetching:
+			case state.next.After(now):
+				waitFor(state.next.Sub(now))
+			default:
+				due = append(due, state)
+			}
+		}
+		sort.Slice(due, func(i, j int) bool {
+			if !due[i].next.Equal(due[j].next) {
+				return due[i].next.Before(due[j].next)
+			}
+			return due[i].url < due[j].url
+		})
+		for _, state := range due {
+			if inFlight == workers {
+				break
+			}
+			name := hostOf(state.fetchURL)
+			h := hosts[name]
+			if h == nil {
+				h = &host{}
+				hosts[name] = h
+			}
+			if h.busy {
+				continue
+			}
+			if ready := h.last.Add(hostInterval); !h.last.IsZero() && ready.After(now) {
+				waitFor(ready.Sub(now))
+				continue
+			}
+
+			h.busy, h.last = true, now
+			state.fetching = true
+			inFlight++
+			go func(state *feedState, fetchURL string, validators fetch.Validators) {
+				result, err := client.Fetch(ctx, fetchURL, validators)
+				results <- fetched{state, result, err}
+			}(state, state.fetchURL, state.validators)
+		}
+		c.mu.Unlock()
+
+		var timer <-chan time.Time
+		if wait >= 0 {
+			timer = clock.After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case <-timer:
+		case done := <-results:
+			inFlight--
+			c.mu.Lock()
+			hosts[hostOf(done.state.fetchURL)].busy = false
+			event, ok := c.handle(done, clock.Now())
+			c.mu.Unlock()
+			if ok {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// handle updates the feed's state after a fetch, and returns the event to
+// send, if any
+func (c *Crawler) handle(done fetched, now time.Time) (Event, bool) {
+	state := done.state
+	state.fetching = false
+	if state.removed {
+		return Event{}, false
+	}
+
+	if done.err != nil {
+		var status *fetch.StatusError
+		var retryAfter time.Duration
+		if errors.As(done.err, &status) {
+			if status.Gone() {
+				delete(c.feeds, state.url)
+				return Event{URL: state.url, Err: done.err}, true
+			}
+			retryAfter = status.RetryAfter
+		}
+		state.failures++
+		state.next = now.Add(Backoff(state.failures, retryAfter, c.Policy))
+		return Event{URL: state.url, Err: done.err, NextFetch: state.next}, true
+	}
+
+	result := done.result
+	state.failures = 0
+	state.validators = result.Validators
+	if result.MovedTo != "" {
+		state.fetchURL = result.MovedTo
+	}
+	event := Event{URL: state.url, MovedTo: result.MovedTo}
+
+	if !result.NotModified {
+		event.Feed = result.Feed
+		event.Initial = state.feed == nil
+		for _, episode := range result.Feed.Channel.Items {
+			key := episodeKey(&episode)
+			if state.seen[key] {
+				continue
+			}
+			state.seen[key] = true
+			event.NewEpisodes = append(event.NewEpisodes, episode)
+			if _, err := episode.PubDate.Time(); err != nil && !event.Initial {
+				state.observed = append(state.observed, now)
+			}
+		}
+		if len(state.observed) > historyLength {
+			state.observed = state.observed[len(state.observed)-historyLength:]
+		}
+		state.feed = result.Feed
+	}
+
+	state.next = NextFetch(state.feed, state.observed, now, c.Policy)
+	event.NextFetch = state.next
+	return event, event.Initial || len(event.NewEpisodes) > 0 || event.MovedTo != ""
+}
+
+// episodeKey identifies an episode between fetches
+func episodeKey(episode *podcast.Episode) string {
+	if episode.GUID != nil && episode.GUID.Value != "" {
+		return "guid:" + episode.GUID.Value
+	}
+	if episode.Enclosure != nil && episode.Enclosure.URL != "" {
+		return "enclosure:" + episode.Enclosure.URL
+	}
+	return "title:" + episode.Title
+}
+
+func hostOf(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return feedURL
+	}
+	return u.Host
+}
@@ -0,0 +1,173 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaydenmilne/podcast/fetch"
+)
+
+// fakeClock only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+	} else {
+		c.waiters = append(c.waiters, fakeWaiter{c.now.Add(d), ch})
+	}
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiting := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.at.After(c.now) {
+			waiting = append(waiting, waiter)
+		} else {
+			waiter.ch <- c.now
+		}
+	}
+	c.waiters = waiting
+}
+
+func TestCrawler(t *testing.T) {
+	var mu sync.Mutex
+	episodes := map[string][]string{"/a": {"a1"}, "/b": {"b1", "b2"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/busy":
+			w.Header().Set("Retry-After", "7200")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		var items strings.Builder
+		for _, guid := range episodes[r.URL.Path] {
+			fmt.Fprintf(&items, "<item><title>%s</title><guid>%s</guid></item>", guid, guid)
+		}
+		fmt.Fprintf(w, `<rss version="2.0"><channel><title>%s</title>%s</channel></rss>`, r.URL.Path, items.String())
+	}))
+	defer server.Close()
+
+	clock := &fakeClock{now: time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)}
+	crawler := &Crawler{
+		Client:       &fetch.Client{HTTPClient: server.Client()},
+		Clock:        clock,
+		HostInterval: time.Second,
+	}
+	crawler.Add(server.URL + "/a")
+	crawler.Add(server.URL + "/b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan Event)
+	done := make(chan error, 1)
+	go func() { done <- crawler.Run(ctx, events) }()
+
+	receive := func() Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return Event{}
+		}
+	}
+	newGUIDs := func(event Event) []string {
+		var guids []string
+		for _, episode := range event.NewEpisodes {
+			guids = append(guids, episode.GUID.Value)
+		}
+		return guids
+	}
+
+	event := receive()
+	if event.URL != server.URL+"/a" || !event.Initial || strings.Join(newGUIDs(event), ",") != "a1" {
+		t.Fatalf("unexpected first event %+v", event)
+	}
+	if expected := clock.Now().Add(time.Hour); !event.NextFetch.Equal(expected) {
+		t.Errorf("expected the next fetch at %v, got %v", expected, event.NextFetch)
+	}
+
+	// The second feed is on the same host, so waits for HostInterval
+	select {
+	case event := <-events:
+		t.Fatalf("expected to wait for the host, got %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	event = receive()
+	if event.URL != server.URL+"/b" || !event.Initial || strings.Join(newGUIDs(event), ",") != "b1,b2" {
+		t.Fatalf("unexpected second event %+v", event)
+	}
+
+	mu.Lock()
+	episodes["/a"] = append(episodes["/a"], "a2")
+	mu.Unlock()
+	clock.Advance(time.Hour)
+	event = receive()
+	if event.URL != server.URL+"/a" || event.Initial || strings.Join(newGUIDs(event), ",") != "a2" {
+		t.Fatalf("unexpected new episode event %+v", event)
+	}
+
+	crawler.Add(server.URL + "/busy")
+	clock.Advance(time.Second)
+	event = receive()
+	if event.URL != server.URL+"/busy" || event.Err == nil {
+		t.Fatalf("expected an error, got %+v", event)
+	}
+	if expected := clock.Now().Add(2 * time.Hour); !event.NextFetch.Equal(expected) {
+		t.Errorf("expected to retry after Retry-After at %v, got %v", expected, event.NextFetch)
+	}
+
+	crawler.Add(server.URL + "/gone")
+	clock.Advance(time.Second)
+	event = receive()
+	if event.URL != server.URL+"/gone" || event.Err == nil {
+		t.Fatalf("expected an error, got %+v", event)
+	}
+	crawler.mu.Lock()
+	_, crawling := crawler.feeds[server.URL+"/gone"]
+	crawler.mu.Unlock()
+	if crawling {
+		t.Error("expected gone feeds to be removed")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected Run to stop with the context, got %v", err)
+	}
+}
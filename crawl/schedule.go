@@ -0,0 +1,171 @@
+package crawl
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Policy bounds how often feeds are fetched
+type Policy struct {
+	// MinInterval is the shortest time between fetches of a feed. Defaults
+	// to 15 minutes.
+	MinInterval time.Duration
+
+	// MaxInterval is the longest time between fetches of a feed, and the
+	// interval for complete feeds. Defaults to a day.
+	MaxInterval time.Duration
+
+	// DefaultInterval is the time between fetches of a feed without enough
+	// history to go on. Defaults to an hour.
+	DefaultInterval time.Duration
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MinInterval <= 0 {
+		p.MinInterval = 15 * time.Minute
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = 24 * time.Hour
+	}
+	if p.DefaultInterval <= 0 {
+		p.DefaultInterval = time.Hour
+	}
+	p.DefaultInterval = min(max(p.DefaultInterval, p.MinInterval), p.MaxInterval)
+	return p
+}
+
+// historyLength is how many of the latest episodes [Interval] looks at
+const historyLength = 10
+
+// historyDivisor is how many times a feed is fetched, on average, between
+// episodes
+const historyDivisor = 6
+
+// Interval is how long to wait before fetching the feed again.
+//
+// It is a sixth of the median time between the latest episodes, so a daily
+// show is fetched every four hours. Episodes are timed by their pubDate, and
+// observed has the times the crawler first saw episodes without one. The
+// interval is at least the feed's ttl, and complete feeds use the policy's
+// MaxInterval.
+func Interval(feed *podcast.RSSPodcast, observed []time.Time, policy Policy) time.Duration {
+	policy = policy.withDefaults()
+	if feed == nil {
+		return policy.DefaultInterval
+	}
+	channel := &feed.Channel
+	if channel.ItunesComplete == podcast.ItunesYesValue || channel.PodcastUpdateFrequency != nil && channel.PodcastUpdateFrequency.Complete {
+		return policy.MaxInterval
+	}
+
+	interval := policy.DefaultInterval
+	if gap, ok := medianGap(publishTimes(feed, observed)); ok {
+		interval = gap / historyDivisor
+	}
+	if ttl := time.Duration(channel.TTL) * time.Minute; ttl > interval {
+		interval = ttl
+	}
+	return min(max(interval, policy.MinInterval), policy.MaxInterval)
+}
+
+// NextFetch is when to fetch the feed next, after [Interval], moved out of
+// the hours and days the feed's skipHours and skipDays ask crawlers to skip.
+// If the feed's podcast:updateFrequency has a schedule, the fetch is brought
+// forward to the policy's MinInterval after the next episode is due.
+func NextFetch(feed *podcast.RSSPodcast, observed []time.Time, now time.Time, policy Policy) time.Time {
+	policy = policy.withDefaults()
+	next := now.Add(Interval(feed, observed, policy))
+	if feed == nil {
+		return next
+	}
+	if frequency := feed.Channel.PodcastUpdateFrequency; frequency != nil && !frequency.Complete && frequency.Rrule != "" {
+		if rule, err := frequency.RecurrenceRule(); err == nil {
+			if due := rule.NextOccurrences(now, 1); len(due) > 0 && due[0].Add(policy.MinInterval).Before(next) {
+				next = due[0].Add(policy.MinInterval)
+			}
+		}
+	}
+	return Unskipped(next, feed.Channel.SkipHours, feed.Channel.SkipDays)
+}
+
+// Unskipped returns t, or the start of the first hour after it that isn't in
+// skipHours or skipDays, which are in GMT. If every hour is skipped, the
+// hints are ignored.
+func Unskipped(t time.Time, skipHours *rss.SkipHours, skipDays *rss.SkipDays) time.Time {
+	var hours [24]bool
+	if skipHours != nil {
+		for _, hour := range skipHours.Hours {
+			if h, err := strconv.Atoi(strings.TrimSpace(hour)); err == nil && h >= 0 && h <= 24 {
+				// Some feeds use 24 for midnight
+				hours[h%24] = true
+			}
+		}
+	}
+	var days [7]bool
+	if skipDays != nil {
+		for _, day := range skipDays.Days {
+			for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+				if strings.EqualFold(strings.TrimSpace(string(day)), weekday.String()) {
+					days[weekday] = true
+				}
+			}
+		}
+	}
+
+	candidate := t
+	for i := 0; i < 24*8; i++ {
+		utc := candidate.UTC()
+		if !hours[utc.Hour()] && !days[utc.Weekday()] {
+			return candidate
+		}
+		candidate = utc.Truncate(time.Hour).Add(time.Hour)
+	}
+	return t
+}
+
+// Backoff is how long to wait before fetching a feed again after failures
+// in a row: doubling from the policy's MinInterval up to its MaxInterval, and
+// no sooner than the server asked with retryAfter
+func Backoff(failures int, retryAfter time.Duration, policy Policy) time.Duration {
+	policy = policy.withDefaults()
+	wait := policy.MinInterval
+	for i := 1; i < failures && wait < policy.MaxInterval; i++ {
+		wait *= 2
+	}
+	return max(min(wait, policy.MaxInterval), retryAfter)
+}
+
+// publishTimes are the latest times episodes were published, newest first
+func publishTimes(feed *podcast.RSSPodcast, observed []time.Time) []time.Time {
+	times := append([]time.Time(nil), observed...)
+	for i := range feed.Channel.Items {
+		if t, err := feed.Channel.Items[i].PubDate.Time(); err == nil {
+			times = append(times, t)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].After(times[j]) })
+	if len(times) > historyLength {
+		times = times[:historyLength]
+	}
+	return times
+}
+
+// medianGap is the median time between consecutive times, which are sorted
+func medianGap(times []time.Time) (time.Duration, bool) {
+	var gaps []time.Duration
+	for i := 1; i < len(times); i++ {
+		if gap := times[i-1].Sub(times[i]); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0, false
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2], true
+}
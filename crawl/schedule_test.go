@@ -0,0 +1,130 @@
+package crawl
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// feedWithEpisodes has an episode every gap, the latest at latest
+func feedWithEpisodes(latest time.Time, gap time.Duration, count int) *podcast.RSSPodcast {
+	feed := &podcast.RSSPodcast{}
+	for i := 0; i < count; i++ {
+		feed.Channel.Items = append(feed.Channel.Items, podcast.Episode{
+			Item: rss.Item{PubDate: rss.NewRFC2822Date(latest.Add(-time.Duration(i) * gap))},
+		})
+	}
+	return feed
+}
+
+func TestInterval(t *testing.T) {
+	latest := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	daily := feedWithEpisodes(latest, 24*time.Hour, 5)
+	weekly := feedWithEpisodes(latest, 7*24*time.Hour, 5)
+	hourly := feedWithEpisodes(latest, time.Hour, 5)
+	withTTL := feedWithEpisodes(latest, 24*time.Hour, 5)
+	withTTL.Channel.TTL = 6 * 60
+	complete := feedWithEpisodes(latest, time.Hour, 5)
+	complete.Channel.PodcastUpdateFrequency = &podcast.PodcastUpdateFrequency{Complete: true}
+	undated := &podcast.RSSPodcast{}
+	undated.Channel.Items = make([]podcast.Episode, 3)
+
+	testCases := []struct {
+		name     string
+		feed     *podcast.RSSPodcast
+		observed []time.Time
+		expected time.Duration
+	}{
+		{"unknown", nil, nil, time.Hour},
+		{"daily", daily, nil, 4 * time.Hour},
+		{"weekly is capped", weekly, nil, 24 * time.Hour},
+		{"hourly is floored", hourly, nil, 15 * time.Minute},
+		{"ttl", withTTL, nil, 6 * time.Hour},
+		{"complete", complete, nil, 24 * time.Hour},
+		{"no history", undated, nil, time.Hour},
+		{"observed", undated, []time.Time{latest, latest.Add(-12 * time.Hour), latest.Add(-24 * time.Hour)}, 2 * time.Hour},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Interval(tc.feed, tc.observed, Policy{}); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnskipped(t *testing.T) {
+	// A Monday
+	monday := time.Date(2024, 3, 4, 22, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		skipHours []string
+		skipDays  []rss.SkipDay
+		expected  time.Time
+	}{
+		{"nothing skipped", nil, nil, monday},
+		{"hour skipped", []string{"22"}, nil, time.Date(2024, 3, 4, 23, 0, 0, 0, time.UTC)},
+		{"hours skipped", []string{"22", "23", "24"}, nil, time.Date(2024, 3, 5, 1, 0, 0, 0, time.UTC)},
+		{"day skipped", nil, []rss.SkipDay{rss.SkipDayMonday}, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"day and hour skipped", []string{"0"}, []rss.SkipDay{rss.SkipDayMonday}, time.Date(2024, 3, 5, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Unskipped(monday, &rss.SkipHours{Hours: tc.skipHours}, &rss.SkipDays{Days: tc.skipDays})
+			if !actual.Equal(tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+
+	var everyHour []string
+	for hour := 0; hour < 24; hour++ {
+		everyHour = append(everyHour, strconv.Itoa(hour))
+	}
+	if actual := Unskipped(monday, &rss.SkipHours{Hours: everyHour}, nil); !actual.Equal(monday) {
+		t.Errorf("expected the hints to be ignored when every hour is skipped, got %v", actual)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		failures   int
+		retryAfter time.Duration
+		expected   time.Duration
+	}{
+		{1, 0, 15 * time.Minute},
+		{2, 0, 30 * time.Minute},
+		{4, 0, 2 * time.Hour},
+		{20, 0, 24 * time.Hour},
+		{1, 3 * time.Hour, 3 * time.Hour},
+	}
+	for _, tc := range testCases {
+		if actual := Backoff(tc.failures, tc.retryAfter, Policy{}); actual != tc.expected {
+			t.Errorf("Backoff(%d, %v): expected %v, got %v", tc.failures, tc.retryAfter, tc.expected, actual)
+		}
+	}
+}
+
+func TestNextFetchSchedule(t *testing.T) {
+	// A Monday, so the weekly episode is due tomorrow
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	feed := feedWithEpisodes(now.AddDate(0, 0, -6), 7*24*time.Hour, 5)
+	if expected := now.Add(24 * time.Hour); !NextFetch(feed, nil, now, Policy{}).Equal(expected) {
+		t.Errorf("expected the weekly show to be fetched at %v", expected)
+	}
+
+	feed.Channel.PodcastUpdateFrequency = &podcast.PodcastUpdateFrequency{
+		UpdateFrequencyText: "Weekly on Monday",
+		Dtstart:             "2024-01-01T14:00:00Z",
+		Rrule:               "FREQ=WEEKLY;BYDAY=MO",
+	}
+	expected := time.Date(2024, 3, 4, 14, 15, 0, 0, time.UTC)
+	if actual := NextFetch(feed, nil, now, Policy{}); !actual.Equal(expected) {
+		t.Errorf("expected a fetch just after the episode is due at %v, got %v", expected, actual)
+	}
+}
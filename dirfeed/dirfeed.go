@@ -0,0 +1,406 @@
+// Package dirfeed generates a podcast feed from a directory of media files
+// and a manifest describing the show.
+//
+// Every media file in the directory, or its subdirectories, is an episode.
+// Files are probed for the enclosure length, type and duration, and their
+// tags give the title, show notes, episode and season numbers and chapters.
+// Sidecar files named after the media file override the tags:
+//
+//   - ep1.json, ep1.yaml, ep1.yml or ep1.toml is an [EpisodeManifest]
+//   - ep1.html, ep1.md or ep1.txt is the show notes, used as is
+//   - ep1.jpg, ep1.jpeg or ep1.png is the episode artwork
+//
+// Files and directories starting with a dot are skipped.
+package dirfeed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaydenmilne/podcast/internal/config"
+	"github.com/jaydenmilne/podcast/internal/uuid"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/probe"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// MediaExtensions are the extensions of the files that are episodes
+var MediaExtensions = []string{".mp3", ".m4a", ".m4b", ".mp4", ".m4v", ".mov", ".ogg", ".oga", ".opus", ".wav"}
+
+var (
+	notesExtensions = []string{".html", ".md", ".txt"}
+	imageExtensions = []string{".jpg", ".jpeg", ".png"}
+)
+
+// Generator is the value of the generator element of built feeds
+const Generator = "github.com/jaydenmilne/podcast/dirfeed"
+
+// BuildDir builds the feed of the directory dir, reading the show manifest
+// from the first of [ManifestNames] in it.
+func BuildDir(dir string) (*podcast.RSSPodcast, error) {
+	fsys := os.DirFS(dir)
+	manifest, err := FindManifest(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return Build(fsys, manifest)
+}
+
+// FindManifest reads the show manifest from the first of [ManifestNames] in
+// fsys.
+func FindManifest(fsys fs.FS) (*Manifest, error) {
+	for _, name := range ManifestNames {
+		if _, err := fs.Stat(fsys, name); err != nil {
+			continue
+		}
+		var manifest Manifest
+		if err := ReadManifest(fsys, name, &manifest); err != nil {
+			return nil, err
+		}
+		return &manifest, nil
+	}
+	return nil, fmt.Errorf("dirfeed: no manifest, expected one of %s", strings.Join(ManifestNames, ", "))
+}
+
+// Build builds the feed of the media files in fsys. Episodes are sorted
+// newest first, and published on the date in their sidecar manifest, the date
+// in their tags, or their modification time, in that order.
+//
+// Episode GUIDs are version 5 UUIDs of the media file's path, in the
+// namespace of the show's GUID, so they don't change when the feed is rebuilt.
+func Build(fsys fs.FS, manifest *Manifest) (*podcast.RSSPodcast, error) {
+	if manifest.Title == "" {
+		return nil, errors.New("dirfeed: the manifest has no title")
+	}
+	base, err := url.Parse(manifest.BaseURL)
+	if err != nil || !base.IsAbs() {
+		return nil, fmt.Errorf("dirfeed: base_url %q must be an absolute URL", manifest.BaseURL)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	builder := &builder{fsys: fsys, manifest: manifest, base: base}
+	channel := builder.channel()
+
+	namespace, err := uuid.Parse(channel.PodcastGUID)
+	if err != nil {
+		// Not a UUID, but still stable
+		podcastNamespace, _ := uuid.Parse(podcast.PodcastGUIDNamespace)
+		namespace = uuid.NewV5(podcastNamespace, channel.PodcastGUID)
+	}
+	builder.namespace = namespace
+
+	var dated []datedEpisode
+	err = fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !isMedia(name) {
+			return nil
+		}
+
+		episode, published, err := builder.episode(name)
+		if err != nil {
+			return err
+		}
+		dated = append(dated, datedEpisode{name, published, episode})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(dated, func(i, j int) bool {
+		if !dated[i].published.Equal(dated[j].published) {
+			return dated[i].published.After(dated[j].published)
+		}
+		return dated[i].name < dated[j].name
+	})
+	for _, d := range dated {
+		channel.Items = append(channel.Items, d.episode)
+	}
+	if len(dated) > 0 {
+		channel.PubDate = rss.NewRFC2822Date(dated[0].published)
+	}
+
+	return &podcast.RSSPodcast{Channel: *channel, Version: rss.RSSVersion}, nil
+}
+
+type datedEpisode struct {
+	name      string
+	published time.Time
+	episode   podcast.Episode
+}
+
+type builder struct {
+	fsys      fs.FS
+	manifest  *Manifest
+	base      *url.URL
+	namespace uuid.UUID
+}
+
+func (b *builder) channel() *podcast.Podcast {
+	m := b.manifest
+	channel := &podcast.Podcast{}
+	channel.Title = m.Title
+	channel.Description = rss.Description{Value: m.Description}
+	channel.Link = m.Link
+	if channel.Link == "" {
+		channel.Link = b.base.String()
+	}
+	channel.Language = m.Language
+	channel.Copyright = m.Copyright
+	channel.Generator = Generator
+
+	channel.ItunesAuthor = m.Author
+	explicit := m.Explicit
+	channel.ItunesExplicit = &explicit
+	channel.ItunesType = m.Type
+	if m.Complete {
+		channel.ItunesComplete = podcast.ItunesYesValue
+	}
+	for _, category := range m.Categories {
+		itunesCategory := podcast.ItunesCategory{Text: category.Name}
+		if category.Subcategory != "" {
+			itunesCategory.SubCategory = &struct {
+				XMLName xml.Name `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+				Text    string   `xml:"text,attr"`
+			}{Text: category.Subcategory}
+		}
+		channel.ItunesCategory = append(channel.ItunesCategory, itunesCategory)
+	}
+
+	image := m.Image
+	if image == "" {
+		image = b.sibling("cover", imageExtensions)
+	}
+	if image != "" {
+		channel.ItunesImage.Href = b.resolve(image)
+	}
+
+	channel.PodcastGUID = m.GUID
+	if channel.PodcastGUID == "" {
+		feedURL := m.FeedURL
+		if feedURL == "" {
+			feedURL = b.base.String()
+		}
+		channel.PodcastGUID = podcast.GUIDFromFeedURL(feedURL)
+	}
+	if m.Locked != nil {
+		locked := &podcast.PodcastLocked{Value: podcast.No, Owner: m.Email}
+		if *m.Locked {
+			locked.Value = podcast.Yes
+		}
+		channel.PodcastLocked = locked
+	}
+	channel.PodcastMedium = m.Medium
+	if m.License != "" {
+		channel.PodcastLicense = &podcast.PodcastLicense{LicenseID: m.License}
+	}
+	for _, funding := range m.Funding {
+		channel.PodcastFunding = append(channel.PodcastFunding, podcast.PodcastFunding{
+			Value: podcast.YesOrNo(funding.Text),
+			URL:   funding.URL,
+		})
+	}
+
+	return channel
+}
+
+// episode builds the episode of the media file name, returning when it was
+// published.
+func (b *builder) episode(name string) (podcast.Episode, time.Time, error) {
+	var episode podcast.Episode
+
+	media, info, closer, err := b.open(name)
+	if err != nil {
+		return episode, time.Time{}, err
+	}
+	defer closer.Close()
+
+	stat, err := probe.Probe(media)
+	if err != nil {
+		return episode, time.Time{}, fmt.Errorf("dirfeed: %s: %w", name, err)
+	}
+	stat.PopulateEpisode(&episode)
+	episode.Enclosure.URL = b.resolve(name)
+
+	var tags *probe.Tags
+	if tags, err = probe.ReadTags(media); err != nil {
+		if !errors.Is(err, probe.ErrUnknownFormat) {
+			return episode, time.Time{}, fmt.Errorf("dirfeed: %s: %w", name, err)
+		}
+		tags = &probe.Tags{}
+	}
+	tags.PopulateEpisode(&episode, "")
+	if episode.Title == "" {
+		episode.Title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+	if len(tags.Chapters) > 0 {
+		jsonChapters := tags.JSONChapters()
+		pscChapters := jsonChapters.PscChapters()
+		episode.PscChapters = &pscChapters
+	}
+
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	if notes := b.sibling(stem, notesExtensions); notes != "" {
+		data, err := fs.ReadFile(b.fsys, notes)
+		if err != nil {
+			return episode, time.Time{}, err
+		}
+		episode.Description = &rss.Description{Value: string(data)}
+	}
+	if image := b.sibling(stem, imageExtensions); image != "" {
+		episode.ItunesImage = &podcast.ItunesImageTag{Href: b.resolve(image)}
+	}
+
+	published := info.ModTime()
+	if date, ok := parseDate(tags.Date); ok {
+		published = date
+	}
+
+	isPermaLink := false
+	guid := &rss.GUID{Value: uuid.NewV5(b.namespace, name).String(), IsPermaLink: &isPermaLink}
+
+	var sidecar EpisodeManifest
+	if name := b.sidecar(stem); name != "" {
+		if err := ReadManifest(b.fsys, name, &sidecar); err != nil {
+			return episode, time.Time{}, err
+		}
+	}
+	if sidecar.Title != "" {
+		episode.Title = sidecar.Title
+	}
+	if sidecar.Description != "" {
+		episode.Description = &rss.Description{Value: sidecar.Description}
+	}
+	if sidecar.Date != "" {
+		date, ok := parseDate(sidecar.Date)
+		if !ok {
+			return episode, time.Time{}, fmt.Errorf("dirfeed: %s: invalid date %q, expected RFC 3339 or YYYY-MM-DD", name, sidecar.Date)
+		}
+		published = date
+	}
+	if sidecar.Episode > 0 {
+		episode.ItunesEpisode = sidecar.Episode
+	}
+	if sidecar.Season > 0 {
+		episode.ItunesSeason = sidecar.Season
+	}
+	episode.ItunesEpisodeType = sidecar.Type
+	episode.ItunesExplicit = sidecar.Explicit
+	if sidecar.GUID != "" {
+		// A URL is assumed to be a permalink, as in RSS
+		guid = &rss.GUID{Value: sidecar.GUID}
+		if u, err := url.Parse(sidecar.GUID); err != nil || !u.IsAbs() {
+			guid.IsPermaLink = &isPermaLink
+		}
+	}
+	episode.Link = sidecar.Link
+	if sidecar.Image != "" {
+		episode.ItunesImage = &podcast.ItunesImageTag{Href: b.resolve(sidecar.Image)}
+	}
+
+	episode.GUID = guid
+	episode.PubDate = rss.NewRFC2822Date(published)
+	return episode, published, nil
+}
+
+// open opens the media file name for probing. The closer must be closed once
+// done with the media.
+func (b *builder) open(name string) (io.ReadSeeker, fs.FileInfo, io.Closer, error) {
+	f, err := b.fsys.Open(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, err
+	}
+	if seeker, ok := f.(io.ReadSeeker); ok {
+		return seeker, info, f, nil
+	}
+
+	// Not every fs.FS can seek, so fall back to reading the whole file
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, err
+	}
+	return bytes.NewReader(data), info, f, nil
+}
+
+// sibling returns stem with the first of extensions that exists in fsys, or
+// "" if none does
+func (b *builder) sibling(stem string, extensions []string) string {
+	for _, extension := range extensions {
+		if info, err := fs.Stat(b.fsys, stem+extension); err == nil && !info.IsDir() {
+			return stem + extension
+		}
+	}
+	return ""
+}
+
+// sidecar returns the episode manifest of the media file with stem, or "" if
+// it has none. The show manifest is never an episode's sidecar, even for
+// media named after it, such as podcast.mp3.
+func (b *builder) sidecar(stem string) string {
+	for _, extension := range config.Extensions {
+		if slices.Contains(ManifestNames, stem+extension) {
+			continue
+		}
+		if info, err := fs.Stat(b.fsys, stem+extension); err == nil && !info.IsDir() {
+			return stem + extension
+		}
+	}
+	return ""
+}
+
+// resolve returns the URL of ref, a URL or a slash separated path relative to
+// the base URL
+func (b *builder) resolve(ref string) string {
+	if u, err := url.Parse(ref); err == nil && u.IsAbs() {
+		return ref
+	}
+	return b.base.ResolveReference(&url.URL{Path: ref}).String()
+}
+
+func isMedia(name string) bool {
+	extension := strings.ToLower(path.Ext(name))
+	for _, media := range MediaExtensions {
+		if extension == media {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDate parses an RFC 3339 timestamp or a YYYY-MM-DD date, which is taken
+// to be midnight UTC
+func parseDate(date string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
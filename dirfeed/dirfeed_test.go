@@ -0,0 +1,233 @@
+package dirfeed
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/internal/uuid"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// wavFile is two seconds of 8 kHz, 8 bit mono silence
+func wavFile() []byte {
+	format := binary.LittleEndian.AppendUint16(nil, 1) // PCM
+	format = binary.LittleEndian.AppendUint16(format, 1)
+	format = binary.LittleEndian.AppendUint32(format, 8000)
+	format = binary.LittleEndian.AppendUint32(format, 8000)
+	format = binary.LittleEndian.AppendUint16(format, 1)
+	format = binary.LittleEndian.AppendUint16(format, 8)
+
+	chunk := func(id string, data []byte) []byte {
+		return append(append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...), data...)
+	}
+	body := bytes.Join([][]byte{[]byte("WAVE"), chunk("fmt ", format), chunk("data", make([]byte, 16000))}, nil)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+// mp3File is 100 frames at 128 kbps, about 2.6 seconds, tagged with a title
+// and track number
+func mp3File() []byte {
+	frame := func(id string, text string) []byte {
+		data := append([]byte{3}, text...) // UTF-8
+		return append(append(append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...), 0, 0), data...)
+	}
+	frames := append(frame("TIT2", "Tagged title"), frame("TRCK", "2/10")...)
+	size := len(frames)
+	tag := append([]byte{'I', 'D', '3', 4, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}, frames...)
+
+	audio := make([]byte, 417)
+	copy(audio, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return append(tag, bytes.Repeat(audio, 100)...)
+}
+
+func testFS() fstest.MapFS {
+	january := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	return fstest.MapFS{
+		"cover.png":          {Data: []byte("png")},
+		"2024/ep1.wav":       {Data: wavFile(), ModTime: january},
+		"2024/ep1.md":        {Data: []byte("Notes for *the first* episode\n")},
+		"2024/ep1.yaml":      {Data: []byte("title: The first episode\ndate: 2024-01-01\nepisode: 1\ntype: trailer\n")},
+		"2024/ep 2.mp3":      {Data: mp3File(), ModTime: january},
+		"2024/ep 2.jpg":      {Data: []byte("jpg")},
+		"2024/ep 2.toml":     {Data: []byte("date = \"2024-02-01T09:30:00Z\"\nexplicit = true\n")},
+		"bonus.wav":          {Data: wavFile(), ModTime: january},
+		"bonus.json":         {Data: []byte(`{"guid": "https://example.com/bonus", "link": "https://example.com/bonus"}`)},
+		".drafts/draft.wav":  {Data: wavFile()},
+		".unlisted.wav":      {Data: wavFile()},
+		"podcast.yaml":       {Data: []byte("title: ignored")},
+		"2024/show-notes.md": {Data: []byte("not an episode")},
+	}
+}
+
+func testManifest() *Manifest {
+	locked := true
+	return &Manifest{
+		Title:       "The Go Podcast",
+		Description: "A show about Go.",
+		BaseURL:     "https://example.com/podcast",
+		FeedURL:     "https://example.com/podcast/feed.xml",
+		Author:      "Jayden",
+		Email:       "jayden@example.com",
+		Language:    "en",
+		Categories:  []Category{{Name: "Technology"}, {Name: "Education", Subcategory: "Courses"}},
+		Type:        podcast.ItunesShowTypeEpisodic,
+		Locked:      &locked,
+		License:     "cc-by-4.0",
+		Funding:     []Funding{{URL: "https://example.com/donate", Text: "Support the show"}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	actual, err := Build(testFS(), testManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	podcastGUID := podcast.GUIDFromFeedURL("https://example.com/podcast/feed.xml")
+	namespace, _ := uuid.Parse(podcastGUID)
+	notPermaLink := false
+	guid := func(name string) *rss.GUID {
+		return &rss.GUID{Value: uuid.NewV5(namespace, name).String(), IsPermaLink: &notPermaLink}
+	}
+	explicit, yes := false, true
+
+	expected := &podcast.RSSPodcast{Version: rss.RSSVersion}
+	channel := &expected.Channel
+	channel.Title = "The Go Podcast"
+	channel.Description = rss.Description{Value: "A show about Go."}
+	channel.Link = "https://example.com/podcast/"
+	channel.Language = "en"
+	channel.Generator = Generator
+	channel.PubDate = rss.NewRFC2822Date(time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC))
+	channel.ItunesAuthor = "Jayden"
+	channel.ItunesExplicit = &explicit
+	channel.ItunesType = podcast.ItunesShowTypeEpisodic
+	channel.ItunesImage.Href = "https://example.com/podcast/cover.png"
+	channel.ItunesCategory = expectedCategories()
+	channel.PodcastGUID = podcastGUID
+	channel.PodcastLocked = &podcast.PodcastLocked{Value: podcast.Yes, Owner: "jayden@example.com"}
+	channel.PodcastLicense = &podcast.PodcastLicense{LicenseID: "cc-by-4.0"}
+	channel.PodcastFunding = []podcast.PodcastFunding{{Value: "Support the show", URL: "https://example.com/donate"}}
+
+	mp3Size, wavSize := len(mp3File()), len(wavFile())
+	channel.Items = []podcast.Episode{
+		{
+			Item: rss.Item{
+				Title:     "Tagged title",
+				Enclosure: &rss.Enclosure{URL: "https://example.com/podcast/2024/ep%202.mp3", Length: mp3Size, Type: "audio/mpeg"},
+				GUID:      guid("2024/ep 2.mp3"),
+				PubDate:   rss.NewRFC2822Date(time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)),
+			},
+			ItunesDuration: "3",
+			ItunesEpisode:  2,
+			ItunesExplicit: &yes,
+			ItunesImage:    &podcast.ItunesImageTag{Href: "https://example.com/podcast/2024/ep%202.jpg"},
+		},
+		{
+			Item: rss.Item{
+				Title:     "bonus",
+				Link:      "https://example.com/bonus",
+				Enclosure: &rss.Enclosure{URL: "https://example.com/podcast/bonus.wav", Length: wavSize, Type: "audio/wav"},
+				GUID:      &rss.GUID{Value: "https://example.com/bonus"},
+				PubDate:   rss.NewRFC2822Date(time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)),
+			},
+			ItunesDuration: "2",
+		},
+		{
+			Item: rss.Item{
+				Title:       "The first episode",
+				Description: &rss.Description{Value: "Notes for *the first* episode\n"},
+				Enclosure:   &rss.Enclosure{URL: "https://example.com/podcast/2024/ep1.wav", Length: wavSize, Type: "audio/wav"},
+				GUID:        guid("2024/ep1.wav"),
+				PubDate:     rss.NewRFC2822Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			ItunesDuration:    "2",
+			ItunesEpisode:     1,
+			ItunesEpisodeType: podcast.TrailerEpisode,
+		},
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Build() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// expectedCategories are the categories of testManifest
+func expectedCategories() []podcast.ItunesCategory {
+	categories := []podcast.ItunesCategory{{Text: "Technology"}, {Text: "Education"}}
+	categories[1].SubCategory = &struct {
+		XMLName xml.Name `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+		Text    string   `xml:"text,attr"`
+	}{Text: "Courses"}
+	return categories
+}
+
+func TestBuildErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fsys     fstest.MapFS
+		manifest Manifest
+	}{
+		{"no title", fstest.MapFS{}, Manifest{BaseURL: "https://example.com/"}},
+		{"relative base URL", fstest.MapFS{}, Manifest{Title: "Show", BaseURL: "/podcast/"}},
+		{"not media", fstest.MapFS{"ep1.mp3": {Data: []byte("not an mp3")}}, Manifest{Title: "Show", BaseURL: "https://example.com/"}},
+		{"bad date", fstest.MapFS{"ep1.wav": {Data: wavFile()}, "ep1.json": {Data: []byte(`{"date": "yesterday"}`)}}, Manifest{Title: "Show", BaseURL: "https://example.com/"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if feed, err := Build(tc.fsys, &tc.manifest); err == nil {
+				t.Errorf("expected an error, got %+v", feed)
+			}
+		})
+	}
+}
+
+func TestBuildDir(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := BuildDir(dir); err == nil {
+		t.Errorf("expected an error without a manifest")
+	}
+
+	manifest := "title = \"The Go Podcast\"\nbase_url = \"https://example.com/\"\nguid = \"not-a-uuid\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "podcast.toml"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ep1.wav"), wavFile(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Named like the manifest, which isn't its sidecar
+	if err := os.WriteFile(filepath.Join(dir, "podcast.wav"), wavFile(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := BuildDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, episode := range feed.Channel.Items {
+		if episode.Title == "The Go Podcast" {
+			t.Errorf("expected the show manifest not to be read as an episode's sidecar")
+		}
+	}
+	if len(feed.Channel.Items) != 2 || feed.Channel.PodcastGUID != "not-a-uuid" {
+		t.Fatalf("unexpected feed %+v", feed.Channel)
+	}
+
+	// GUIDs are stable across builds
+	again, err := BuildDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(feed.Channel.Items[0].GUID, again.Channel.Items[0].GUID); diff != "" {
+		t.Errorf("GUID changed (-first +second):\n%s", diff)
+	}
+}
@@ -0,0 +1,135 @@
+package dirfeed
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/jaydenmilne/podcast/internal/config"
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// ManifestNames are the file names [BuildDir] looks for the show manifest
+// under, in order.
+var ManifestNames = []string{"podcast.json", "podcast.yaml", "podcast.yml", "podcast.toml"}
+
+// Manifest describes the show, and is written in JSON, YAML or TOML with the
+// keys given by the json tags, for example:
+//
+//	title: The Go Podcast
+//	description: A show about Go.
+//	base_url: https://example.com/podcast/
+//	feed_url: https://example.com/podcast/feed.xml
+//	author: Jayden
+//	language: en
+//	categories:
+//	  - name: Technology
+//	  - name: Education
+//	    subcategory: Courses
+//	funding:
+//	  - url: https://example.com/donate
+//	    text: Support the show
+type Manifest struct {
+	// Title (required) is the name of the show
+	Title string `json:"title"`
+
+	// Description (required) is the show notes of the whole show
+	Description string `json:"description"`
+
+	// BaseURL (required) is where the directory is published. Enclosure and
+	// image URLs are the path of the file within the directory, resolved
+	// against it.
+	BaseURL string `json:"base_url"`
+
+	// FeedURL (optional) is where the feed is published. It's used to derive
+	// [Manifest.GUID] when that isn't set.
+	FeedURL string `json:"feed_url"`
+
+	// GUID (optional) is the podcast:guid of the show. Episode GUIDs are
+	// derived from it, so set it, or FeedURL, to keep them stable if the
+	// media moves to a different BaseURL.
+	GUID string `json:"guid"`
+
+	// Link (optional) is the website of the show, defaulting to BaseURL
+	Link string `json:"link"`
+
+	Language  string `json:"language"`
+	Copyright string `json:"copyright"`
+	Author    string `json:"author"`
+
+	// Email (optional) is the owner's email, used for podcast:locked
+	Email string `json:"email"`
+
+	// Image (optional) is the show artwork, a URL or a path relative to
+	// BaseURL. It defaults to a cover.jpg or cover.png file in the directory.
+	Image string `json:"image"`
+
+	Categories []Category             `json:"categories"`
+	Explicit   bool                   `json:"explicit"`
+	Type       podcast.ItunesShowType `json:"type"`
+	Complete   bool                   `json:"complete"`
+
+	// Locked (optional) sets podcast:locked, asking platforms not to import
+	// the feed elsewhere
+	Locked *bool `json:"locked"`
+
+	Medium  podcast.PodcastMedium `json:"medium"`
+	License string                `json:"license"`
+	Funding []Funding             `json:"funding"`
+}
+
+// Category is an Apple Podcasts category of the show, see
+// [podcast.ItunesCategory]
+type Category struct {
+	Name        string `json:"name"`
+	Subcategory string `json:"subcategory"`
+}
+
+// Funding is a link to donate to the show, see [podcast.PodcastFunding]
+type Funding struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// EpisodeManifest is the optional sidecar file of an episode, named after its
+// media file with a .json, .yaml, .yml or .toml extension. Fields that are
+// set override what's read from the media file's tags.
+type EpisodeManifest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	// Date is the publication date, in RFC 3339 or YYYY-MM-DD form
+	Date string `json:"date"`
+
+	Episode  int                 `json:"episode"`
+	Season   int                 `json:"season"`
+	Type     podcast.EpisodeType `json:"type"`
+	Explicit *bool               `json:"explicit"`
+
+	// GUID overrides the derived GUID, for episodes published before the
+	// feed was generated
+	GUID string `json:"guid"`
+
+	Link string `json:"link"`
+
+	// Image is the episode artwork, a URL or a path relative to the
+	// manifest's BaseURL
+	Image string `json:"image"`
+}
+
+// ReadManifest reads a show or episode manifest from fsys, picking the format
+// from the extension of name.
+func ReadManifest(fsys fs.FS, name string, v any) error {
+	format, ok := config.FormatOf(name)
+	if !ok {
+		return fmt.Errorf("dirfeed: unknown format of %s, expected one of %s", name, strings.Join(config.Extensions, ", "))
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := config.Unmarshal(data, format, v); err != nil {
+		return fmt.Errorf("dirfeed: %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// windows1252 maps the bytes 0x80-0x9F of Windows-1252 to runes, where
+// ISO-8859-1 has control characters. The rest of the two are the same.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+// xmlEncoding finds the encoding in an XML declaration
+var xmlEncoding = regexp.MustCompile(`^<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// detectCharset finds the character set of a feed from its byte order mark,
+// Content-Type header or XML declaration, in that order, defaulting to UTF-8
+// as XML does
+func detectCharset(body []byte, contentType string) string {
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return strings.ToLower(params["charset"])
+	}
+	if match := xmlEncoding.FindSubmatch(body); match != nil {
+		return strings.ToLower(string(match[1]))
+	}
+	return "utf-8"
+}
+
+// toUTF8 converts the body from charset to UTF-8, and declares it as UTF-8 so
+// the XML decoder doesn't need to convert it again
+func toUTF8(body []byte, charset string) ([]byte, error) {
+	var converted []byte
+	switch charset {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		converted = bytes.TrimPrefix(body, []byte{0xEF, 0xBB, 0xBF})
+		if !utf8.Valid(converted) {
+			// Feeds that claim UTF-8 but aren't are usually Windows-1252
+			converted = decodeSingleByte(converted, true)
+		}
+	case "iso-8859-1", "latin1", "latin-1", "l1", "iso_8859-1":
+		converted = decodeSingleByte(body, false)
+	case "windows-1252", "cp1252", "x-cp1252":
+		converted = decodeSingleByte(body, true)
+	case "utf-16", "utf-16be", "utf-16le":
+		converted = decodeUTF16(body, charset)
+	default:
+		return nil, fmt.Errorf("fetch: unsupported charset %q", charset)
+	}
+
+	if match := xmlEncoding.FindSubmatchIndex(converted); match != nil {
+		declared := append([]byte(nil), converted[:match[2]]...)
+		declared = append(declared, "UTF-8"...)
+		converted = append(declared, converted[match[3]:]...)
+	}
+	return converted, nil
+}
+
+func decodeSingleByte(body []byte, cp1252 bool) []byte {
+	var b bytes.Buffer
+	b.Grow(len(body))
+	for _, c := range body {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case cp1252 && c < 0xA0:
+			b.WriteRune(windows1252[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.Bytes()
+}
+
+func decodeUTF16(body []byte, charset string) []byte {
+	bigEndian := charset == "utf-16be"
+	switch {
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		bigEndian, body = true, body[2:]
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		bigEndian, body = false, body[2:]
+	}
+
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(body[2*i])<<8 | uint16(body[2*i+1])
+		} else {
+			units[i] = uint16(body[2*i+1])<<8 | uint16(body[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
@@ -0,0 +1,301 @@
+// Package fetch downloads podcast feeds over HTTP the way a crawler should:
+// with conditional requests, compression, charset conversion, limits, and
+// tracking of feeds that have moved.
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// Defaults for the zero [Client]
+const (
+	DefaultTimeout     = 30 * time.Second
+	DefaultMaxBodySize = 32 << 20
+	DefaultUserAgent   = "podcast-fetch/1.0 (+https://github.com/jaydenmilne/podcast)"
+	maxRedirects       = 10
+)
+
+var (
+	// ErrTooLarge is returned for feeds bigger than [Client.MaxBodySize]
+	ErrTooLarge = errors.New("fetch: feed is too large")
+
+	// ErrUnsupportedEncoding is returned for responses compressed in a way
+	// the client can't decode, such as zstd. The client doesn't ask for
+	// these, but some servers send them anyway.
+	ErrUnsupportedEncoding = errors.New("fetch: unsupported content encoding")
+)
+
+// StatusError is returned for responses other than 200 OK and 304 Not
+// Modified
+type StatusError struct {
+	StatusCode int
+	Status     string
+
+	// RetryAfter is how long the server asked clients to wait, from the
+	// Retry-After header of 429 and 503 responses, or 0
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return "fetch: " + e.Status
+}
+
+// Gone reports if the server says the feed is gone for good
+func (e *StatusError) Gone() bool {
+	return e.StatusCode == http.StatusGone
+}
+
+// Client fetches feeds. The zero value is ready to use.
+type Client struct {
+	// HTTPClient makes the requests. Its CheckRedirect is replaced to track
+	// redirects. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+
+	// UserAgent defaults to [DefaultUserAgent]. Some hosts block requests
+	// without one.
+	UserAgent string
+
+	// Timeout limits each fetch, including reading the body. Defaults to
+	// [DefaultTimeout].
+	Timeout time.Duration
+
+	// MaxBodySize limits the size of a feed, after decompression. Defaults to
+	// [DefaultMaxBodySize].
+	MaxBodySize int64
+}
+
+// Validators identify the version of a feed from an earlier fetch, for a
+// conditional request
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Redirect is a redirect followed while fetching a feed
+type Redirect struct {
+	From, To   string
+	StatusCode int
+}
+
+// Permanent reports if the redirect is a 301 or 308, which means subscribers
+// should use the new URL from now on
+func (r Redirect) Permanent() bool {
+	return r.StatusCode == http.StatusMovedPermanently || r.StatusCode == http.StatusPermanentRedirect
+}
+
+// Result is a fetched feed and what was learned fetching it
+type Result struct {
+	// Feed is the parsed feed, or nil if NotModified
+	Feed *podcast.RSSPodcast
+
+	// NotModified is set when the feed hasn't changed since the fetch the
+	// validators came from
+	NotModified bool
+
+	// URL is where the feed was fetched from, after redirects
+	URL string
+
+	// Redirects lists the redirects that were followed, in order
+	Redirects []Redirect
+
+	// MovedTo is where the feed should be fetched from from now on, if it
+	// moved: the URL at the end of the permanent redirects from the
+	// requested URL, or the feed's itunes:new-feed-url. It is empty if the
+	// feed didn't move.
+	MovedTo string
+
+	// Validators are for the next conditional fetch. They are carried over
+	// from the request when the feed wasn't modified.
+	Validators Validators
+
+	// Charset is the character set the feed was converted from
+	Charset string
+
+	// Size is the size of the feed in bytes, after decompression
+	Size int
+
+	// FetchedAt is when the response arrived
+	FetchedAt time.Time
+}
+
+// Fetch downloads and parses the feed at feedURL. Pass the validators of the
+// last fetch to only download the feed if it changed.
+func (c *Client) Fetch(ctx context.Context, feedURL string, validators Validators) (*Result, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Accept", "application/rss+xml, application/xml;q=0.9, text/xml;q=0.9, */*;q=0.1")
+	// Asking explicitly stops the transport decompressing transparently,
+	// which it would do without applying the size limit
+	request.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	if validators.ETag != "" {
+		request.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		request.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	result := &Result{URL: feedURL}
+	client := http.DefaultClient
+	if c.HTTPClient != nil {
+		client = c.HTTPClient
+	}
+	tracking := *client
+	tracking.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("fetch: stopped after %d redirects", maxRedirects)
+		}
+		result.Redirects = append(result.Redirects, Redirect{
+			From:       via[len(via)-1].URL.String(),
+			To:         next.URL.String(),
+			StatusCode: next.Response.StatusCode,
+		})
+		return nil
+	}
+
+	response, err := tracking.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	result.FetchedAt = time.Now()
+	result.URL = response.Request.URL.String()
+	for _, redirect := range result.Redirects {
+		if !redirect.Permanent() {
+			break
+		}
+		result.MovedTo = redirect.To
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		result.NotModified = true
+		result.Validators = validators
+		if etag := response.Header.Get("ETag"); etag != "" {
+			result.Validators.ETag = etag
+		}
+		return result, nil
+	default:
+		return nil, &StatusError{
+			StatusCode: response.StatusCode,
+			Status:     response.Status,
+			RetryAfter: retryAfter(response.Header.Get("Retry-After"), result.FetchedAt),
+		}
+	}
+	result.Validators = Validators{
+		ETag:         response.Header.Get("ETag"),
+		LastModified: response.Header.Get("Last-Modified"),
+	}
+
+	body, err := c.readBody(response)
+	if err != nil {
+		return nil, err
+	}
+	result.Size = len(body)
+	result.Charset = detectCharset(body, response.Header.Get("Content-Type"))
+	body, err = toUTF8(body, result.Charset)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Feed, err = podcast.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %s: %w", result.URL, err)
+	}
+	if moved := strings.TrimSpace(result.Feed.Channel.ItunesNewFeedURL); moved != "" && moved != result.URL && moved != feedURL {
+		result.MovedTo = moved
+	}
+	return result, nil
+}
+
+// readBody reads the decompressed body, up to the size limit
+func (c *Client) readBody(response *http.Response) ([]byte, error) {
+	limit := c.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+
+	var reader io.Reader = response.Body
+	switch encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	case "deflate":
+		// Servers disagree on whether deflate has a zlib header, so accept
+		// both
+		buffered := bufio.NewReader(response.Body)
+		if header, err := buffered.Peek(2); err == nil && header[0]&0x0F == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, fmt.Errorf("fetch: %w", err)
+			}
+			defer zr.Close()
+			reader = zr
+		} else {
+			fr := flate.NewReader(buffered)
+			defer fr.Close()
+			reader = fr
+		}
+	case "br":
+		reader = brotli.NewReader(response.Body)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedEncoding, encoding)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch: reading %s: %w", response.Request.URL, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
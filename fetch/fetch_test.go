@@ -0,0 +1,202 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/google/go-cmp/cmp"
+)
+
+const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+<title>Caf&#233; Talk</title>
+<item><title>Episode 1</title><enclosure url="https://example.com/1.mp3" length="1" type="audio/mpeg"/></item>
+</channel>
+</rss>
+`
+
+func TestFetchConditional(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client()}
+	result, err := client.Fetch(context.Background(), server.URL, Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Feed.Channel.Title != "Café Talk" || len(result.Feed.Channel.Items) != 1 {
+		t.Errorf("unexpected feed %+v", result.Feed.Channel.Channel)
+	}
+	expected := Validators{ETag: `"v1"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT"}
+	if diff := cmp.Diff(expected, result.Validators); diff != "" {
+		t.Errorf("unexpected validators (-want +got):\n%s", diff)
+	}
+
+	result, err = client.Fetch(context.Background(), server.URL, result.Validators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.NotModified || result.Feed != nil {
+		t.Errorf("expected not modified, got %+v", result)
+	}
+	if diff := cmp.Diff(expected, result.Validators); diff != "" {
+		t.Errorf("expected the validators to be kept (-want +got):\n%s", diff)
+	}
+}
+
+func TestFetchEncoding(t *testing.T) {
+	latin1 := strings.Replace(strings.Replace(feed, "UTF-8", "ISO-8859-1", 1), "&#233;", "\xe9", 1)
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(feed))
+	gz.Close()
+	var brotlied bytes.Buffer
+	br := brotli.NewWriter(&brotlied)
+	br.Write([]byte(feed))
+	br.Close()
+
+	testCases := []struct {
+		name            string
+		body            []byte
+		contentType     string
+		contentEncoding string
+		charset         string
+		err             error
+	}{
+		{"utf-8", []byte(feed), "application/rss+xml", "", "utf-8", nil},
+		{"declared latin-1", []byte(latin1), "application/rss+xml", "", "iso-8859-1", nil},
+		{"header latin-1", []byte(latin1), "text/xml; charset=ISO-8859-1", "", "iso-8859-1", nil},
+		{"utf-16", utf16LE("\uFEFF" + strings.Replace(feed, "UTF-8", "UTF-16", 1)), "text/xml", "", "utf-16le", nil},
+		{"gzip", gzipped.Bytes(), "application/rss+xml", "gzip", "utf-8", nil},
+		{"brotli", brotlied.Bytes(), "application/rss+xml", "br", "utf-8", nil},
+		{"zstd", []byte("not really zstd"), "application/rss+xml", "zstd", "", ErrUnsupportedEncoding},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") != "gzip, deflate, br" {
+					t.Errorf("unexpected Accept-Encoding %q", r.Header.Get("Accept-Encoding"))
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				if tc.contentEncoding != "" {
+					w.Header().Set("Content-Encoding", tc.contentEncoding)
+				}
+				w.Write(tc.body)
+			}))
+			defer server.Close()
+
+			result, err := (&Client{HTTPClient: server.Client()}).Fetch(context.Background(), server.URL, Validators{})
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Charset != tc.charset {
+				t.Errorf("expected charset %s, got %s", tc.charset, result.Charset)
+			}
+			if result.Feed.Channel.Title != "Café Talk" {
+				t.Errorf("unexpected title %q", result.Feed.Channel.Title)
+			}
+		})
+	}
+}
+
+func utf16LE(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		b = append(b, byte(r), byte(r>>8))
+	}
+	return b
+}
+
+func TestFetchRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/old", http.RedirectHandler("/older", http.StatusMovedPermanently))
+	mux.Handle("/older", http.RedirectHandler("/temporary", http.StatusFound))
+	mux.Handle("/temporary", http.RedirectHandler("/feed", http.StatusMovedPermanently))
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Replace(feed, "<title>", "<itunes:new-feed-url>https://example.com/new</itunes:new-feed-url><title>", 1)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := &Client{HTTPClient: server.Client()}
+
+	result, err := client.Fetch(context.Background(), server.URL+"/old", Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Redirect{
+		{server.URL + "/old", server.URL + "/older", http.StatusMovedPermanently},
+		{server.URL + "/older", server.URL + "/temporary", http.StatusFound},
+		{server.URL + "/temporary", server.URL + "/feed", http.StatusMovedPermanently},
+	}
+	if diff := cmp.Diff(expected, result.Redirects); diff != "" {
+		t.Errorf("unexpected redirects (-want +got):\n%s", diff)
+	}
+	// Only the redirects before the temporary one count
+	if result.MovedTo != server.URL+"/older" || result.URL != server.URL+"/feed" {
+		t.Errorf("unexpected URLs: moved to %s, fetched %s", result.MovedTo, result.URL)
+	}
+
+	result, err = client.Fetch(context.Background(), server.URL+"/moved", Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.MovedTo != "https://example.com/new" {
+		t.Errorf("expected the new feed URL, got %q", result.MovedTo)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/busy":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.Write([]byte(feed))
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client()}
+	_, err := client.Fetch(context.Background(), server.URL+"/busy", Validators{})
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusServiceUnavailable || status.RetryAfter.Seconds() != 120 {
+		t.Errorf("expected a 503 with Retry-After, got %v", err)
+	}
+	_, err = client.Fetch(context.Background(), server.URL+"/gone", Validators{})
+	if !errors.As(err, &status) || !status.Gone() {
+		t.Errorf("expected gone, got %v", err)
+	}
+
+	client.MaxBodySize = 100
+	if _, err := client.Fetch(context.Background(), server.URL, Validators{}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+}
@@ -2,4 +2,16 @@ module github.com/jaydenmilne/podcast
 
 go 1.21
 
-require github.com/google/go-cmp v0.7.0
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/ProtonMail/go-crypto v1.1.6
+	github.com/andybalholm/brotli v1.1.1
+	github.com/google/go-cmp v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/cloudflare/circl v1.3.7 // indirect
+	golang.org/x/crypto v0.17.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+)
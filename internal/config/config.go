@@ -0,0 +1,95 @@
+// Package config decodes the JSON, YAML and TOML files dirfeed reads
+// manifests from into Go values.
+//
+// YAML and TOML documents are parsed with gopkg.in/yaml.v3 and
+// github.com/BurntSushi/toml into maps, slices and scalars, then decoded with
+// encoding/json, so the target's json struct tags apply to every format.
+// Values keep the type the format gives them: in YAML, title: 2024 is a
+// number, and can't be decoded into a string field unless quoted.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the syntax of a configuration file
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	TOML Format = "toml"
+)
+
+// Extensions are the file extensions recognized by [FormatOf]
+var Extensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// FormatOf returns the format of a file from its extension.
+func FormatOf(name string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return JSON, true
+	case ".yaml", ".yml":
+		return YAML, true
+	case ".toml":
+		return TOML, true
+	}
+	return "", false
+}
+
+// Unmarshal decodes data in the given format into v, as json.Unmarshal would.
+func Unmarshal(data []byte, format Format, v any) error {
+	switch format {
+	case JSON:
+		return json.Unmarshal(data, v)
+	case YAML, TOML:
+	default:
+		return fmt.Errorf("config: unknown format %q", format)
+	}
+
+	var parsed any
+	if format == YAML {
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+	} else {
+		var table map[string]any
+		if err := toml.Unmarshal(data, &table); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		parsed = table
+	}
+
+	// Reuse encoding/json for the mapping onto v
+	encoded, err := json.Marshal(parsed)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	return decoder.Decode(v)
+}
+
+// ReadFile decodes the named file into v, picking the format from its
+// extension.
+func ReadFile(name string, v any) error {
+	format, ok := FormatOf(name)
+	if !ok {
+		return fmt.Errorf("config: unknown format of %s, expected one of %s", name, strings.Join(Extensions, ", "))
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := Unmarshal(data, format, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
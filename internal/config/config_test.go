@@ -0,0 +1,210 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type show struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Explicit    bool     `json:"explicit"`
+	Episodes    int      `json:"episodes"`
+	Rating      float64  `json:"rating"`
+	Keywords    []string `json:"keywords"`
+	Owner       struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"owner"`
+	Funding []struct {
+		URL  string `json:"url"`
+		Text string `json:"text"`
+	} `json:"funding"`
+	Missing *string `json:"missing"`
+}
+
+func expectedShow() show {
+	var expected show
+	expected.Title = "The Go Podcast: \"Episodes\" # not a comment"
+	expected.Description = "A show about Go.\nWith two lines.\n"
+	expected.Explicit = true
+	expected.Episodes = 42
+	expected.Rating = 4.5
+	expected.Keywords = []string{"go", "programming", "it's"}
+	expected.Owner.Name = "Jayden"
+	expected.Owner.Email = "jayden@example.com"
+	expected.Funding = []struct {
+		URL  string `json:"url"`
+		Text string `json:"text"`
+	}{
+		{URL: "https://example.com/donate", Text: "Support the show"},
+		{URL: "https://example.com/patreon", Text: "Patreon"},
+	}
+	return expected
+}
+
+func TestUnmarshal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   Format
+		document string
+	}{
+		{"json", JSON, `{
+			"title": "The Go Podcast: \"Episodes\" # not a comment",
+			"description": "A show about Go.\nWith two lines.\n",
+			"explicit": true,
+			"episodes": 42,
+			"rating": 4.5,
+			"keywords": ["go", "programming", "it's"],
+			"owner": {"name": "Jayden", "email": "jayden@example.com"},
+			"funding": [
+				{"url": "https://example.com/donate", "text": "Support the show"},
+				{"url": "https://example.com/patreon", "text": "Patreon"}
+			]
+		}`},
+		{"yaml", YAML, `---
+# The show
+title: "The Go Podcast: \"Episodes\" # not a comment"
+description: |
+  A show about Go.
+  With two lines.
+explicit: true # a comment
+episodes: 42
+rating: 4.5
+keywords: [go, programming, 'it''s']
+owner:
+  name: Jayden
+  email: jayden@example.com
+
+funding:
+- url: https://example.com/donate
+  text: Support the show
+-   url: https://example.com/patreon
+    text: Patreon
+missing: ~
+`},
+		{"yaml nested sequence", YAML, `
+title: 'The Go Podcast: "Episodes" # not a comment'
+description: >-
+  A show about Go.
+
+  With two lines.
+explicit: TRUE
+episodes: 0x2A
+rating: 4.5
+keywords:
+  - go
+  - "programming"
+  - it's
+owner: {name: Jayden, email: jayden@example.com}
+funding:
+  - {url: "https://example.com/donate", text: Support the show}
+  -
+    url: https://example.com/patreon
+    text: Patreon
+`},
+		{"toml", TOML, `# The show
+title = "The Go Podcast: \"Episodes\" # not a comment"
+description = """
+A show about Go.
+With two lines.
+"""
+explicit = true # a comment
+episodes = 4_2
+rating = 4.5
+keywords = [
+  "go",
+  'programming', # trailing comma
+  "it's",
+]
+owner = { name = "Jayden", email = "jayden@example.com" }
+
+[[funding]]
+url = "https://example.com/donate"
+text = "Support the show"
+
+[[funding]]
+url = 'https://example.com/patreon'
+text = "Patreon"
+`},
+		{"toml tables", TOML, `
+title = 'The Go Podcast: "Episodes" # not a comment'
+description = """A show about Go.\nWith \
+    two lines.\n"""
+explicit = true
+episodes = 42
+rating = 4.5
+keywords = ["go", "programming", "it's"]
+
+[owner]
+name = "Jayden"
+"email" = "jayden@example.com"
+
+[[funding]]
+url = "https://example.com/donate"
+text = "Support the show"
+[[funding]]
+url = "https://example.com/patreon"
+text = "Patreon"
+`},
+	}
+
+	expected := expectedShow()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual show
+			if err := Unmarshal([]byte(tc.document), tc.format, &actual); err != nil {
+				t.Fatal(err)
+			}
+			expected := expected
+			if tc.format == YAML && tc.name != "yaml" {
+				// Folding joins the lines, and - strips the final new line
+				expected.Description = "A show about Go.\nWith two lines."
+			}
+			if diff := cmp.Diff(expected, actual); diff != "" {
+				t.Errorf("Unmarshal() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   Format
+		document string
+	}{
+		{"yaml bad indentation", YAML, "title: a\n  description: b\n"},
+		{"yaml duplicate key", YAML, "title: a\ntitle: b\n"},
+		{"yaml tab indentation", YAML, "owner:\n\tname: a\n"},
+		{"yaml unterminated", YAML, "title: \"a\n"},
+		{"yaml wrong type", YAML, "title: 2024\n"},
+		{"toml duplicate table", TOML, "[owner]\n[owner]\n"},
+		{"toml duplicate key", TOML, "title = 'a'\ntitle = 'b'\n"},
+		{"toml missing equals", TOML, "title 'a'\n"},
+		{"toml trailing content", TOML, "title = 'a' 'b'\n"},
+		{"toml unterminated array", TOML, "keywords = ['a'\n"},
+		{"unknown format", "ini", "title=a"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual show
+			if err := Unmarshal([]byte(tc.document), tc.format, &actual); err == nil {
+				t.Errorf("expected an error, got %+v", actual)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for name, expected := range map[string]Format{"podcast.json": JSON, "podcast.YAML": YAML, "a/b.yml": YAML, "podcast.toml": TOML} {
+		if format, ok := FormatOf(name); !ok || format != expected {
+			t.Errorf("expected %s for %s, got %q", expected, name, format)
+		}
+	}
+	if _, ok := FormatOf("podcast.xml"); ok {
+		t.Errorf("expected xml to be unknown")
+	}
+}
@@ -0,0 +1,47 @@
+// Package uuid parses UUIDs and derives the name based (version 5) UUIDs
+// that podcast:guid is made of from feed URLs.
+package uuid
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// UUID is a 128 bit universally unique identifier, see [RFC 4122]
+//
+// [RFC 4122]: https://www.rfc-editor.org/rfc/rfc4122
+type UUID [16]byte
+
+// Parse parses a UUID in its canonical form, such as
+// ead4c236-bf58-58c6-a2c6-a6b28d128cb6
+func Parse(s string) (UUID, error) {
+	var u UUID
+	raw := strings.ReplaceAll(s, "-", "")
+	if len(s) != 36 || len(raw) != 32 {
+		return u, fmt.Errorf("uuid: invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(raw)); err != nil {
+		return u, fmt.Errorf("uuid: invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// NewV5 returns the version 5 UUID of name within namespace
+func NewV5(namespace UUID, name string) UUID {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+
+	var u UUID
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0F | 0x50 // version 5
+	u[8] = u[8]&0x3F | 0x80 // RFC 4122 variant
+	return u
+}
+
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
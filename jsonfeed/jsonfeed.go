@@ -0,0 +1,244 @@
+// Package jsonfeed provides types for JSON Feed 1.1, and converts them to and
+// from podcasts.
+//
+// See [spec]
+//
+// [spec]: https://www.jsonfeed.org/version/1.1/
+package jsonfeed
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Version is the URL of the version of the spec these types follow
+const Version = "https://jsonfeed.org/version/1.1"
+
+// Feed is the top level object of a JSON Feed
+type Feed struct {
+	// Version (required) is the URL of the version of the format the feed
+	// uses.
+	Version string `json:"version"`
+
+	// Title (required) is the name of the feed.
+	Title string `json:"title"`
+
+	// HomePageURL (optional but strongly recommended) is the URL of the
+	// resource that the feed describes.
+	HomePageURL string `json:"home_page_url,omitempty"`
+
+	// FeedURL (optional but strongly recommended) is the URL of the feed, and
+	// serves as the unique identifier for the feed.
+	FeedURL string `json:"feed_url,omitempty"`
+
+	// Description (optional) provides more detail, beyond the title, on what
+	// the feed is about.
+	Description string `json:"description,omitempty"`
+
+	// Icon (optional) is the URL of an image for the feed, which should be
+	// square and relatively large, such as 512 x 512 pixels.
+	Icon string `json:"icon,omitempty"`
+
+	// Favicon (optional) is the URL of an image for the feed, which should be
+	// square and relatively small, such as 64 x 64 pixels.
+	Favicon string `json:"favicon,omitempty"`
+
+	Authors []Author `json:"authors,omitempty"`
+
+	// Language (optional) is the primary language of the feed, as a language
+	// tag such as en-US.
+	Language string `json:"language,omitempty"`
+
+	// Expired (optional) says whether or not the feed is finished, and will
+	// never be updated again.
+	Expired bool `json:"expired,omitempty"`
+
+	Items []Item `json:"items"`
+}
+
+// Item is an entry of a feed
+type Item struct {
+	// ID (required) is unique for the item in the feed, and never changes.
+	ID string `json:"id"`
+
+	// URL (optional) is the URL of the resource described by the item.
+	URL string `json:"url,omitempty"`
+
+	Title string `json:"title,omitempty"`
+
+	// ContentHTML and ContentText (one is required) are the HTML and plain
+	// text content of the item.
+	ContentHTML string `json:"content_html,omitempty"`
+	ContentText string `json:"content_text,omitempty"`
+
+	// Summary (optional) is a plain text sentence or two describing the item.
+	Summary string `json:"summary,omitempty"`
+
+	// Image (optional) is the URL of the main image of the item.
+	Image string `json:"image,omitempty"`
+
+	// DatePublished and DateModified (optional) are RFC 3339 timestamps
+	DatePublished string `json:"date_published,omitempty"`
+	DateModified  string `json:"date_modified,omitempty"`
+
+	Authors []Author `json:"authors,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+
+	// Attachments (optional) are related resources, such as podcast episodes.
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Author is the author of a feed or item
+type Author struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// Attachment is a resource related to an item, such as its audio
+type Attachment struct {
+	// URL (required) is the location of the attachment.
+	URL string `json:"url"`
+
+	// MIMEType (required) is the type of the attachment, such as audio/mpeg.
+	MIMEType string `json:"mime_type"`
+
+	// Title (optional) is a name for the attachment. Attachments with the
+	// same title are considered alternate representations of the same thing.
+	Title string `json:"title,omitempty"`
+
+	SizeInBytes       int     `json:"size_in_bytes,omitempty"`
+	DurationInSeconds float64 `json:"duration_in_seconds,omitempty"`
+}
+
+// FromPodcast converts a podcast into a JSON Feed. The enclosure and
+// alternate enclosures of an episode become its attachments. feedURL is
+// where the JSON Feed will be published, and may be "".
+func FromPodcast(pod *podcast.RSSPodcast, feedURL string) *Feed {
+	channel := &pod.Channel
+	feed := &Feed{
+		Version:     Version,
+		Title:       channel.Title,
+		HomePageURL: channel.Link,
+		FeedURL:     feedURL,
+		Description: channel.Description.Value,
+		Icon:        channel.ItunesImage.Href,
+		Language:    channel.Language,
+		Expired:     channel.ItunesComplete == podcast.ItunesYesValue,
+		Items:       []Item{},
+	}
+	if channel.ItunesAuthor != "" {
+		feed.Authors = []Author{{Name: channel.ItunesAuthor}}
+	}
+
+	for i := range channel.Items {
+		episode := &channel.Items[i]
+		item := Item{
+			ID:    episode.Link,
+			URL:   episode.Link,
+			Title: episode.Title,
+		}
+		if episode.GUID != nil {
+			item.ID = episode.GUID.Value
+		}
+		if episode.Description != nil {
+			item.ContentHTML = episode.Description.Value
+		}
+		if episode.ItunesImage != nil {
+			item.Image = episode.ItunesImage.Href
+		}
+		if published, err := episode.PubDate.Time(); err == nil {
+			item.DatePublished = published.Format(time.RFC3339)
+		}
+		if episode.Author != "" {
+			item.Authors = []Author{{Name: episode.Author}}
+		}
+
+		duration, _ := episode.Duration()
+		if episode.Enclosure != nil {
+			item.Attachments = append(item.Attachments, Attachment{
+				URL:               episode.Enclosure.URL,
+				MIMEType:          episode.Enclosure.Type,
+				SizeInBytes:       episode.Enclosure.Length,
+				DurationInSeconds: duration.Seconds(),
+			})
+		}
+		for _, alternate := range episode.PodcastAlternateEnclosures {
+			for _, source := range alternate.Source {
+				item.Attachments = append(item.Attachments, Attachment{
+					URL:               source.URI,
+					MIMEType:          alternate.Type,
+					Title:             alternate.Title,
+					SizeInBytes:       alternate.Length,
+					DurationInSeconds: duration.Seconds(),
+				})
+			}
+		}
+
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed
+}
+
+// Podcast converts the JSON Feed into a podcast, the reverse of
+// [FromPodcast]. The first attachment of an item becomes the episode's
+// enclosure.
+func (f *Feed) Podcast() *podcast.RSSPodcast {
+	pod := &podcast.RSSPodcast{Version: rss.RSSVersion}
+	channel := &pod.Channel
+
+	channel.Title = f.Title
+	channel.Link = f.HomePageURL
+	channel.Description.Value = f.Description
+	channel.Language = f.Language
+	channel.ItunesImage.Href = f.Icon
+	if len(f.Authors) > 0 {
+		channel.ItunesAuthor = f.Authors[0].Name
+	}
+	if f.Expired {
+		channel.ItunesComplete = podcast.ItunesYesValue
+	}
+
+	for _, item := range f.Items {
+		var episode podcast.Episode
+		episode.Title = item.Title
+		episode.Link = item.URL
+
+		isPermaLink := item.ID == item.URL
+		episode.GUID = &rss.GUID{Value: item.ID, IsPermaLink: &isPermaLink}
+
+		description := item.ContentHTML
+		if description == "" {
+			description = item.ContentText
+		}
+		if description != "" {
+			episode.Description = &rss.Description{Value: description}
+		}
+		if item.Image != "" {
+			episode.ItunesImage = &podcast.ItunesImageTag{Href: item.Image}
+		}
+		if published, err := time.Parse(time.RFC3339, strings.TrimSpace(item.DatePublished)); err == nil {
+			episode.PubDate = rss.NewRFC2822Date(published)
+		}
+		if len(item.Authors) > 0 {
+			episode.Author = item.Authors[0].Name
+		}
+		if len(item.Attachments) > 0 {
+			attachment := item.Attachments[0]
+			episode.Enclosure = &rss.Enclosure{URL: attachment.URL, Length: attachment.SizeInBytes, Type: attachment.MIMEType}
+			if attachment.DurationInSeconds > 0 {
+				episode.ItunesDuration = strconv.Itoa(int(math.Round(attachment.DurationInSeconds)))
+			}
+		}
+
+		channel.Items = append(channel.Items, episode)
+	}
+
+	return pod
+}
@@ -0,0 +1,77 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func testPodcast() *podcast.RSSPodcast {
+	isPermaLink := false
+	pod := &podcast.RSSPodcast{Version: rss.RSSVersion}
+	pod.Channel.Title = "The Go Podcast"
+	pod.Channel.Link = "https://example.com/"
+	pod.Channel.Description = rss.Description{Value: "A show about Go."}
+	pod.Channel.Language = "en"
+	pod.Channel.ItunesAuthor = "Jayden"
+	pod.Channel.ItunesImage.Href = "https://example.com/cover.jpg"
+	pod.Channel.ItunesComplete = podcast.ItunesYesValue
+	pod.Channel.Items = []podcast.Episode{
+		{
+			Item: rss.Item{
+				Title:       "Episode 1",
+				Link:        "https://example.com/1",
+				Description: &rss.Description{Value: "<p>The first one.</p>"},
+				Enclosure:   &rss.Enclosure{URL: "https://example.com/1.mp3", Length: 1000, Type: "audio/mpeg"},
+				GUID:        &rss.GUID{Value: "episode-1", IsPermaLink: &isPermaLink},
+				PubDate:     "Mon, 01 Jan 2024 00:00:00 +0000",
+			},
+			ItunesDuration: "3723",
+			ItunesImage:    &podcast.ItunesImageTag{Href: "https://example.com/1.jpg"},
+		},
+	}
+	return pod
+}
+
+func TestFromPodcast(t *testing.T) {
+	expected := `{
+	"version": "https://jsonfeed.org/version/1.1",
+	"title": "The Go Podcast",
+	"home_page_url": "https://example.com/",
+	"feed_url": "https://example.com/feed.json",
+	"description": "A show about Go.",
+	"icon": "https://example.com/cover.jpg",
+	"authors": [{"name": "Jayden"}],
+	"language": "en",
+	"expired": true,
+	"items": [
+		{
+			"id": "episode-1",
+			"url": "https://example.com/1",
+			"title": "Episode 1",
+			"content_html": "<p>The first one.</p>",
+			"image": "https://example.com/1.jpg",
+			"date_published": "2024-01-01T00:00:00Z",
+			"attachments": [
+				{"url": "https://example.com/1.mp3", "mime_type": "audio/mpeg", "size_in_bytes": 1000, "duration_in_seconds": 3723}
+			]
+		}
+	]
+}`
+	var expectedFeed Feed
+	if err := json.Unmarshal([]byte(expected), &expectedFeed); err != nil {
+		t.Fatal(err)
+	}
+
+	actual := FromPodcast(testPodcast(), "https://example.com/feed.json")
+	if diff := cmp.Diff(&expectedFeed, actual); diff != "" {
+		t.Errorf("FromPodcast() mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(testPodcast(), actual.Podcast()); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
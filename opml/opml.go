@@ -0,0 +1,138 @@
+// Package opml provides types for OPML 2.0 subscription lists, which podcast
+// apps use to import and export the shows they follow, and converts them to
+// and from podcast:remoteItem lists.
+//
+// See [spec]
+//
+// [spec]: http://opml.org/spec2.opml
+package opml
+
+import (
+	"encoding/xml"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Version is the version of the spec these types follow
+const Version = "2.0"
+
+// OPML is the root element of an OPML document
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+
+	// Outlines (required) are the contents of the body, of which there must
+	// be at least one.
+	Outlines []Outline `xml:"body>outline"`
+}
+
+// Head is the metadata of the document
+type Head struct {
+	Title string `xml:"title,omitempty"`
+
+	// DateCreated and DateModified are RFC 822 dates
+	DateCreated  rss.RFC2822Date `xml:"dateCreated,omitempty"`
+	DateModified rss.RFC2822Date `xml:"dateModified,omitempty"`
+
+	OwnerName  string `xml:"ownerName,omitempty"`
+	OwnerEmail string `xml:"ownerEmail,omitempty"`
+	OwnerID    string `xml:"ownerId,omitempty"`
+}
+
+// Outline is an entry of the document, which for subscription lists is a feed
+// or a folder of feeds
+type Outline struct {
+	// Text (required) is what's displayed for the outline.
+	Text string `xml:"text,attr"`
+
+	// Type is rss for subscriptions to a feed.
+	Type string `xml:"type,attr,omitempty"`
+
+	Title string `xml:"title,attr,omitempty"`
+
+	// XMLURL (required for rss) is the URL of the feed.
+	XMLURL string `xml:"xmlUrl,attr,omitempty"`
+
+	// HTMLURL is the website of the feed.
+	HTMLURL     string `xml:"htmlUrl,attr,omitempty"`
+	Description string `xml:"description,attr,omitempty"`
+	Language    string `xml:"language,attr,omitempty"`
+
+	// Outlines are the children of a folder
+	Outlines []Outline `xml:"outline,omitempty"`
+}
+
+// New returns a subscription list of the outlines
+func New(title string, outlines ...Outline) *OPML {
+	return &OPML{Version: Version, Head: Head{Title: title}, Outlines: outlines}
+}
+
+// Subscription returns the outline subscribing to the podcast, published at
+// feedURL.
+func Subscription(pod *podcast.RSSPodcast, feedURL string) Outline {
+	return Outline{
+		Text:        pod.Channel.Title,
+		Type:        "rss",
+		Title:       pod.Channel.Title,
+		XMLURL:      feedURL,
+		HTMLURL:     pod.Channel.Link,
+		Description: pod.Channel.Description.Value,
+		Language:    pod.Channel.Language,
+	}
+}
+
+// Feeds returns the outlines subscribing to a feed, including those in
+// folders, in document order.
+func (o *OPML) Feeds() []Outline {
+	var feeds []Outline
+	var visit func(outlines []Outline)
+	visit = func(outlines []Outline) {
+		for _, outline := range outlines {
+			if outline.XMLURL != "" {
+				feeds = append(feeds, outline)
+			}
+			visit(outline.Outlines)
+		}
+	}
+	visit(o.Outlines)
+	return feeds
+}
+
+// FromPodcast converts a podcast into a subscription list. A list of podcasts
+// (a feed whose podcast:medium is podcastL) lists the feeds of its channel's
+// podcast:remoteItem tags, any other feed is a list of just itself, published
+// at feedURL.
+func FromPodcast(pod *podcast.RSSPodcast, feedURL string) *OPML {
+	if pod.Channel.PodcastMedium != podcast.MediumPodcastList {
+		return New(pod.Channel.Title, Subscription(pod, feedURL))
+	}
+
+	list := New(pod.Channel.Title)
+	for _, item := range pod.Channel.PodcastRemoteItems {
+		if item.FeedURL == "" {
+			continue
+		}
+		list.Outlines = append(list.Outlines, Outline{Text: item.FeedURL, Type: "rss", XMLURL: item.FeedURL})
+	}
+	return list
+}
+
+// Podcast converts the subscription list into a list of podcasts: a feed
+// whose podcast:medium is podcastL, with a podcast:remoteItem for each feed.
+// The feedGuid of each item is derived from the feed's URL with
+// [podcast.GUIDFromFeedURL], which is right for most, but not all, feeds.
+func (o *OPML) Podcast() *podcast.RSSPodcast {
+	pod := &podcast.RSSPodcast{Version: rss.RSSVersion}
+	pod.Channel.Title = o.Head.Title
+	pod.Channel.PodcastMedium = podcast.MediumPodcastList
+
+	for _, feed := range o.Feeds() {
+		pod.Channel.PodcastRemoteItems = append(pod.Channel.PodcastRemoteItems, podcast.PodcastRemoteItem{
+			FeedGUID: podcast.GUIDFromFeedURL(feed.XMLURL),
+			FeedURL:  feed.XMLURL,
+		})
+	}
+	return pod
+}
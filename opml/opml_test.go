@@ -0,0 +1,69 @@
+package opml
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+const subscriptions = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+	<head>
+		<title>My subscriptions</title>
+	</head>
+	<body>
+		<outline text="Podcasting 2.0" type="rss" xmlUrl="https://mp3s.nashownotes.com/pc20rss.xml"/>
+		<outline text="Tech">
+			<outline text="The Go Podcast" type="rss" xmlUrl="https://example.com/feed.xml" htmlUrl="https://example.com/"/>
+		</outline>
+	</body>
+</opml>`
+
+func TestPodcast(t *testing.T) {
+	var list OPML
+	if err := xml.Unmarshal([]byte(subscriptions), &list); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &podcast.RSSPodcast{Version: rss.RSSVersion}
+	expected.Channel.Title = "My subscriptions"
+	expected.Channel.PodcastMedium = podcast.MediumPodcastList
+	expected.Channel.PodcastRemoteItems = []podcast.PodcastRemoteItem{
+		{FeedGUID: "917393e3-1b1e-5cef-ace4-edaa54e1f810", FeedURL: "https://mp3s.nashownotes.com/pc20rss.xml"},
+		{FeedGUID: podcast.GUIDFromFeedURL("https://example.com/feed.xml"), FeedURL: "https://example.com/feed.xml"},
+	}
+	actual := list.Podcast()
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Podcast() mismatch (-want +got):\n%s", diff)
+	}
+
+	// And back, without the folder
+	roundTrip := FromPodcast(actual, "")
+	expectedList := New("My subscriptions",
+		Outline{Text: "https://mp3s.nashownotes.com/pc20rss.xml", Type: "rss", XMLURL: "https://mp3s.nashownotes.com/pc20rss.xml"},
+		Outline{Text: "https://example.com/feed.xml", Type: "rss", XMLURL: "https://example.com/feed.xml"},
+	)
+	if diff := cmp.Diff(expectedList, roundTrip); diff != "" {
+		t.Errorf("FromPodcast() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromPodcast(t *testing.T) {
+	pod := &podcast.RSSPodcast{}
+	pod.Channel.Title = "The Go Podcast"
+	pod.Channel.Link = "https://example.com/"
+
+	expected := New("The Go Podcast", Outline{
+		Text:    "The Go Podcast",
+		Type:    "rss",
+		Title:   "The Go Podcast",
+		XMLURL:  "https://example.com/feed.xml",
+		HTMLURL: "https://example.com/",
+	})
+	if diff := cmp.Diff(expected, FromPodcast(pod, "https://example.com/feed.xml")); diff != "" {
+		t.Errorf("FromPodcast() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,70 @@
+package podcast
+
+import "encoding/xml"
+
+// AtomNamespaceURL is the namespace of [Atom] elements, which RSS feeds
+// borrow for links.
+//
+// [Atom]: https://www.rfc-editor.org/rfc/rfc4287
+const AtomNamespaceURL = "http://www.w3.org/2005/Atom"
+
+// FeedHistoryNamespaceURL is the namespace of the [RFC 5005] feed history
+// elements.
+//
+// [RFC 5005]: https://www.rfc-editor.org/rfc/rfc5005
+const FeedHistoryNamespaceURL = "http://purl.org/syndication/history/1.0"
+
+// The link relations used by feeds
+const (
+	// RelSelf is the URL of the feed itself
+	RelSelf = "self"
+	// RelNext is the next, older, page of a paged feed
+	RelNext = "next"
+	// RelPrevious is the previous, newer, page of a paged feed
+	RelPrevious = "previous"
+	// RelFirst is the first page of a paged feed, with the newest episodes
+	RelFirst = "first"
+	// RelLast is the last page of a paged feed, with the oldest episodes
+	RelLast = "last"
+	// RelCurrent is the feed an archive page belongs to
+	RelCurrent = "current"
+	// RelPrevArchive is the previous, older, archive page
+	RelPrevArchive = "prev-archive"
+	// RelNextArchive is the next, newer, archive page
+	RelNextArchive = "next-archive"
+	// RelHub is a WebSub hub that pushes updates of the feed
+	RelHub = "hub"
+)
+
+// AtomLink is a link from the feed to another document.
+//
+// Example:
+//
+//	<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+type AtomLink struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom link"`
+
+	// Href is the URL of the document
+	Href string `xml:"href,attr"`
+
+	// Rel is how the document relates to the feed, such as [RelSelf]
+	Rel string `xml:"rel,attr,omitempty"`
+
+	// Type is the media type of the document
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// FeedHistoryMarker is an empty element, such as <fh:archive/>, whose
+// presence is what matters
+type FeedHistoryMarker struct{}
+
+// AtomLinkHref returns the href of the first of [Podcast.AtomLinks] with the
+// relation rel, or "" if there isn't one
+func (p *Podcast) AtomLinkHref(rel string) string {
+	for _, link := range p.AtomLinks {
+		if link.Rel == rel {
+			return link.Href
+		}
+	}
+	return ""
+}
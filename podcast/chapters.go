@@ -0,0 +1,193 @@
+package podcast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// PscNamespaceURL is the namespace of [Podlove Simple Chapters], which embeds
+// chapters in the feed instead of linking to a chapters file like
+// [PodcastChapters] does.
+//
+// Types beginning with "Psc" are from the Podlove Simple Chapters spec.
+//
+// [Podlove Simple Chapters]: https://podlove.org/simple-chapters/
+const PscNamespaceURL = "http://podlove.org/simple-chapters"
+
+// PscVersion is the latest version of Podlove Simple Chapters
+const PscVersion = "1.2"
+
+// JSONChaptersVersion is the version of the JSON chapters format produced by
+// [PscChapters.JSONChapters]
+const JSONChaptersVersion = "1.2.0"
+
+// JSONChaptersType is the mime type to use for [PodcastChapters.Type] when
+// linking to a [JSONChapters] file.
+const JSONChaptersType = "application/json+chapters"
+
+// PscChapters is the container for the chapters of an episode.
+//
+// Example:
+//
+//	<psc:chapters version="1.2">
+//	  <psc:chapter start="0" title="Welcome" />
+//	  <psc:chapter start="3:07" title="Introducing Podlove" href="http://podlove.org/" />
+//	  <psc:chapter start="8:26.250" title="Podlove WordPress Plugin" href="http://podlove.org/podlove-podcast-publisher" />
+//	</psc:chapters>
+//
+// See [spec]
+//
+// [spec]: https://podlove.org/simple-chapters/
+type PscChapters struct {
+	XMLName xml.Name `xml:"http://podlove.org/simple-chapters chapters"`
+
+	// Version (recommended) of the spec the chapters follow, see [PscVersion]
+	Version string `xml:"version,attr,omitempty"`
+
+	Chapters []PscChapter `xml:"http://podlove.org/simple-chapters chapter"`
+}
+
+// PscChapter is a single chapter mark.
+type PscChapter struct {
+	XMLName xml.Name `xml:"http://podlove.org/simple-chapters chapter"`
+
+	// Start (required) is a single point in time relative to the beginning of
+	// the media file, in [Normal Play Time] such as 00:01:02.500. Chapters
+	// don't have to be in order.
+	//
+	// [Normal Play Time]: https://www.ietf.org/rfc/rfc2326.txt
+	Start string `xml:"start,attr"`
+
+	// Title (required) is the name of the chapter.
+	Title string `xml:"title,attr"`
+
+	// Href (optional) is a URL related to the chapter.
+	Href string `xml:"href,attr,omitempty"`
+
+	// Image (optional) is the URL of an image representing the chapter.
+	Image string `xml:"image,attr,omitempty"`
+}
+
+// JSONChapters is the [JSON chapters format] of the podcast namespace, the
+// file linked from [PodcastChapters].
+//
+// [JSON chapters format]: https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/examples/chapters/jsonChapters.md
+type JSONChapters struct {
+	// Version (required) of the JSON chapters format, see [JSONChaptersVersion]
+	Version string `json:"version"`
+
+	Chapters []JSONChapter `json:"chapters"`
+
+	Author      string `json:"author,omitempty"`
+	Title       string `json:"title,omitempty"`
+	PodcastName string `json:"podcastName,omitempty"`
+	Description string `json:"description,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+}
+
+// JSONChapter is a single chapter in a [JSONChapters] file.
+type JSONChapter struct {
+	// StartTime (required) is the starting time of the chapter, in seconds.
+	StartTime float64 `json:"startTime"`
+
+	// EndTime (optional) is the end time of the chapter, in seconds.
+	EndTime float64 `json:"endTime,omitempty"`
+
+	// Title (optional) of the chapter.
+	Title string `json:"title,omitempty"`
+
+	// Img (optional) is the URL of an image for the chapter.
+	Img string `json:"img,omitempty"`
+
+	// URL (optional) is a web page or supporting document related to the
+	// chapter.
+	URL string `json:"url,omitempty"`
+
+	// Toc (optional) if false, the chapter should not be displayed in the
+	// table of contents. Useful for chapters that only change artwork.
+	Toc *bool `json:"toc,omitempty"`
+}
+
+// JSONChapters converts Podlove chapters into [JSONChapters], so a player can
+// treat both uniformly. The chapters are sorted by start time, since Podlove
+// chapters may be in any order. It fails if a start time can't be parsed.
+func (c *PscChapters) JSONChapters() (JSONChapters, error) {
+	chapters := JSONChapters{Version: JSONChaptersVersion}
+
+	for _, chapter := range c.Chapters {
+		start, err := ParseNormalPlayTime(chapter.Start)
+		if err != nil {
+			return JSONChapters{}, err
+		}
+
+		chapters.Chapters = append(chapters.Chapters, JSONChapter{
+			StartTime: start,
+			Title:     chapter.Title,
+			Img:       chapter.Image,
+			URL:       chapter.Href,
+		})
+	}
+
+	sort.SliceStable(chapters.Chapters, func(i, j int) bool {
+		return chapters.Chapters[i].StartTime < chapters.Chapters[j].StartTime
+	})
+
+	return chapters, nil
+}
+
+// PscChapters converts JSON chapters into Podlove chapters. Chapters hidden
+// from the table of contents have no Podlove equivalent and are dropped, as
+// are end times.
+func (c *JSONChapters) PscChapters() PscChapters {
+	chapters := PscChapters{Version: PscVersion}
+
+	for _, chapter := range c.Chapters {
+		if chapter.Toc != nil && !*chapter.Toc {
+			continue
+		}
+
+		chapters.Chapters = append(chapters.Chapters, PscChapter{
+			Start: FormatNormalPlayTime(chapter.StartTime),
+			Title: chapter.Title,
+			Href:  chapter.URL,
+			Image: chapter.Img,
+		})
+	}
+
+	return chapters
+}
+
+// ParseNormalPlayTime parses a [Normal Play Time] timestamp, as used by
+// [PscChapter.Start], into seconds. Hours, minutes and milliseconds are
+// optional, and minutes and seconds after the first part must be less than
+// 60, so all of these are valid:
+//
+//	01:02:03.500
+//	2:03
+//	123.5
+//
+// [Normal Play Time]: https://www.ietf.org/rfc/rfc2326.txt
+func ParseNormalPlayTime(npt string) (float64, error) {
+	seconds, ok := parseClock(strings.TrimSpace(npt))
+	if !ok {
+		return 0, fmt.Errorf("podcast: invalid normal play time %q", npt)
+	}
+	return seconds, nil
+}
+
+// FormatNormalPlayTime formats seconds as a [Normal Play Time] timestamp with
+// millisecond precision, such as 00:01:02.500
+//
+// [Normal Play Time]: https://www.ietf.org/rfc/rfc2326.txt
+func FormatNormalPlayTime(seconds float64) string {
+	milliseconds := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		milliseconds/3600000,
+		milliseconds/60000%60,
+		milliseconds/1000%60,
+		milliseconds%1000,
+	)
+}
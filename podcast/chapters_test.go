@@ -0,0 +1,84 @@
+package podcast
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+const pscFeed = `<rss version="2.0" xmlns:psc="http://podlove.org/simple-chapters">
+  <channel>
+    <title>Podlove</title>
+    <item>
+      <title>Episode 1</title>
+      <psc:chapters version="1.2">
+        <psc:chapter start="0" title="Welcome" />
+        <psc:chapter start="8:26.250" title="Podlove WordPress Plugin" href="http://podlove.org/podlove-podcast-publisher" />
+        <psc:chapter start="3:07" title="Introducing Podlove" href="http://podlove.org/" image="http://podlove.org/logo.png" />
+      </psc:chapters>
+    </item>
+  </channel>
+</rss>`
+
+func TestPscChapters(t *testing.T) {
+	var pod RSSPodcast
+	if err := rss.GetDecoder(strings.NewReader(pscFeed)).Decode(&pod); err != nil {
+		t.Fatalf("failure to unmarshal: %s", err)
+	}
+
+	psc := pod.Channel.Items[0].PscChapters
+	if psc == nil || len(psc.Chapters) != 3 {
+		t.Fatalf("chapters not decoded: %+v", psc)
+	}
+
+	chapters, err := psc.JSONChapters()
+	if err != nil {
+		t.Fatalf("failed to convert: %s", err)
+	}
+
+	expected := JSONChapters{
+		Version: JSONChaptersVersion,
+		Chapters: []JSONChapter{
+			{StartTime: 0, Title: "Welcome"},
+			{StartTime: 187, Title: "Introducing Podlove", URL: "http://podlove.org/", Img: "http://podlove.org/logo.png"},
+			{StartTime: 506.25, Title: "Podlove WordPress Plugin", URL: "http://podlove.org/podlove-podcast-publisher"},
+		},
+	}
+	if !cmp.Equal(expected, chapters) {
+		t.Errorf("chapters didn't match! %s", cmp.Diff(expected, chapters))
+	}
+
+	back := chapters.PscChapters()
+	if back.Chapters[2].Start != "00:08:26.250" {
+		t.Errorf("unexpected start %q", back.Chapters[2].Start)
+	}
+
+	if _, err := json.Marshal(chapters); err != nil {
+		t.Errorf("failed to marshal json: %s", err)
+	}
+}
+
+func TestParseNormalPlayTime(t *testing.T) {
+	for npt, expected := range map[string]float64{
+		"0":            0,
+		"123.5":        123.5,
+		"2:03":         123,
+		"01:02:03.500": 3723.5,
+	} {
+		seconds, err := ParseNormalPlayTime(npt)
+		if err != nil {
+			t.Errorf("failed to parse %q: %s", npt, err)
+		} else if seconds != expected {
+			t.Errorf("%q parsed as %f, expected %f", npt, seconds, expected)
+		}
+	}
+
+	for _, npt := range []string{"", "1:2:3:4", "1.5:00", "abc", "1:60", "NaN", "Inf", "1e9", "-1", "+1", "1."} {
+		if _, err := ParseNormalPlayTime(npt); err == nil {
+			t.Errorf("expected %q to fail", npt)
+		}
+	}
+}
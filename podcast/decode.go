@@ -0,0 +1,67 @@
+package podcast
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Decode reads an RSS 0.91, 0.92, 1.0 (RDF) or 2.0 podcast feed. It's
+// [DecodeFeed] for callers that don't need to know if the feed is a podcast.
+func Decode(r io.Reader) (*RSSPodcast, error) {
+	pod, _, err := DecodeFeed(r)
+	return pod, err
+}
+
+// DecodeFeed reads an RSS 0.91, 0.92, 1.0 (RDF) or 2.0 feed. ok reports if
+// any of its items has an enclosure, which is what makes a feed a podcast.
+//
+// RSS 2.0 feeds are decoded directly, so any itunes: or podcast: tags are
+// kept. Older versions, which predate those namespaces, are normalized with
+// [rss.DecodeElement] and then converted with [FromRSS]. See [rss.Decode]
+// for details.
+func DecodeFeed(r io.Reader) (pod *RSSPodcast, ok bool, err error) {
+	decoder := rss.GetDecoder(r)
+	decoder.Entity = xml.HTMLEntity
+
+	start, err := rss.RootElement(decoder)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if rss.IsRDF(start) || rss.IsLegacy(start) {
+		feed, err := rss.DecodeElement(decoder, start)
+		if err != nil {
+			return nil, false, err
+		}
+		converted, ok := FromRSS(feed)
+		return &converted, ok, nil
+	}
+
+	pod = &RSSPodcast{}
+	if err := decoder.DecodeElement(pod, &start); err != nil {
+		return nil, false, err
+	}
+	for i := range pod.Channel.Items {
+		ok = ok || pod.Channel.Items[i].Enclosure != nil
+	}
+	return pod, ok, nil
+}
+
+// FromRSS wraps a plain RSS feed in the podcast types. ok reports if any of the
+// items has an enclosure, which is what makes a feed a podcast.
+func FromRSS(feed *rss.RSS) (pod RSSPodcast, ok bool) {
+	pod = RSSPodcast{
+		Version: feed.Version,
+		Channel: Podcast{Channel: feed.Channel},
+	}
+	pod.Channel.Channel.Items = nil
+
+	for _, item := range feed.Channel.Items {
+		pod.Channel.Items = append(pod.Channel.Items, Episode{Item: item})
+		ok = ok || item.Enclosure != nil
+	}
+
+	return pod, ok
+}
@@ -0,0 +1,90 @@
+package podcast
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const rdfPodcast = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:enc="http://purl.oclc.org/net/rss_2.0/enc#" xmlns="http://purl.org/rss/1.0/">
+  <channel rdf:about="https://example.com/feed.rdf">
+    <title>Old Timey Radio</title>
+    <link>https://example.com</link>
+    <description>Recordings from the archive</description>
+  </channel>
+  <item rdf:about="https://example.com/1">
+    <title>Episode 1</title>
+    <link>https://example.com/1</link>
+    <enc:enclosure rdf:resource="https://example.com/1.mp3" enc:length="42" enc:type="audio/mpeg" />
+  </item>
+</rdf:RDF>`
+
+func TestDecodeRDF(t *testing.T) {
+	pod, err := Decode(strings.NewReader(rdfPodcast))
+	if err != nil {
+		t.Fatalf("failure to decode: %s", err)
+	}
+
+	if pod.Channel.Title != "Old Timey Radio" {
+		t.Errorf("unexpected title %q", pod.Channel.Title)
+	}
+	if len(pod.Channel.Items) != 1 {
+		t.Fatalf("expected 1 episode, got %d", len(pod.Channel.Items))
+	}
+
+	enclosure := pod.Channel.Items[0].Enclosure
+	if enclosure == nil || enclosure.URL != "https://example.com/1.mp3" || enclosure.Length != 42 {
+		t.Errorf("enclosure not decoded: %+v", enclosure)
+	}
+}
+
+func TestDecodeRSS2(t *testing.T) {
+	pod, err := Decode(bytes.NewReader(Podcasting20Example))
+	if err != nil {
+		t.Fatalf("failure to decode: %s", err)
+	}
+
+	if pod.Channel.PodcastGUID != "y0ur-gu1d-g035-h3r3" {
+		t.Errorf("podcast namespace was lost, guid is %q", pod.Channel.PodcastGUID)
+	}
+}
+
+func TestDecodeLegacy(t *testing.T) {
+	const legacy = `<?xml version="1.0"?>
+<rss version="0.91">
+  <channel>
+    <title>Old Timey Radio</title>
+    <textinput>
+      <title>Search</title>
+      <description>Search the archive</description>
+      <name>q</name>
+      <link>https://example.com/search</link>
+    </textinput>
+    <item>
+      <title>Episode 1</title>
+      <link>https://example.com/1</link>
+    </item>
+  </channel>
+</rss>`
+	pod, ok, err := DecodeFeed(strings.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("failure to decode: %s", err)
+	}
+	if ok {
+		t.Errorf("expected a feed without enclosures not to be a podcast")
+	}
+	if pod.Version != "0.91" || len(pod.Channel.Items) != 1 {
+		t.Errorf("unexpected feed %+v", pod)
+	}
+	if input := pod.Channel.TextInput; input == nil || input.Name != "q" || input.Link != "https://example.com/search" {
+		t.Errorf("legacy textinput was lost: %+v", input)
+	}
+
+	if _, ok, err := DecodeFeed(strings.NewReader(rdfPodcast)); err != nil || !ok {
+		t.Errorf("expected the RDF feed to be a podcast, got %v, %v", ok, err)
+	}
+	if _, ok, err := DecodeFeed(bytes.NewReader(Podcasting20Example)); err != nil || !ok {
+		t.Errorf("expected the RSS 2.0 feed to be a podcast, got %v, %v", ok, err)
+	}
+}
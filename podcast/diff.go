@@ -0,0 +1,321 @@
+package podcast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Changeset is the difference between two versions of a feed, from [Diff]
+type Changeset struct {
+	// Channel lists the changed channel elements, other than the items
+	Channel []FieldChange
+
+	// Added lists the new feed's episodes that aren't in the old feed, and
+	// Removed the old feed's episodes that aren't in the new feed, both in
+	// document order
+	Added   []*Episode
+	Removed []*Episode
+
+	// Modified lists the episodes in both feeds that changed, in the new
+	// feed's order
+	Modified []EpisodeChange
+}
+
+// FieldChange is a changed element. Old and New are the element's text, or
+// its XML if it has attributes or children, with repeated elements on
+// separate lines. They are empty when the element was added or removed.
+type FieldChange struct {
+	// Field is the element's name, with the prefix from [Namespaces]
+	Field string
+
+	Old, New string
+
+	// Breaking is set for changes that make apps treat an episode as new, and
+	// download it again, or the show as a different show: a change to an
+	// episode's guid or enclosure URL, or to the channel's podcast:guid
+	Breaking bool
+}
+
+// EpisodeChange is an episode in both feeds that changed
+type EpisodeChange struct {
+	Old, New *Episode
+	Fields   []FieldChange
+}
+
+// Breaking reports if any of the changes to the episode are breaking
+func (c *EpisodeChange) Breaking() bool {
+	for _, field := range c.Fields {
+		if field.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty reports if the feeds are the same
+func (c *Changeset) Empty() bool {
+	return len(c.Channel) == 0 && len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Breaking reports if any of the changes are breaking, see
+// [FieldChange.Breaking]
+func (c *Changeset) Breaking() bool {
+	for _, field := range c.Channel {
+		if field.Breaking {
+			return true
+		}
+	}
+	for i := range c.Modified {
+		if c.Modified[i].Breaking() {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff compares two versions of a feed.
+//
+// Episodes are matched by guid. An episode without a match falls back to
+// matching by enclosure URL, which finds episodes whose guid changed.
+// Elements are compared by their XML encoding, so an element that only moved
+// within its channel or item isn't a change, but a reordered list of repeated
+// elements, such as categories, is.
+func Diff(old, new *RSSPodcast) *Changeset {
+	d := differ{prefixes: map[string]string{}}
+	oldChannel, newChannel := d.channelNode(&old.Channel), d.channelNode(&new.Channel)
+	oldItems, newItems := d.itemNodes(old.Channel.Items), d.itemNodes(new.Channel.Items)
+
+	// Give every namespace its prefix up front, so the names are consistent
+	all := &xmlNode{children: []*xmlNode{oldChannel, newChannel}}
+	all.children = append(all.children, oldItems...)
+	all.children = append(all.children, newItems...)
+	(&encoder{prefixes: d.prefixes}).assignPrefixes(all)
+
+	changes := &Changeset{}
+	changes.Channel = d.fields(oldChannel, newChannel)
+	for i := range changes.Channel {
+		if changes.Channel[i].Field == "podcast:guid" {
+			changes.Channel[i].Breaking = true
+		}
+	}
+
+	matches := matchEpisodes(old.Channel.Items, new.Channel.Items)
+	matched := make([]bool, len(old.Channel.Items))
+	for j := range new.Channel.Items {
+		i, ok := matches[j]
+		if !ok {
+			changes.Added = append(changes.Added, &new.Channel.Items[j])
+			continue
+		}
+		matched[i] = true
+
+		oldEpisode, newEpisode := &old.Channel.Items[i], &new.Channel.Items[j]
+		fields := d.fields(oldItems[i], newItems[j])
+		for k := range fields {
+			switch fields[k].Field {
+			case "guid":
+				fields[k].Breaking = guidValue(oldEpisode) != guidValue(newEpisode)
+			case "enclosure":
+				fields[k].Breaking = enclosureURL(oldEpisode) != enclosureURL(newEpisode)
+			}
+		}
+		if len(fields) > 0 {
+			changes.Modified = append(changes.Modified, EpisodeChange{oldEpisode, newEpisode, fields})
+		}
+	}
+	for i := range old.Channel.Items {
+		if !matched[i] {
+			changes.Removed = append(changes.Removed, &old.Channel.Items[i])
+		}
+	}
+	return changes
+}
+
+// matchEpisodes maps the index of each new episode to the index of the old
+// episode it matches, first by guid and then by enclosure URL
+func matchEpisodes(old, new []Episode) map[int]int {
+	matches := map[int]int{}
+	taken := make([]bool, len(old))
+
+	byGUID := map[string]int{}
+	for i := range old {
+		if guid := guidValue(&old[i]); guid != "" {
+			if _, ok := byGUID[guid]; !ok {
+				byGUID[guid] = i
+			}
+		}
+	}
+	for j := range new {
+		if i, ok := byGUID[guidValue(&new[j])]; ok && !taken[i] {
+			matches[j] = i
+			taken[i] = true
+		}
+	}
+
+	byURL := map[string]int{}
+	for i := range old {
+		if url := enclosureURL(&old[i]); url != "" && !taken[i] {
+			if _, ok := byURL[url]; !ok {
+				byURL[url] = i
+			}
+		}
+	}
+	for j := range new {
+		if _, ok := matches[j]; ok {
+			continue
+		}
+		if i, ok := byURL[enclosureURL(&new[j])]; ok && !taken[i] {
+			matches[j] = i
+			taken[i] = true
+		}
+	}
+	return matches
+}
+
+func guidValue(episode *Episode) string {
+	if episode.GUID == nil {
+		return ""
+	}
+	return strings.TrimSpace(episode.GUID.Value)
+}
+
+func enclosureURL(episode *Episode) string {
+	if episode.Enclosure == nil {
+		return ""
+	}
+	return strings.TrimSpace(episode.Enclosure.URL)
+}
+
+type differ struct {
+	prefixes map[string]string
+}
+
+// channelNode is the XML tree of the channel without its items
+func (d *differ) channelNode(channel *Podcast) *xmlNode {
+	withoutItems := *channel
+	withoutItems.Items = nil
+	return d.node(&withoutItems)
+}
+
+func (d *differ) itemNodes(episodes []Episode) []*xmlNode {
+	nodes := make([]*xmlNode, len(episodes))
+	for i := range episodes {
+		nodes[i] = d.node(&episodes[i])
+	}
+	return nodes
+}
+
+func (d *differ) node(v any) *xmlNode {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		// A feed that can't be marshaled can't be compared element by element,
+		// so compare it as a whole
+		return &xmlNode{text: err.Error()}
+	}
+	node, err := parseXMLNode(xml.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return &xmlNode{text: err.Error()}
+	}
+	return node
+}
+
+// fields compares the children of two elements, grouped by name
+func (d *differ) fields(old, new *xmlNode) []FieldChange {
+	var names []xml.Name
+	oldValues, newValues := map[xml.Name][]string{}, map[xml.Name][]string{}
+	collect := func(node *xmlNode, values map[xml.Name][]string) {
+		for _, child := range node.children {
+			if _, ok := oldValues[child.name]; !ok {
+				if _, ok := newValues[child.name]; !ok {
+					names = append(names, child.name)
+				}
+			}
+			values[child.name] = append(values[child.name], d.render(child))
+		}
+	}
+	collect(old, oldValues)
+	collect(new, newValues)
+
+	var changes []FieldChange
+	for _, name := range names {
+		oldValue, newValue := strings.Join(oldValues[name], "\n"), strings.Join(newValues[name], "\n")
+		if oldValue != newValue {
+			field := (&encoder{prefixes: d.prefixes}).qualified(name)
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+// render is the text of a simple element, or the XML of the element
+func (d *differ) render(node *xmlNode) string {
+	if len(node.attrs) == 0 && len(node.children) == 0 {
+		return strings.TrimSpace(node.text)
+	}
+	var b strings.Builder
+	e := &encoder{w: bufio.NewWriter(&b), prefixes: d.prefixes}
+	e.writeNode(node, 0)
+	e.w.Flush()
+	return b.String()
+}
+
+// String describes the changes for people, one per line, with breaking
+// changes marked
+func (c *Changeset) String() string {
+	var b strings.Builder
+	writeFields := func(fields []FieldChange, indent string) {
+		for _, field := range fields {
+			marker := ""
+			if field.Breaking {
+				marker = " (breaking)"
+			}
+			if !strings.Contains(field.Old+field.New, "\n") {
+				fmt.Fprintf(&b, "%s%s: %q -> %q%s\n", indent, field.Field, field.Old, field.New, marker)
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s:%s\n", indent, field.Field, marker)
+			for _, line := range splitNonEmpty(field.Old) {
+				fmt.Fprintf(&b, "%s\t- %s\n", indent, line)
+			}
+			for _, line := range splitNonEmpty(field.New) {
+				fmt.Fprintf(&b, "%s\t+ %s\n", indent, line)
+			}
+		}
+	}
+
+	if len(c.Channel) > 0 {
+		b.WriteString("channel:\n")
+		writeFields(c.Channel, "\t")
+	}
+	for _, episode := range c.Added {
+		fmt.Fprintf(&b, "added %s\n", describeEpisode(episode))
+	}
+	for _, episode := range c.Removed {
+		fmt.Fprintf(&b, "removed %s\n", describeEpisode(episode))
+	}
+	for _, change := range c.Modified {
+		fmt.Fprintf(&b, "modified %s:\n", describeEpisode(change.New))
+		writeFields(change.Fields, "\t")
+	}
+	return b.String()
+}
+
+func describeEpisode(episode *Episode) string {
+	description := fmt.Sprintf("%q", episode.Title)
+	if guid := guidValue(episode); guid != "" {
+		description += " (" + guid + ")"
+	} else if url := enclosureURL(episode); url != "" {
+		description += " (" + url + ")"
+	}
+	return description
+}
+
+func splitNonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
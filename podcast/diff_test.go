@@ -0,0 +1,196 @@
+package podcast
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestDiffSame(t *testing.T) {
+	file, err := os.Open("samples/podcastindex.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	feed, err := Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := Diff(feed, feed)
+	if !changes.Empty() || changes.String() != "" {
+		t.Errorf("expected no changes, got\n%s", changes)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	episode2 := validFeed().Channel.Items[0]
+	episode2.Title = "Episode 2"
+	episode2.GUID = &rss.GUID{Value: "episode-2"}
+	episode2.Enclosure = &rss.Enclosure{URL: "https://example.com/2.mp3", Length: 2000, Type: "audio/mpeg"}
+
+	testCases := []struct {
+		name     string
+		modify   func(feed *RSSPodcast)
+		channel  []FieldChange
+		added    []string
+		removed  []string
+		modified map[string][]FieldChange
+		breaking bool
+		rendered string
+	}{
+		{
+			name: "channel field",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.ItunesAuthor = "Gophers"
+				feed.Channel.Copyright = "2024"
+			},
+			channel: []FieldChange{
+				{Field: "itunes:author", Old: "Jayden", New: "Gophers"},
+				{Field: "copyright", Old: "", New: "2024"},
+			},
+			rendered: "channel:\n" +
+				"\titunes:author: \"Jayden\" -> \"Gophers\"\n" +
+				"\tcopyright: \"\" -> \"2024\"\n",
+		},
+		{
+			name: "podcast guid",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.PodcastGUID = "ead4c236-bf58-58c6-a2c6-a6b28d128cb6"
+			},
+			channel: []FieldChange{
+				{Field: "podcast:guid", Old: "917393e3-1b1e-5cef-ace4-edaa54e1f810", New: "ead4c236-bf58-58c6-a2c6-a6b28d128cb6", Breaking: true},
+			},
+			breaking: true,
+		},
+		{
+			name: "added and removed",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.Items = []Episode{episode2}
+			},
+			added:    []string{"episode-2"},
+			removed:  []string{"episode-1"},
+			rendered: "added \"Episode 2\" (episode-2)\nremoved \"Episode 1\" (episode-1)\n",
+		},
+		{
+			name: "episode field",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.Items[0].Title = "Episode One"
+				feed.Channel.Items[0].ItunesDuration = ""
+			},
+			modified: map[string][]FieldChange{
+				"episode-1": {
+					{Field: "title", Old: "Episode 1", New: "Episode One"},
+					{Field: "itunes:duration", Old: "01:02:03", New: ""},
+				},
+			},
+			rendered: "modified \"Episode One\" (episode-1):\n" +
+				"\ttitle: \"Episode 1\" -> \"Episode One\"\n" +
+				"\titunes:duration: \"01:02:03\" -> \"\"\n",
+		},
+		{
+			name: "enclosure length",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.Items[0].Enclosure.Length = 2000
+			},
+			modified: map[string][]FieldChange{
+				"episode-1": {{
+					Field: "enclosure",
+					Old:   `<enclosure url="https://example.com/1.mp3" length="1000" type="audio/mpeg"/>`,
+					New:   `<enclosure url="https://example.com/1.mp3" length="2000" type="audio/mpeg"/>`,
+				}},
+			},
+		},
+		{
+			name: "enclosure url",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.Items[0].Enclosure.URL = "https://cdn.example.com/1.mp3"
+			},
+			modified: map[string][]FieldChange{
+				"episode-1": {{
+					Field:    "enclosure",
+					Old:      `<enclosure url="https://example.com/1.mp3" length="1000" type="audio/mpeg"/>`,
+					New:      `<enclosure url="https://cdn.example.com/1.mp3" length="1000" type="audio/mpeg"/>`,
+					Breaking: true,
+				}},
+			},
+			breaking: true,
+		},
+		{
+			name: "guid isPermaLink",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.Items[0].GUID.IsPermaLink = nil
+			},
+			modified: map[string][]FieldChange{
+				"episode-1": {{
+					Field: "guid",
+					Old:   `<guid isPermaLink="false">episode-1</guid>`,
+					New:   `episode-1`,
+				}},
+			},
+		},
+		{
+			name: "guid matched by enclosure",
+			modify: func(feed *RSSPodcast) {
+				feed.Channel.Items[0].GUID.Value = "https://example.com/1"
+			},
+			modified: map[string][]FieldChange{
+				"https://example.com/1": {{
+					Field:    "guid",
+					Old:      `<guid isPermaLink="false">episode-1</guid>`,
+					New:      `<guid isPermaLink="false">https://example.com/1</guid>`,
+					Breaking: true,
+				}},
+			},
+			breaking: true,
+			rendered: "modified \"Episode 1\" (https://example.com/1):\n" +
+				"\tguid: \"<guid isPermaLink=\\\"false\\\">episode-1</guid>\" -> \"<guid isPermaLink=\\\"false\\\">https://example.com/1</guid>\" (breaking)\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			old, new := validFeed(), validFeed()
+			tc.modify(new)
+			changes := Diff(old, new)
+
+			if diff := cmp.Diff(tc.channel, changes.Channel); diff != "" {
+				t.Errorf("unexpected channel changes (-want +got):\n%s", diff)
+			}
+			guids := func(episodes []*Episode) []string {
+				var values []string
+				for _, episode := range episodes {
+					values = append(values, episode.GUID.Value)
+				}
+				return values
+			}
+			if diff := cmp.Diff(tc.added, guids(changes.Added)); diff != "" {
+				t.Errorf("unexpected added episodes (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.removed, guids(changes.Removed)); diff != "" {
+				t.Errorf("unexpected removed episodes (-want +got):\n%s", diff)
+			}
+			var modified map[string][]FieldChange
+			for _, change := range changes.Modified {
+				if modified == nil {
+					modified = map[string][]FieldChange{}
+				}
+				modified[change.New.GUID.Value] = change.Fields
+			}
+			if diff := cmp.Diff(tc.modified, modified); diff != "" {
+				t.Errorf("unexpected modified episodes (-want +got):\n%s", diff)
+			}
+
+			if changes.Breaking() != tc.breaking {
+				t.Errorf("expected breaking %v", tc.breaking)
+			}
+			if tc.rendered != "" {
+				if diff := cmp.Diff(tc.rendered, changes.String()); diff != "" {
+					t.Errorf("unexpected rendering (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package podcast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration parses [Episode.ItunesDuration], which is a number of seconds, or
+// MM:SS or HH:MM:SS. It returns zero if the episode has no duration.
+func (e *Episode) Duration() (time.Duration, error) {
+	return ParseItunesDuration(e.ItunesDuration)
+}
+
+// ParseItunesDuration parses an itunes:duration value, which is a number of
+// seconds, or MM:SS or HH:MM:SS. The empty string is a zero duration.
+func ParseItunesDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	seconds, ok := parseClock(value)
+	if !ok {
+		return 0, fmt.Errorf("podcast: invalid duration %q", value)
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond), nil
+}
+
+// parseClock parses seconds, MM:SS or HH:MM:SS into seconds. Only the first
+// part may be 60 or more, and only the last may have a fractional part.
+// Components are plain decimal numbers, so signs, exponents, NaN and Inf are
+// rejected.
+func parseClock(value string) (float64, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		whole, fraction, hasFraction := strings.Cut(part, ".")
+		if !isDigits(whole) || (hasFraction && (i != len(parts)-1 || !isDigits(fraction))) {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
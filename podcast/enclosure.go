@@ -0,0 +1,197 @@
+package podcast
+
+import (
+	"sort"
+	"strings"
+)
+
+// EnclosurePreferences describes what a player can and would like to play.
+// It is used by [Episode.SelectEnclosure] to pick between the renditions
+// offered by [PodcastAlternateEnclosure]s. The zero value accepts anything.
+type EnclosurePreferences struct {
+	// Types are acceptable mime types, most preferred first. A type may end
+	// in a wildcard, such as "audio/*". If empty, any type is accepted.
+	Types []string
+
+	// MaxBitrate is the highest acceptable bitrate in bits per second. Zero
+	// means there is no limit. Renditions without a bitrate are accepted.
+	MaxBitrate float32
+
+	// MaxHeight is the highest acceptable video height in pixels. Zero means
+	// there is no limit. Renditions without a height are accepted.
+	MaxHeight int
+
+	// Languages are BCP 47 language tags, most preferred first. A tag matches
+	// any more specific tag, so "en" matches "en-US". Renditions in other
+	// languages are still accepted, but ranked last.
+	Languages []string
+
+	// Codecs are the codecs the player supports, matched as prefixes of the
+	// RFC 6381 codecs of a rendition, so "mp4a" matches "mp4a.40.2". If
+	// empty, any codecs are accepted. Renditions without codecs are accepted.
+	Codecs []string
+
+	// Rel selects the group of renditions to choose from, see
+	// [PodcastAlternateEnclosure.Rel]. The empty string selects the default
+	// group, which are the alternatives to the main enclosure.
+	Rel string
+}
+
+// SelectEnclosure picks the rendition of the episode that best fits prefs.
+//
+// Renditions that don't satisfy the type, bitrate, height or codecs
+// preferences are discarded. The remainder are ranked by type preference,
+// then language preference, then [PodcastAlternateEnclosure.Default], then
+// the highest bitrate and finally the highest resolution.
+//
+// The main <enclosure> competes as a rendition of the default group, and it is
+// returned if nothing else fits. ok is false if the episode has no main
+// enclosure and none of its alternate enclosures are in the Rel group or
+// satisfy the preferences, including when it has no media at all.
+func (e *Episode) SelectEnclosure(prefs EnclosurePreferences) (selected PodcastAlternateEnclosure, ok bool) {
+	var candidates []PodcastAlternateEnclosure
+	hasDefault := false
+
+	for _, alternate := range e.PodcastAlternateEnclosures {
+		if !sameRel(alternate.Rel, prefs.Rel) {
+			continue
+		}
+		candidates = append(candidates, alternate)
+		hasDefault = hasDefault || (alternate.Default != nil && *alternate.Default)
+	}
+
+	// A default alternate enclosure is the same media as the enclosure, only
+	// better described, so the enclosure is only a candidate of its own
+	// without one.
+	if e.Enclosure != nil && sameRel("", prefs.Rel) && !hasDefault {
+		candidates = append(candidates, e.EnclosureAsAlternate())
+	}
+
+	var accepted []PodcastAlternateEnclosure
+	for _, candidate := range candidates {
+		if prefs.accepts(candidate) {
+			accepted = append(accepted, candidate)
+		}
+	}
+
+	if len(accepted) == 0 {
+		if e.Enclosure == nil {
+			return PodcastAlternateEnclosure{}, false
+		}
+		return e.EnclosureAsAlternate(), true
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		a, b := accepted[i], accepted[j]
+
+		if rankA, rankB := prefs.typeRank(a.Type), prefs.typeRank(b.Type); rankA != rankB {
+			return rankA < rankB
+		}
+		if rankA, rankB := prefs.languageRank(a.Lang), prefs.languageRank(b.Lang); rankA != rankB {
+			return rankA < rankB
+		}
+		if defaultA, defaultB := a.Default != nil && *a.Default, b.Default != nil && *b.Default; defaultA != defaultB {
+			return defaultA
+		}
+		if a.Bitrate != b.Bitrate {
+			return a.Bitrate > b.Bitrate
+		}
+		return a.Height > b.Height
+	})
+
+	return accepted[0], true
+}
+
+// EnclosureAsAlternate describes the episode's main enclosure as a
+// [PodcastAlternateEnclosure], so it can be handled alongside the other
+// renditions. It returns the zero value if the episode has no enclosure.
+func (e *Episode) EnclosureAsAlternate() PodcastAlternateEnclosure {
+	if e.Enclosure == nil {
+		return PodcastAlternateEnclosure{}
+	}
+
+	return PodcastAlternateEnclosure{
+		Type:   e.Enclosure.Type,
+		Length: e.Enclosure.Length,
+		Source: []PodcastSource{{URI: e.Enclosure.URL}},
+	}
+}
+
+func sameRel(a, b string) bool {
+	if a == "default" {
+		a = ""
+	}
+	if b == "default" {
+		b = ""
+	}
+	return a == b
+}
+
+func (prefs EnclosurePreferences) accepts(candidate PodcastAlternateEnclosure) bool {
+	if len(prefs.Types) > 0 && prefs.typeRank(candidate.Type) == len(prefs.Types) {
+		return false
+	}
+	if prefs.MaxBitrate > 0 && candidate.Bitrate > prefs.MaxBitrate {
+		return false
+	}
+	if prefs.MaxHeight > 0 && candidate.Height > prefs.MaxHeight {
+		return false
+	}
+	if len(prefs.Codecs) > 0 {
+		for _, codec := range strings.Split(candidate.Codecs, ",") {
+			codec = strings.TrimSpace(codec)
+			if codec != "" && !prefs.supportsCodec(codec) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// typeRank is the index of the first type preference that matches, or
+// len(prefs.Types) if none do.
+func (prefs EnclosurePreferences) typeRank(mimeType string) int {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	for i, preferred := range prefs.Types {
+		preferred = strings.ToLower(preferred)
+		if prefix, ok := strings.CutSuffix(preferred, "*"); ok {
+			if strings.HasPrefix(mimeType, prefix) {
+				return i
+			}
+		} else if mimeType == preferred {
+			return i
+		}
+	}
+	return len(prefs.Types)
+}
+
+// languageRank is the index of the first language preference that matches.
+// Renditions without a language come after any matches, and renditions in
+// other languages last. Without language preferences every rendition ranks
+// the same.
+func (prefs EnclosurePreferences) languageRank(lang string) int {
+	if len(prefs.Languages) == 0 {
+		return 0
+	}
+	if lang == "" {
+		return len(prefs.Languages)
+	}
+	lang = strings.ToLower(lang)
+	for i, preferred := range prefs.Languages {
+		preferred = strings.ToLower(preferred)
+		if lang == preferred || strings.HasPrefix(lang, preferred+"-") {
+			return i
+		}
+	}
+	return len(prefs.Languages) + 1
+}
+
+func (prefs EnclosurePreferences) supportsCodec(codec string) bool {
+	codec = strings.ToLower(codec)
+	for _, supported := range prefs.Codecs {
+		if strings.HasPrefix(codec, strings.ToLower(supported)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package podcast
+
+import (
+	"testing"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestSelectEnclosure(t *testing.T) {
+	episode := Podcasting20ExampleExpected.Channel.Items[0]
+
+	testCases := []struct {
+		name     string
+		prefs    EnclosurePreferences
+		expected string
+	}{
+		{"no preferences picks the default", EnclosurePreferences{}, "Standard"},
+		{"video", EnclosurePreferences{Types: []string{"video/*"}}, "Video version"},
+		{"video too tall", EnclosurePreferences{Types: []string{"video/*", "audio/mpeg"}, MaxHeight: 480}, "Standard"},
+		{"opus", EnclosurePreferences{Types: []string{"audio/opus"}}, "High quality"},
+		{"low bandwidth", EnclosurePreferences{Types: []string{"audio/*"}, MaxBitrate: 64000}, "Low bandwidth"},
+		{"nothing fits falls back to enclosure", EnclosurePreferences{Types: []string{"audio/flac"}}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, ok := episode.SelectEnclosure(tc.prefs)
+			if !ok {
+				t.Fatalf("expected a rendition")
+			}
+			if selected.Title != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, selected.Title)
+			}
+		})
+	}
+
+	fallback, _ := episode.SelectEnclosure(EnclosurePreferences{Types: []string{"audio/flac"}})
+	if fallback.Source[0].URI != episode.Enclosure.URL {
+		t.Errorf("expected the enclosure, got %+v", fallback)
+	}
+}
+
+func TestSelectEnclosureLanguageAndCodecs(t *testing.T) {
+	episode := Episode{
+		Item: rss.Item{
+			Enclosure: &rss.Enclosure{URL: "https://example.com/en.mp4", Type: "video/mp4", Length: 1},
+		},
+		PodcastAlternateEnclosures: []PodcastAlternateEnclosure{
+			{Type: "video/mp4", Lang: "en-US", Codecs: "avc1.42E01E, mp4a.40.2", Title: "English"},
+			{Type: "video/mp4", Lang: "es", Codecs: "avc1.42E01E, mp4a.40.2", Title: "Spanish"},
+			{Type: "video/mp4", Lang: "es", Codecs: "hev1.1.6.L93.B0, mp4a.40.2", Title: "Spanish HEVC", Bitrate: 900000},
+			{Type: "audio/mpeg", Rel: "commentary", Title: "Commentary"},
+		},
+	}
+
+	selected, _ := episode.SelectEnclosure(EnclosurePreferences{Languages: []string{"es", "en"}, Codecs: []string{"avc1", "mp4a"}})
+	if selected.Title != "Spanish" {
+		t.Errorf("expected Spanish, got %q", selected.Title)
+	}
+
+	selected, _ = episode.SelectEnclosure(EnclosurePreferences{Languages: []string{"en"}})
+	if selected.Title != "English" {
+		t.Errorf("expected English, got %q", selected.Title)
+	}
+
+	selected, _ = episode.SelectEnclosure(EnclosurePreferences{Rel: "commentary"})
+	if selected.Title != "Commentary" {
+		t.Errorf("expected Commentary, got %q", selected.Title)
+	}
+
+	// Without language preferences, a rendition's language doesn't matter
+	withDefault := Episode{PodcastAlternateEnclosures: []PodcastAlternateEnclosure{
+		{Type: "audio/mpeg", Title: "No language"},
+		{Type: "audio/mpeg", Lang: "en", Default: &[]bool{true}[0], Title: "Default"},
+	}}
+	selected, _ = withDefault.SelectEnclosure(EnclosurePreferences{})
+	if selected.Title != "Default" {
+		t.Errorf("expected Default, got %q", selected.Title)
+	}
+
+	if _, ok := (&Episode{}).SelectEnclosure(EnclosurePreferences{}); ok {
+		t.Errorf("expected no rendition for an episode without media")
+	}
+	onlyAlternates := Episode{PodcastAlternateEnclosures: []PodcastAlternateEnclosure{
+		{Type: "audio/mpeg", Title: "MP3"},
+		{Type: "video/mp4", Title: "Trailer", Rel: "trailer"},
+	}}
+	if selected, ok := onlyAlternates.SelectEnclosure(EnclosurePreferences{Types: []string{"audio/opus"}}); ok {
+		t.Errorf("expected no rendition when every alternate is filtered out, got %q", selected.Title)
+	}
+}
@@ -0,0 +1,230 @@
+package podcast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Namespaces maps the URLs of the namespaces this package supports to the
+// prefix [Encode] declares them with.
+var Namespaces = map[string]string{
+	ItunesNamespaceURL:      "itunes",
+	PodcastNamepaceURL:      "podcast",
+	GoogleplayNamespaceURL:  "googleplay",
+	SpotifyNamespaceURL:     "spotify",
+	MediaNamespaceURL:       "media",
+	PscNamespaceURL:         "psc",
+	AtomNamespaceURL:        "atom",
+	FeedHistoryNamespaceURL: "fh",
+}
+
+// Encode writes the feed as an indented XML document in a canonical form.
+//
+// encoding/xml declares the namespace of every element on the element itself,
+// which is valid but hard to read, and trips up some podcast apps. Encode
+// instead declares each namespace in use once on the rss element, with the
+// prefix from [Namespaces], and writes RSS elements without a prefix. Text
+// containing HTML is written as CDATA. Encoding the same feed always gives
+// the same bytes.
+func Encode(w io.Writer, feed *RSSPodcast) error {
+	data, err := xml.Marshal(feed)
+	if err != nil {
+		return err
+	}
+	root, err := parseXMLNode(xml.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return fmt.Errorf("podcast: re-reading marshaled feed: %w", err)
+	}
+
+	e := &encoder{w: bufio.NewWriter(w), prefixes: map[string]string{}}
+	e.assignPrefixes(root)
+
+	// Declare the namespaces on the root element, sorted by prefix
+	var declarations []xml.Attr
+	for space, prefix := range e.prefixes {
+		if prefix != "" {
+			declarations = append(declarations, xml.Attr{Name: xml.Name{Space: "xmlns", Local: prefix}, Value: space})
+		}
+	}
+	sort.Slice(declarations, func(i, j int) bool {
+		return declarations[i].Name.Local < declarations[j].Name.Local
+	})
+	root.attrs = append(root.attrs, declarations...)
+
+	e.w.WriteString(xml.Header)
+	e.writeNode(root, 0)
+	e.w.WriteByte('\n')
+	return e.w.Flush()
+}
+
+// xmlNode is an element with its namespace declarations removed
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+// parseXMLNode reads the next element from decoder into a tree
+func parseXMLNode(decoder *xml.Decoder) (*xmlNode, error) {
+	var stack []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					continue
+				}
+				node.attrs = append(node.attrs, attr)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return node, nil
+			}
+		}
+	}
+}
+
+type encoder struct {
+	w *bufio.Writer
+
+	// prefixes maps namespace URLs to their prefix, "" for RSS
+	prefixes map[string]string
+}
+
+// assignPrefixes picks a prefix for each namespace used in the tree. Unknown
+// namespaces are numbered in order of their URL.
+func (e *encoder) assignPrefixes(root *xmlNode) {
+	var unknown []string
+	var visit func(node *xmlNode)
+	note := func(space string) {
+		if _, ok := e.prefixes[space]; ok {
+			return
+		}
+		switch prefix, known := Namespaces[space]; {
+		case space == "" || space == rss.RSSNamespace:
+			e.prefixes[space] = ""
+		case known:
+			e.prefixes[space] = prefix
+		default:
+			e.prefixes[space] = ""
+			unknown = append(unknown, space)
+		}
+	}
+	visit = func(node *xmlNode) {
+		note(node.name.Space)
+		for _, attr := range node.attrs {
+			if attr.Name.Space != "" {
+				note(attr.Name.Space)
+			}
+		}
+		for _, child := range node.children {
+			visit(child)
+		}
+	}
+	visit(root)
+
+	sort.Strings(unknown)
+	for i, space := range unknown {
+		e.prefixes[space] = fmt.Sprintf("ns%d", i+1)
+	}
+}
+
+func (e *encoder) qualified(name xml.Name) string {
+	switch prefix := e.prefixes[name.Space]; {
+	case name.Space == "xmlns":
+		return "xmlns:" + name.Local
+	case prefix != "":
+		return prefix + ":" + name.Local
+	}
+	return name.Local
+}
+
+func (e *encoder) writeNode(node *xmlNode, depth int) {
+	indent := strings.Repeat("\t", depth)
+	name := e.qualified(node.name)
+
+	e.w.WriteString("<" + name)
+	for _, attr := range node.attrs {
+		e.w.WriteString(" " + e.qualified(attr.Name) + `="`)
+		e.w.WriteString(escapeXML(attr.Value, true))
+		e.w.WriteByte('"')
+	}
+
+	text := node.text
+	if len(node.children) > 0 {
+		text = strings.TrimSpace(text)
+	}
+	if text == "" && len(node.children) == 0 {
+		e.w.WriteString("/>")
+		return
+	}
+	e.w.WriteByte('>')
+
+	if text != "" {
+		if strings.Contains(text, "<") {
+			e.w.WriteString("<![CDATA[" + strings.ReplaceAll(text, "]]>", "]]]]><![CDATA[>") + "]]>")
+		} else {
+			e.w.WriteString(escapeXML(text, false))
+		}
+	}
+	for _, child := range node.children {
+		e.w.WriteString("\n" + indent + "\t")
+		e.writeNode(child, depth+1)
+	}
+	if len(node.children) > 0 {
+		e.w.WriteString("\n" + indent)
+	}
+	e.w.WriteString("</" + name + ">")
+}
+
+// escapeXML escapes text for use in element content, or in a double quoted
+// attribute value, where white space other than spaces has to be escaped to
+// survive normalization.
+func escapeXML(s string, attr bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case attr && r == '"':
+			b.WriteString("&quot;")
+		case attr && (r == '\n' || r == '\r' || r == '\t'):
+			fmt.Fprintf(&b, "&#x%X;", r)
+		case r == '\r':
+			// A literal carriage return would be normalized away
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
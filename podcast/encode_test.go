@@ -0,0 +1,82 @@
+package podcast
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, name := range []string{"apple.rss", "example.xml", "media.rss", "more-complex.rss", "platforms.rss", "podcastindex.rss"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile("samples/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var encoded bytes.Buffer
+			if err := Encode(&encoded, expected); err != nil {
+				t.Fatal(err)
+			}
+			actual, err := Decode(bytes.NewReader(encoded.Bytes()))
+			if err != nil {
+				t.Fatalf("decoding %s: %v", encoded.String(), err)
+			}
+			if diff := cmp.Diff(expected, actual); diff != "" {
+				t.Errorf("Encode() round trip mismatch (-want +got):\n%s", diff)
+			}
+
+			var again bytes.Buffer
+			if err := Encode(&again, actual); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(encoded.String(), again.String()); diff != "" {
+				t.Errorf("Encode() isn't stable (-first +second):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	feed := &RSSPodcast{
+		Version: rss.RSSVersion,
+		Channel: Podcast{
+			Channel: rss.Channel{
+				Title:       "Tom & Jerry",
+				Link:        "https://example.com",
+				Description: rss.Description{Value: "<p>Cat & mouse</p>"},
+			},
+			ItunesAuthor: "Hanna-Barbera",
+			ItunesImage:  ItunesImageTag{Href: "https://example.com/a.jpg?b=1&c=\"2\""},
+			PodcastGUID:  "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+		},
+	}
+
+	var encoded bytes.Buffer
+	if err := Encode(&encoded, feed); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:podcast="https://podcastindex.org/namespace/1.0">
+	<channel>
+		<title>Tom &amp; Jerry</title>
+		<link>https://example.com</link>
+		<description><![CDATA[<p>Cat & mouse</p>]]></description>
+		<itunes:image href="https://example.com/a.jpg?b=1&amp;c=&quot;2&quot;"/>
+		<itunes:author>Hanna-Barbera</itunes:author>
+		<podcast:guid>917393e3-1b1e-5cef-ace4-edaa54e1f810</podcast:guid>
+	</channel>
+</rss>
+`
+	if diff := cmp.Diff(expected, encoded.String()); diff != "" {
+		t.Errorf("Encode() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,47 @@
+package podcast
+
+import "encoding/xml"
+
+// GoogleplayNamespaceURL is the namespace of the tags described in Google's
+// [RSS feed guidelines for Google Podcasts].
+//
+// Types beginning with "Googleplay" are from the Google Podcasts guidelines.
+// Google Podcasts has since shut down, but many feeds still carry these tags.
+//
+// [RSS feed guidelines for Google Podcasts]: https://support.google.com/podcast-publishers/answer/9889544
+const GoogleplayNamespaceURL = "http://www.google.com/schemas/play-podcasts/1.0"
+
+// GoogleplayImage is the artwork for the show.
+//
+// Example:
+//
+//	<googleplay:image href="https://www.example.com/podcasts/dafnas-zebras/img/dafna-zebra-pod-logo.jpg"/>
+type GoogleplayImage struct {
+	XMLName xml.Name `xml:"http://www.google.com/schemas/play-podcasts/1.0 image"`
+	Href    string   `xml:"href,attr"`
+}
+
+// GoogleplayCategory is a category for the show.
+//
+// Example:
+//
+//	<googleplay:category text="Technology"/>
+type GoogleplayCategory struct {
+	XMLName xml.Name `xml:"http://www.google.com/schemas/play-podcasts/1.0 category"`
+
+	// Text is the name of the category. Google Podcasts accepted the same list
+	// of categories as Apple Podcasts, see [ItunesCategory].
+	Text string `xml:"text,attr"`
+}
+
+// GoogleplayExplicit is the value of a <googleplay:explicit> tag.
+type GoogleplayExplicit string
+
+const (
+	// GoogleplayExplicitYes indicates the content is explicit.
+	GoogleplayExplicitYes GoogleplayExplicit = "yes"
+	// GoogleplayExplicitNo (default) indicates the content isn't explicit.
+	GoogleplayExplicitNo GoogleplayExplicit = "no"
+	// GoogleplayExplicitClean indicates an edited version of explicit content.
+	GoogleplayExplicitClean GoogleplayExplicit = "clean"
+)
@@ -0,0 +1,29 @@
+package podcast
+
+import (
+	"strings"
+
+	"github.com/jaydenmilne/podcast/internal/uuid"
+)
+
+// PodcastGUIDNamespace is the UUID namespace of [Podcast.PodcastGUID] values
+// derived from feed URLs.
+const PodcastGUIDNamespace = "ead4c236-bf58-58c6-a2c6-a6b28d128cb6"
+
+// GUIDFromFeedURL derives the value of [Podcast.PodcastGUID] from the feed's
+// URL, as the [spec] recommends: a version 5 UUID of the URL without its
+// scheme and trailing slashes.
+//
+// The GUID should not change once a feed is published, even if its URL does.
+//
+// [spec]: https://podcastindex.org/namespace/1.0#guid
+func GUIDFromFeedURL(feedURL string) string {
+	name := feedURL
+	if _, rest, ok := strings.Cut(name, "://"); ok {
+		name = rest
+	}
+	name = strings.TrimRight(name, "/")
+
+	namespace, _ := uuid.Parse(PodcastGUIDNamespace)
+	return uuid.NewV5(namespace, name).String()
+}
@@ -0,0 +1,17 @@
+package podcast
+
+import "testing"
+
+func TestGUIDFromFeedURL(t *testing.T) {
+	// The example from the podcast namespace spec
+	expected := "917393e3-1b1e-5cef-ace4-edaa54e1f810"
+	for _, feedURL := range []string{
+		"https://mp3s.nashownotes.com/pc20rss.xml",
+		"http://mp3s.nashownotes.com/pc20rss.xml/",
+		"mp3s.nashownotes.com/pc20rss.xml",
+	} {
+		if actual := GUIDFromFeedURL(feedURL); actual != expected {
+			t.Errorf("GUIDFromFeedURL(%q) = %s, expected %s", feedURL, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package podcast
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FeedSource returns the feed a [Handler] serves. changed reports whether the
+// feed differs from the one it last returned. When it's false, the handler
+// serves the feed it encoded before, and feed may be nil. It's ignored the
+// first time the source is called.
+type FeedSource func(ctx context.Context) (feed *RSSPodcast, changed bool, err error)
+
+// Handler is an [http.Handler] serving the feed from Source, encoded with
+// [Encode]. It answers GET and HEAD requests, gzips the feed for clients that
+// accept it, and answers conditional requests with the feed's ETag and its
+// LastBuildDate as the Last-Modified time. The encoded feed is kept until
+// Source reports a change.
+type Handler struct {
+	Source FeedSource
+
+	// CacheControl sets a Cache-Control max-age from the feed's TTL, for
+	// feeds that have one
+	CacheControl bool
+
+	mu     sync.Mutex
+	cached *encodedFeed
+}
+
+// encodedFeed is a feed ready to be served
+type encodedFeed struct {
+	body         []byte
+	gzipped      []byte
+	etag         string
+	lastModified time.Time
+	ttl          int
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	feed, err := h.encoded(r.Context())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "application/rss+xml; charset=utf-8")
+	header.Add("Vary", "Accept-Encoding")
+	if h.CacheControl && feed.ttl > 0 {
+		header.Set("Cache-Control", "max-age="+strconv.Itoa(feed.ttl*60))
+	}
+	body := feed.body
+	etag := `"` + feed.etag + `"`
+	if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		// Each encoding of the feed needs its own strong ETag
+		body = feed.gzipped
+		etag = `"` + feed.etag + `-gzip"`
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("ETag", etag)
+
+	// ServeContent handles HEAD, conditional and range requests
+	http.ServeContent(w, r, "", feed.lastModified, bytes.NewReader(body))
+}
+
+// encoded returns the feed from Source, encoding it if it changed
+func (h *Handler) encoded(ctx context.Context) (*encodedFeed, error) {
+	if h.Source == nil {
+		return nil, fmt.Errorf("podcast: handler has no source")
+	}
+	feed, changed, err := h.Source(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	cached := h.cached
+	h.mu.Unlock()
+	if cached != nil && !changed {
+		return cached, nil
+	}
+	if feed == nil {
+		return nil, fmt.Errorf("podcast: source returned no feed")
+	}
+
+	var body bytes.Buffer
+	if err := Encode(&body, feed); err != nil {
+		return nil, err
+	}
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write(body.Bytes())
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body.Bytes())
+	cached = &encodedFeed{
+		body:    body.Bytes(),
+		gzipped: gzipped.Bytes(),
+		etag:    hex.EncodeToString(sum[:16]),
+		ttl:     feed.Channel.TTL,
+	}
+	if lastBuild, err := feed.Channel.LastBuildDate.Time(); err == nil {
+		cached.lastModified = lastBuild
+	}
+
+	h.mu.Lock()
+	h.cached = cached
+	h.mu.Unlock()
+	return cached, nil
+}
+
+// acceptsGzip reports whether an Accept-Encoding header accepts gzip
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(key) == "q" {
+				q, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
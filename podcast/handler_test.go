@@ -0,0 +1,138 @@
+package podcast
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestHandler(t *testing.T) {
+	lastBuild := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	feed := &RSSPodcast{Version: "2.0", Channel: Podcast{Channel: rss.Channel{
+		Title:         "Example",
+		TTL:           60,
+		LastBuildDate: rss.NewRFC2822Date(lastBuild),
+	}}}
+	var calls int
+	changed := false
+	handler := &Handler{
+		CacheControl: true,
+		Source: func(ctx context.Context) (*RSSPodcast, bool, error) {
+			calls++
+			if calls > 1 && !changed {
+				return nil, false, nil
+			}
+			return feed, changed, nil
+		},
+	}
+	get := func(method string, header map[string]string) *http.Response {
+		t.Helper()
+		request := httptest.NewRequest(method, "/feed.xml", nil)
+		for key, value := range header {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Result()
+	}
+
+	response := get(http.MethodGet, nil)
+	body, _ := io.ReadAll(response.Body)
+	decoded, err := Decode(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Channel.Title != "Example" {
+		t.Errorf("unexpected feed %+v", decoded.Channel)
+	}
+	expectedHeader := map[string]string{
+		"Content-Type":  "application/rss+xml; charset=utf-8",
+		"Last-Modified": "Fri, 01 Mar 2024 12:00:00 GMT",
+		"Cache-Control": "max-age=3600",
+		"Vary":          "Accept-Encoding",
+	}
+	for key, expected := range expectedHeader {
+		if actual := response.Header.Get(key); actual != expected {
+			t.Errorf("expected %s %q, got %q", key, expected, actual)
+		}
+	}
+	etag := response.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+
+	// Compressed
+	response = get(http.MethodGet, map[string]string{"Accept-Encoding": "br;q=1, gzip;q=0.5"})
+	if response.Header.Get("Content-Encoding") != "gzip" || response.Header.Get("ETag") == etag {
+		t.Errorf("expected gzip with its own ETag, got %v", response.Header)
+	}
+	gz, err := gzip.NewReader(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unzipped, _ := io.ReadAll(gz)
+	if diff := cmp.Diff(string(body), string(unzipped)); diff != "" {
+		t.Errorf("unexpected gzipped body (-want +got):\n%s", diff)
+	}
+	if response := get(http.MethodGet, map[string]string{"Accept-Encoding": "gzip;q=0, *"}); response.Header.Get("Content-Encoding") != "" {
+		t.Errorf("expected no compression when gzip is refused")
+	}
+
+	// Conditional and HEAD requests
+	if response := get(http.MethodGet, map[string]string{"If-None-Match": etag}); response.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", response.StatusCode)
+	}
+	if response := get(http.MethodGet, map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 12:00:00 GMT"}); response.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 when not modified since, got %d", response.StatusCode)
+	}
+	if response := get(http.MethodGet, map[string]string{"If-Modified-Since": "Thu, 29 Feb 2024 12:00:00 GMT"}); response.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 when modified since, got %d", response.StatusCode)
+	}
+	response = get(http.MethodHead, nil)
+	if head, _ := io.ReadAll(response.Body); response.StatusCode != http.StatusOK || len(head) != 0 || response.Header.Get("ETag") != etag {
+		t.Errorf("unexpected HEAD response %d %q %v", response.StatusCode, head, response.Header)
+	}
+	if response := get(http.MethodPost, nil); response.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", response.StatusCode)
+	}
+
+	// Changes
+	feed.Channel.Title = "Changed"
+	if response := get(http.MethodGet, map[string]string{"If-None-Match": etag}); response.StatusCode != http.StatusNotModified {
+		t.Errorf("expected the cached feed until the source reports a change, got %d", response.StatusCode)
+	}
+	changed = true
+	response = get(http.MethodGet, map[string]string{"If-None-Match": etag})
+	body, _ = io.ReadAll(response.Body)
+	if decoded, err := Decode(bytes.NewReader(body)); response.StatusCode != http.StatusOK || err != nil || decoded.Channel.Title != "Changed" {
+		t.Errorf("expected the changed feed, got %d %q", response.StatusCode, body)
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	testCases := map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"GZIP":              true,
+		"deflate, gzip":     true,
+		"gzip;q=0":          false,
+		"gzip; q=0.001":     true,
+		"*":                 true,
+		"*;q=0":             false,
+		"gzip;q=0, *;q=1":   false,
+		"identity, deflate": false,
+	}
+	for header, expected := range testCases {
+		if actual := acceptsGzip(header); actual != expected {
+			t.Errorf("acceptsGzip(%q) = %v, expected %v", header, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package podcast
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+)
+
+// Values of [PodcastIntegrity.Type]
+const (
+	IntegrityTypeSRI          = "sri"
+	IntegrityTypePGPSignature = "pgp-signature"
+)
+
+// SRIAlgorithm is a hash function allowed in a [Subresource Integrity] string
+//
+// [Subresource Integrity]: https://www.w3.org/TR/SRI/
+type SRIAlgorithm string
+
+const (
+	SRISHA256 SRIAlgorithm = "sha256"
+	SRISHA384 SRIAlgorithm = "sha384"
+	SRISHA512 SRIAlgorithm = "sha512"
+)
+
+// ErrIntegrityMismatch is returned by [PodcastIntegrity.Verify] when the media
+// doesn't match the integrity value.
+var ErrIntegrityMismatch = errors.New("podcast: media does not match integrity")
+
+// sriStrength orders the algorithms, as the strongest one present in an SRI
+// string is the one that has to match.
+var sriStrength = map[SRIAlgorithm]int{
+	SRISHA256: 1,
+	SRISHA384: 2,
+	SRISHA512: 3,
+}
+
+func (a SRIAlgorithm) newHash() (hash.Hash, error) {
+	switch a {
+	case SRISHA256:
+		return sha256.New(), nil
+	case SRISHA384:
+		return sha512.New384(), nil
+	case SRISHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("podcast: unsupported SRI algorithm %q", a)
+}
+
+// ComputeSRI reads media until EOF and returns its Subresource Integrity
+// string, such as
+//
+//	sha384-ExVqijgYHm15PqQqdXfW95x+Rs6C+d6E/ICxyQOeFevnxNLR/wtJNrNYTjIysUBo
+//
+// If several algorithms are given, a hash for each is included, separated by
+// spaces. With no algorithms, sha384 is used.
+func ComputeSRI(media io.Reader, algorithms ...SRIAlgorithm) (string, error) {
+	if len(algorithms) == 0 {
+		algorithms = []SRIAlgorithm{SRISHA384}
+	}
+
+	hashes := make([]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, len(algorithms))
+	for i, algorithm := range algorithms {
+		h, err := algorithm.newHash()
+		if err != nil {
+			return "", err
+		}
+		hashes[i], writers[i] = h, h
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), media); err != nil {
+		return "", err
+	}
+
+	values := make([]string, len(algorithms))
+	for i, algorithm := range algorithms {
+		values[i] = string(algorithm) + "-" + base64.StdEncoding.EncodeToString(hashes[i].Sum(nil))
+	}
+	return strings.Join(values, " "), nil
+}
+
+// NewSRIIntegrity reads media until EOF and returns a <podcast:integrity>
+// holding its Subresource Integrity string. See [ComputeSRI].
+func NewSRIIntegrity(media io.Reader, algorithms ...SRIAlgorithm) (*PodcastIntegrity, error) {
+	value, err := ComputeSRI(media, algorithms...)
+	if err != nil {
+		return nil, err
+	}
+	return &PodcastIntegrity{Type: IntegrityTypeSRI, Value: value}, nil
+}
+
+// SetIntegrity reads media, which should be the file of this alternate
+// enclosure, until EOF and sets PodcastIntegrity to its Subresource Integrity
+// string. See [ComputeSRI].
+func (a *PodcastAlternateEnclosure) SetIntegrity(media io.Reader, algorithms ...SRIAlgorithm) error {
+	integrity, err := NewSRIIntegrity(media, algorithms...)
+	if err != nil {
+		return err
+	}
+	a.PodcastIntegrity = integrity
+	return nil
+}
+
+// PGPKeyring is a set of OpenPGP public keys trusted to sign media, used to
+// verify an integrity of type "pgp-signature".
+//
+// Only keys allowed to sign are used, and signatures by revoked or expired
+// keys, or that expired themselves, don't verify.
+type PGPKeyring struct {
+	keys openpgp.EntityList
+}
+
+// armorBegin starts each ASCII armored block
+var armorBegin = []byte("-----BEGIN PGP")
+
+// ReadPGPKeyring reads OpenPGP public keys, either binary or ASCII armored,
+// such as the output of
+//
+//	gpg --export --armor publisher@example.com
+//
+// Several armored blocks may follow each other.
+func ReadPGPKeyring(r io.Reader) (*PGPKeyring, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(data, armorBegin) {
+		keys, err := openpgp.ReadKeyRing(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("podcast: reading keyring: %w", err)
+		}
+		return &PGPKeyring{keys: keys}, nil
+	}
+
+	keyring := &PGPKeyring{}
+	for start := bytes.Index(data, armorBegin); start >= 0; {
+		block := data[start:]
+		next := bytes.Index(block[len(armorBegin):], armorBegin)
+		if next >= 0 {
+			block = block[:next+len(armorBegin)]
+		}
+		decoded, err := armor.Decode(bytes.NewReader(block))
+		if err != nil {
+			return nil, fmt.Errorf("podcast: reading keyring: %w", err)
+		}
+		keys, err := openpgp.ReadKeyRing(decoded.Body)
+		if err != nil {
+			return nil, fmt.Errorf("podcast: reading keyring: %w", err)
+		}
+		keyring.keys = append(keyring.keys, keys...)
+		if next < 0 {
+			break
+		}
+		start += next + len(armorBegin)
+	}
+	return keyring, nil
+}
+
+// Verify reads media until EOF and checks it against the integrity value,
+// returning [ErrIntegrityMismatch] if it doesn't match.
+//
+// For "sri" integrity, the strongest supported algorithm present decides, as
+// described by the [SRI spec]. keyring is ignored and may be nil.
+//
+// For "pgp-signature" integrity, Value must be a base64 encoded detached
+// signature made by a key in keyring.
+//
+// [SRI spec]: https://www.w3.org/TR/SRI/#does-response-match-metadatalist
+func (i *PodcastIntegrity) Verify(media io.Reader, keyring *PGPKeyring) error {
+	switch i.Type {
+	case IntegrityTypeSRI:
+		return verifySRI(media, i.Value)
+	case IntegrityTypePGPSignature:
+		return verifyPGP(media, i.Value, keyring)
+	}
+	return fmt.Errorf("podcast: unsupported integrity type %q", i.Type)
+}
+
+func verifySRI(media io.Reader, value string) error {
+	var strongest SRIAlgorithm
+	var expected [][]byte
+
+	for _, token := range strings.Fields(value) {
+		algorithm, digest, ok := strings.Cut(token, "-")
+		if !ok {
+			continue
+		}
+		// Options, such as sha384-abc?foo, are reserved and ignored
+		digest, _, _ = strings.Cut(digest, "?")
+
+		strength, supported := sriStrength[SRIAlgorithm(algorithm)]
+		if !supported || strength < sriStrength[strongest] {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(digest)
+		if err != nil {
+			continue
+		}
+
+		if strength > sriStrength[strongest] {
+			strongest, expected = SRIAlgorithm(algorithm), nil
+		}
+		expected = append(expected, decoded)
+	}
+
+	if strongest == "" {
+		return fmt.Errorf("podcast: no supported hash in integrity %q", value)
+	}
+
+	h, err := strongest.newHash()
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, media); err != nil {
+		return err
+	}
+	actual := h.Sum(nil)
+
+	for _, digest := range expected {
+		if subtle.ConstantTimeCompare(actual, digest) == 1 {
+			return nil
+		}
+	}
+	return ErrIntegrityMismatch
+}
+
+func verifyPGP(media io.Reader, value string, keyring *PGPKeyring) error {
+	if keyring == nil {
+		return errors.New("podcast: a keyring is required to verify a pgp-signature")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return fmt.Errorf("podcast: invalid pgp-signature: %w", err)
+	}
+
+	_, err = openpgp.CheckDetachedSignature(keyring.keys, media, bytes.NewReader(signature), nil)
+	var signatureErr pgperrors.SignatureError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &signatureErr):
+		return ErrIntegrityMismatch
+	}
+	return fmt.Errorf("podcast: pgp-signature: %w", err)
+}
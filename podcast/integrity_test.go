@@ -0,0 +1,289 @@
+package podcast
+
+import (
+	"bytes"
+	"crypto"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+//go:embed samples/integrity
+var integritySamples embed.FS
+
+func readIntegritySample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := integritySamples.ReadFile("samples/integrity/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestComputeSRI(t *testing.T) {
+	// Test vector from https://www.w3.org/TR/SRI/#integrity-metadata
+	media := "alert('Hello, world.');"
+
+	sri, err := ComputeSRI(strings.NewReader(media))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO"
+	if sri != expected {
+		t.Errorf("expected %q, got %q", expected, sri)
+	}
+
+	sri, err = ComputeSRI(strings.NewReader(media), SRISHA256, SRISHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "sha256-qznLcsROx4GACP2dm0UCKCzCG+HiZ1guq6ZZDob/Tng= sha512-Q2bFTOhEALkN8hOms2FKTDLy7eugP2zFZ1T8LCvX42Fp3WoNr3bjZSAHeOsHrbV1Fu9/A0EzCinRE7Af1ofPrw=="
+	if sri != expected {
+		t.Errorf("expected %q, got %q", expected, sri)
+	}
+
+	if _, err := ComputeSRI(strings.NewReader(media), "md5"); err == nil {
+		t.Errorf("expected an error for md5")
+	}
+}
+
+func TestVerifySRI(t *testing.T) {
+	media := []byte("alert('Hello, world.');")
+
+	alternate := PodcastAlternateEnclosure{Type: "audio/mpeg"}
+	if err := alternate.SetIntegrity(bytes.NewReader(media), SRISHA256, SRISHA384); err != nil {
+		t.Fatal(err)
+	}
+	if alternate.PodcastIntegrity.Type != IntegrityTypeSRI {
+		t.Errorf("expected type sri, got %q", alternate.PodcastIntegrity.Type)
+	}
+	if err := alternate.PodcastIntegrity.Verify(bytes.NewReader(media), nil); err != nil {
+		t.Errorf("expected a match, got %v", err)
+	}
+	if err := alternate.PodcastIntegrity.Verify(strings.NewReader("tampered"), nil); !errors.Is(err, ErrIntegrityMismatch) {
+		t.Errorf("expected a mismatch, got %v", err)
+	}
+
+	testCases := []struct {
+		name  string
+		value string
+		err   error
+	}{
+		// Only the strongest algorithm counts, so a wrong sha256 is ignored
+		{"strongest wins", "sha256-AAAA sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO", nil},
+		{"wrong strongest", "sha256-qznLcsROx4GACP2dm0UCKCzCG+HiZ1guq6ZZDob/Tng= sha512-AAAA", ErrIntegrityMismatch},
+		{"any of the strongest", "sha384-AAAA sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO", nil},
+		{"options are ignored", "sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO?foo", nil},
+		{"unknown algorithms are ignored", "md5-AAAA sha256-qznLcsROx4GACP2dm0UCKCzCG+HiZ1guq6ZZDob/Tng=", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			integrity := PodcastIntegrity{Type: IntegrityTypeSRI, Value: tc.value}
+			if err := integrity.Verify(bytes.NewReader(media), nil); !errors.Is(err, tc.err) {
+				t.Errorf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+
+	integrity := PodcastIntegrity{Type: IntegrityTypeSRI, Value: "md5-AAAA"}
+	if err := integrity.Verify(bytes.NewReader(media), nil); err == nil || errors.Is(err, ErrIntegrityMismatch) {
+		t.Errorf("expected an error for no supported hash, got %v", err)
+	}
+}
+
+func TestVerifyPGPSignature(t *testing.T) {
+	media := readIntegritySample(t, "media.mp3")
+
+	var keys []byte
+	keys = append(keys, readIntegritySample(t, "rsa.asc")...)
+	keys = append(keys, readIntegritySample(t, "ed25519.asc")...)
+	keyring, err := ReadPGPKeyring(bytes.NewReader(keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaOnly, err := ReadPGPKeyring(bytes.NewReader(readIntegritySample(t, "rsa.asc")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"rsa", "ed25519"} {
+		t.Run(name, func(t *testing.T) {
+			signature := readIntegritySample(t, "media.mp3."+name+".sig")
+			integrity := PodcastIntegrity{
+				Type:  IntegrityTypePGPSignature,
+				Value: base64.StdEncoding.EncodeToString(signature),
+			}
+
+			if err := integrity.Verify(bytes.NewReader(media), keyring); err != nil {
+				t.Errorf("expected a valid signature, got %v", err)
+			}
+
+			tampered := append([]byte("x"), media...)
+			if err := integrity.Verify(bytes.NewReader(tampered), keyring); !errors.Is(err, ErrIntegrityMismatch) {
+				t.Errorf("expected a mismatch, got %v", err)
+			}
+
+			if err := integrity.Verify(bytes.NewReader(media), nil); err == nil {
+				t.Errorf("expected an error without a keyring")
+			}
+		})
+	}
+
+	// Signed by a key that isn't trusted
+	integrity := PodcastIntegrity{
+		Type:  IntegrityTypePGPSignature,
+		Value: base64.StdEncoding.EncodeToString(readIntegritySample(t, "media.mp3.ed25519.sig")),
+	}
+	if err := integrity.Verify(bytes.NewReader(media), rsaOnly); err == nil || errors.Is(err, ErrIntegrityMismatch) {
+		t.Errorf("expected an unknown issuer error, got %v", err)
+	}
+}
+
+// pgpFixture signs media with a generated key, returning the key's public
+// keyring and the integrity of the signature
+func pgpFixture(t *testing.T, entity *openpgp.Entity, media []byte, config *packet.Config) (*PGPKeyring, PodcastIntegrity) {
+	t.Helper()
+	var signature bytes.Buffer
+	if err := openpgp.DetachSign(&signature, entity, bytes.NewReader(media), config); err != nil {
+		t.Fatal(err)
+	}
+	return pgpKeyring(t, entity), PodcastIntegrity{
+		Type:  IntegrityTypePGPSignature,
+		Value: base64.StdEncoding.EncodeToString(signature.Bytes()),
+	}
+}
+
+func pgpKeyring(t *testing.T, entity *openpgp.Entity) *PGPKeyring {
+	t.Helper()
+	var public bytes.Buffer
+	if err := entity.Serialize(&public); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := ReadPGPKeyring(&public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func newPGPEntity(t *testing.T, config *packet.Config) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Publisher", "", "publisher@example.com", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entity
+}
+
+func TestVerifyPGPAlgorithms(t *testing.T) {
+	media := []byte("some audio")
+	testCases := []struct {
+		name   string
+		config *packet.Config
+	}{
+		{"rsa", &packet.Config{Algorithm: packet.PubKeyAlgoRSA, RSABits: 2048}},
+		{"ecdsa p-256", &packet.Config{Algorithm: packet.PubKeyAlgoECDSA, Curve: packet.CurveNistP256}},
+		{"ecdsa p-384", &packet.Config{Algorithm: packet.PubKeyAlgoECDSA, Curve: packet.CurveNistP384}},
+		{"ed25519 legacy", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}},
+		{"ed25519", &packet.Config{Algorithm: packet.PubKeyAlgoEd25519}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyring, integrity := pgpFixture(t, newPGPEntity(t, tc.config), media, tc.config)
+			if err := integrity.Verify(bytes.NewReader(media), keyring); err != nil {
+				t.Errorf("expected a valid signature, got %v", err)
+			}
+			if err := integrity.Verify(strings.NewReader("other audio"), keyring); !errors.Is(err, ErrIntegrityMismatch) {
+				t.Errorf("expected a mismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyPGPUntrustedKeys(t *testing.T) {
+	media := []byte("some audio")
+
+	t.Run("encryption subkey", func(t *testing.T) {
+		entity := newPGPEntity(t, &packet.Config{Algorithm: packet.PubKeyAlgoRSA, RSABits: 2048})
+		subkey := entity.Subkeys[0]
+		if subkey.Sig.FlagSign || !subkey.Sig.FlagEncryptCommunications {
+			t.Fatal("expected an encryption-only subkey")
+		}
+		sig := &packet.Signature{
+			Version:      subkey.PublicKey.Version,
+			SigType:      packet.SigTypeBinary,
+			PubKeyAlgo:   subkey.PublicKey.PubKeyAlgo,
+			Hash:         crypto.SHA256,
+			CreationTime: time.Now(),
+			IssuerKeyId:  &subkey.PublicKey.KeyId,
+		}
+		h, err := sig.PrepareSign(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write(media)
+		if err := sig.Sign(h, subkey.PrivateKey, nil); err != nil {
+			t.Fatal(err)
+		}
+		var signature bytes.Buffer
+		if err := sig.Serialize(&signature); err != nil {
+			t.Fatal(err)
+		}
+		integrity := PodcastIntegrity{Type: IntegrityTypePGPSignature, Value: base64.StdEncoding.EncodeToString(signature.Bytes())}
+		if err := integrity.Verify(bytes.NewReader(media), pgpKeyring(t, entity)); err == nil {
+			t.Errorf("expected a signature by an encryption-only subkey to fail")
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		created := time.Now().Add(-time.Hour)
+		config := &packet.Config{
+			Algorithm:       packet.PubKeyAlgoEdDSA,
+			KeyLifetimeSecs: 60,
+			Time:            func() time.Time { return created },
+		}
+		keyring, integrity := pgpFixture(t, newPGPEntity(t, config), media, config)
+		if err := integrity.Verify(bytes.NewReader(media), keyring); err == nil || errors.Is(err, ErrIntegrityMismatch) {
+			t.Errorf("expected an expired key error, got %v", err)
+		}
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		entity := newPGPEntity(t, &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+		_, integrity := pgpFixture(t, entity, media, nil)
+		if err := entity.RevokeKey(packet.KeyCompromised, "leaked", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := integrity.Verify(bytes.NewReader(media), pgpKeyring(t, entity)); err == nil || errors.Is(err, ErrIntegrityMismatch) {
+			t.Errorf("expected a revoked key error, got %v", err)
+		}
+	})
+
+	t.Run("revoked subkey", func(t *testing.T) {
+		config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+		entity := newPGPEntity(t, config)
+		if err := entity.AddSigningSubkey(config); err != nil {
+			t.Fatal(err)
+		}
+		signing := &entity.Subkeys[len(entity.Subkeys)-1]
+		signConfig := &packet.Config{SigningKeyId: signing.PublicKey.KeyId}
+		keyring, integrity := pgpFixture(t, entity, media, signConfig)
+		if err := integrity.Verify(bytes.NewReader(media), keyring); err != nil {
+			t.Fatalf("expected the signing subkey's signature to verify, got %v", err)
+		}
+		if err := entity.RevokeSubkey(signing, packet.KeyCompromised, "leaked", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := integrity.Verify(bytes.NewReader(media), pgpKeyring(t, entity)); err == nil || errors.Is(err, ErrIntegrityMismatch) {
+			t.Errorf("expected a revoked subkey error, got %v", err)
+		}
+	})
+}
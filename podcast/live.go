@@ -0,0 +1,178 @@
+package podcast
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// iso8601Layouts are the layouts tried, in order, by [ISO8601Timestamp.Time].
+// The spec's own examples leave the colon out of the offset.
+var iso8601Layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04Z0700",
+}
+
+// NewISO8601Timestamp formats t as an ISO 8601 timestamp, such as
+//
+//	2021-09-26T07:30:00.000-06:00
+func NewISO8601Timestamp(t time.Time) ISO8601Timestamp {
+	return ISO8601Timestamp(t.Format("2006-01-02T15:04:05.000Z07:00"))
+}
+
+// Time parses the timestamp, which must have a time zone
+func (ts ISO8601Timestamp) Time() (time.Time, error) {
+	value := strings.TrimSpace(string(ts))
+	if value == "" {
+		return time.Time{}, fmt.Errorf("podcast: empty timestamp")
+	}
+	for _, layout := range iso8601Layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("podcast: unrecognized timestamp %q", value)
+}
+
+// PodpingReason is why a feed changed, which is sent along with podpings
+//
+// See [podping]
+//
+// [podping]: https://github.com/Podcastindex-org/podping-hivewriter#podping-reasons
+type PodpingReason string
+
+const (
+	PodpingReasonUpdate  PodpingReason = "update"
+	PodpingReasonLive    PodpingReason = "live"
+	PodpingReasonLiveEnd PodpingReason = "liveEnd"
+)
+
+// ErrInvalidTransition is returned by [PodcastLiveItem.Transition] for
+// transitions other than pending to live and live to ended
+var ErrInvalidTransition = errors.New("podcast: invalid live item transition")
+
+// Schedule makes the live item pending, to start at start and end at end,
+// which may be zero if it isn't known
+func (l *PodcastLiveItem) Schedule(start, end time.Time) error {
+	if start.IsZero() {
+		return fmt.Errorf("podcast: live item has no start time")
+	}
+	if !end.IsZero() && !end.After(start) {
+		return fmt.Errorf("podcast: live item ends at %s, before it starts at %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	l.Status = StatusPending
+	l.Start = NewISO8601Timestamp(start)
+	l.End = ""
+	if !end.IsZero() {
+		l.End = NewISO8601Timestamp(end)
+	}
+	return nil
+}
+
+// Times parses the start and end of the live item, and checks that it ends
+// after it starts. The end is zero if it isn't set.
+func (l *PodcastLiveItem) Times() (start, end time.Time, err error) {
+	start, err = l.Start.Time()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("podcast: live item start: %w", err)
+	}
+	if strings.TrimSpace(string(l.End)) == "" {
+		return start, time.Time{}, nil
+	}
+	end, err = l.End.Time()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("podcast: live item end: %w", err)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("podcast: live item ends at %s, before it starts at %s", l.End, l.Start)
+	}
+	return start, end, nil
+}
+
+// Transition moves the live item from pending to live, or from live to
+// ended, at the time at, which becomes its start or end. It returns the
+// reason for the podping that should be sent once the feed is published, or
+// an error if the times it checks the new one against don't parse.
+func (l *PodcastLiveItem) Transition(to PodcastLiveStreamStatus, at time.Time) (PodpingReason, error) {
+	var reason PodpingReason
+	switch {
+	case l.Status == StatusPending && to == StatusLive:
+		reason = PodpingReasonLive
+		if !at.IsZero() {
+			// A planned end that passed before the stream started is wrong
+			if strings.TrimSpace(string(l.End)) != "" {
+				end, err := l.End.Time()
+				if err != nil {
+					return "", fmt.Errorf("podcast: live item end: %w", err)
+				}
+				if !end.After(at) {
+					l.End = ""
+				}
+			}
+			l.Start = NewISO8601Timestamp(at)
+		}
+	case l.Status == StatusLive && to == StatusEnded:
+		reason = PodpingReasonLiveEnd
+		if !at.IsZero() {
+			start, err := l.Start.Time()
+			if err != nil {
+				return "", fmt.Errorf("podcast: live item start: %w", err)
+			}
+			if !at.After(start) {
+				return "", fmt.Errorf("podcast: live item can't end at %s, before it started at %s", at.Format(time.RFC3339), l.Start)
+			}
+			l.End = NewISO8601Timestamp(at)
+		}
+	default:
+		return "", fmt.Errorf("%w from %q to %q", ErrInvalidTransition, l.Status, to)
+	}
+	l.Status = to
+	return reason, nil
+}
+
+// Recording converts an ended live item into an episode of its recording.
+// The episode is published when the stream started, and keeps the live
+// item's GUID and metadata, but not its stream enclosures.
+func (l *PodcastLiveItem) Recording(recording *rss.Enclosure) (Episode, error) {
+	if l.Status != StatusEnded {
+		return Episode{}, fmt.Errorf("podcast: only ended live items have a recording, not %q ones", l.Status)
+	}
+	if recording == nil || recording.URL == "" {
+		return Episode{}, fmt.Errorf("podcast: missing recording enclosure")
+	}
+
+	episode := l.Episode
+	episode.Enclosure = recording
+	episode.PodcastAlternateEnclosures = nil
+	start, end, err := l.Times()
+	if err != nil {
+		return Episode{}, err
+	}
+	episode.PubDate = rss.NewRFC2822Date(start)
+	if episode.ItunesDuration == "" && !end.IsZero() {
+		episode.ItunesDuration = strconv.Itoa(int(end.Sub(start).Seconds()))
+	}
+	return episode, nil
+}
+
+// PublishRecording replaces the ended live item at index i of
+// p.PodcastLiveItem with an episode of its recording, at the top of the
+// show's episodes
+func (p *Podcast) PublishRecording(i int, recording *rss.Enclosure) error {
+	if i < 0 || i >= len(p.PodcastLiveItem) {
+		return fmt.Errorf("podcast: no live item %d", i)
+	}
+	episode, err := p.PodcastLiveItem[i].Recording(recording)
+	if err != nil {
+		return err
+	}
+	p.PodcastLiveItem = append(p.PodcastLiveItem[:i:i], p.PodcastLiveItem[i+1:]...)
+	p.Items = append([]Episode{episode}, p.Items...)
+	return nil
+}
@@ -0,0 +1,121 @@
+package podcast
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestISO8601Timestamp(t *testing.T) {
+	expected := time.Date(2021, 9, 26, 7, 30, 0, 0, time.FixedZone("", -6*60*60))
+	for _, value := range []ISO8601Timestamp{
+		"2021-09-26T07:30:00.000-0600",
+		"2021-09-26T07:30:00-06:00",
+		"2021-09-26T13:30:00Z",
+		"2021-09-26T07:30-06:00",
+		NewISO8601Timestamp(expected),
+	} {
+		actual, err := value.Time()
+		if err != nil {
+			t.Errorf("%s: %v", value, err)
+		} else if !actual.Equal(expected) {
+			t.Errorf("%s: expected %v, got %v", value, expected, actual)
+		}
+	}
+	for _, value := range []ISO8601Timestamp{"", "2021-09-26", "2021-09-26T07:30:00"} {
+		if _, err := value.Time(); err == nil {
+			t.Errorf("expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestLiveItemLifecycle(t *testing.T) {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	p := &Podcast{Items: []Episode{{Item: rss.Item{Title: "Earlier"}}}}
+	p.PodcastLiveItem = []PodcastLiveItem{{
+		Episode: Episode{
+			Item: rss.Item{
+				Title:     "Live show",
+				GUID:      &rss.GUID{Value: "live-1"},
+				Enclosure: &rss.Enclosure{URL: "https://example.com/stream", Type: "audio/mpeg"},
+			},
+			PodcastAlternateEnclosures: []PodcastAlternateEnclosure{{Type: "application/x-mpegURL"}},
+		},
+	}}
+	live := &p.PodcastLiveItem[0]
+
+	if err := live.Schedule(start, start.Add(-time.Hour)); err == nil {
+		t.Error("expected an end before the start to be rejected")
+	}
+	if err := live.Schedule(start, start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if live.Status != StatusPending || live.Start != "2024-03-04T18:00:00.000Z" || live.End != "2024-03-04T20:00:00.000Z" {
+		t.Errorf("unexpected scheduled live item %s %s %s", live.Status, live.Start, live.End)
+	}
+	if _, err := live.Transition(StatusEnded, start); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("expected pending items not to end, got %v", err)
+	}
+	if _, err := live.Recording(&rss.Enclosure{URL: "https://example.com/recording.mp3"}); err == nil {
+		t.Error("expected pending items to have no recording")
+	}
+
+	planned := live.End
+	live.End = "soon"
+	if _, err := live.Transition(StatusLive, start.Add(5*time.Minute)); err == nil || live.Status != StatusPending {
+		t.Errorf("expected an unparsable end to be an error, got %v", err)
+	}
+	live.End = planned
+
+	reason, err := live.Transition(StatusLive, start.Add(5*time.Minute))
+	if err != nil || reason != PodpingReasonLive {
+		t.Fatalf("expected a live podping, got %q, %v", reason, err)
+	}
+	if live.Start != "2024-03-04T18:05:00.000Z" {
+		t.Errorf("expected the actual start time, got %s", live.Start)
+	}
+	if _, err := live.Transition(StatusLive, time.Time{}); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("expected live items not to go live again, got %v", err)
+	}
+	if _, err := live.Transition(StatusEnded, start); err == nil {
+		t.Error("expected an end before the start to be rejected")
+	}
+
+	started := live.Start
+	live.Start = "yesterday"
+	if _, err := live.Transition(StatusEnded, start.Add(95*time.Minute)); err == nil || live.Status != StatusLive {
+		t.Errorf("expected an unparsable start to be an error, got %v", err)
+	}
+	live.Start = started
+
+	reason, err = live.Transition(StatusEnded, start.Add(95*time.Minute))
+	if err != nil || reason != PodpingReasonLiveEnd {
+		t.Fatalf("expected a liveEnd podping, got %q, %v", reason, err)
+	}
+	if live.End != "2024-03-04T19:35:00.000Z" {
+		t.Errorf("expected the actual end time, got %s", live.End)
+	}
+
+	recording := &rss.Enclosure{URL: "https://example.com/recording.mp3", Length: 1000, Type: "audio/mpeg"}
+	if err := p.PublishRecording(0, recording); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.PodcastLiveItem) != 0 || len(p.Items) != 2 {
+		t.Fatalf("expected the live item to become an episode, got %d live items and %d episodes", len(p.PodcastLiveItem), len(p.Items))
+	}
+	expected := Episode{
+		Item: rss.Item{
+			Title:     "Live show",
+			GUID:      &rss.GUID{Value: "live-1"},
+			Enclosure: recording,
+			PubDate:   "Mon, 04 Mar 2024 18:05:00 +0000",
+		},
+		ItunesDuration: "5400",
+	}
+	if diff := cmp.Diff(expected, p.Items[0]); diff != "" {
+		t.Errorf("unexpected recording (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,298 @@
+package podcast
+
+import "encoding/xml"
+
+// MediaNamespaceURL is the namespace of [Media RSS], an RSS module which
+// supplements the <enclosure> capabilities of RSS 2.0. It is used heavily by
+// video podcasts and YouTube derived feeds.
+//
+// Types beginning with "Media" are from the Media RSS spec.
+//
+// [Media RSS]: https://www.rssboard.org/media-rss
+const MediaNamespaceURL = "http://search.yahoo.com/mrss/"
+
+// MediaMedium is the type of object in a [MediaContent]. While this attribute
+// can at times seem redundant if type is supplied, it is included because it
+// simplifies decision making on the reader side, as well as flushes out any
+// ambiguities between MIME type and object type.
+type MediaMedium string
+
+const (
+	MediaMediumImage      MediaMedium = "image"
+	MediaMediumAudio      MediaMedium = "audio"
+	MediaMediumVideo      MediaMedium = "video"
+	MediaMediumDocument   MediaMedium = "document"
+	MediaMediumExecutable MediaMedium = "executable"
+)
+
+// MediaExpression determines if the object is a sample or the full version of
+// the object, or even if it is a continuous stream.
+type MediaExpression string
+
+const (
+	// MediaExpressionFull (default)
+	MediaExpressionFull    MediaExpression = "full"
+	MediaExpressionSample  MediaExpression = "sample"
+	MediaExpressionNonstop MediaExpression = "nonstop"
+)
+
+// MediaTextType is the type of text embedded in a [MediaTitle] or
+// [MediaDescription]
+type MediaTextType string
+
+const (
+	// MediaTextPlain (default)
+	MediaTextPlain MediaTextType = "plain"
+	MediaTextHTML  MediaTextType = "html"
+)
+
+// MediaGroup allows grouping of [MediaContent] elements that are effectively
+// the same content, yet different representations. For instance: the same
+// song recorded in both the WAV and MP3 format. It's an optional element that
+// must only be used for this purpose.
+//
+// See [spec]
+//
+// [spec]: https://www.rssboard.org/media-rss#media-group
+type MediaGroup struct {
+	XMLName xml.Name `xml:"http://search.yahoo.com/mrss/ group"`
+
+	Contents    []MediaContent    `xml:"http://search.yahoo.com/mrss/ content,omitempty"`
+	Title       *MediaTitle       `xml:"http://search.yahoo.com/mrss/ title,omitempty"`
+	Description *MediaDescription `xml:"http://search.yahoo.com/mrss/ description,omitempty"`
+	Thumbnails  []MediaThumbnail  `xml:"http://search.yahoo.com/mrss/ thumbnail,omitempty"`
+	Ratings     []MediaRating     `xml:"http://search.yahoo.com/mrss/ rating,omitempty"`
+	Credits     []MediaCredit     `xml:"http://search.yahoo.com/mrss/ credit,omitempty"`
+}
+
+// MediaContent is a sub-element of either <item> or <media:group>. Media
+// objects that are not the same content should not be included in the same
+// <media:group> element.
+//
+// Example:
+//
+//	<media:content
+//	 url="http://www.foo.com/movie.mov"
+//	 fileSize="12216320"
+//	 type="video/quicktime"
+//	 medium="video"
+//	 isDefault="true"
+//	 expression="full"
+//	 bitrate="128"
+//	 duration="185"
+//	 height="200"
+//	 width="300"
+//	 lang="en" />
+//
+// See [spec]
+//
+// [spec]: https://www.rssboard.org/media-rss#media-content
+type MediaContent struct {
+	XMLName xml.Name `xml:"http://search.yahoo.com/mrss/ content"`
+
+	// URL (recommended) should specify the direct URL to the media object. If
+	// not included, a <media:player> element must be specified.
+	URL string `xml:"url,attr,omitempty"`
+
+	// FileSize (optional) is the number of bytes of the media object.
+	FileSize int64 `xml:"fileSize,attr,omitempty"`
+
+	// Type (optional) is the standard MIME type of the object.
+	Type string `xml:"type,attr,omitempty"`
+
+	// Medium (optional) is the type of object (image | audio | video |
+	// document | executable).
+	Medium MediaMedium `xml:"medium,attr,omitempty"`
+
+	// IsDefault (optional) determines if this is the default object that
+	// should be used for the <media:group>. There should only be one default
+	// object per <media:group>.
+	IsDefault *bool `xml:"isDefault,attr,omitempty"`
+
+	// Expression (optional) determines if the object is a sample or the full
+	// version of the object, or even if it is a continuous stream (sample |
+	// full | nonstop). Default value is "full".
+	Expression MediaExpression `xml:"expression,attr,omitempty"`
+
+	// Bitrate (optional) is the kilobits per second rate of media.
+	Bitrate float32 `xml:"bitrate,attr,omitempty"`
+
+	// Framerate (optional) is the number of frames per second for the media
+	// object.
+	Framerate float32 `xml:"framerate,attr,omitempty"`
+
+	// SamplingRate (optional) is the number of samples per second taken to
+	// create the media object. It is expressed in thousands of samples per
+	// second (kHz).
+	SamplingRate float32 `xml:"samplingrate,attr,omitempty"`
+
+	// Channels (optional) is number of audio channels in the media object.
+	Channels int `xml:"channels,attr,omitempty"`
+
+	// Duration (optional) is the number of seconds the media object plays.
+	Duration int `xml:"duration,attr,omitempty"`
+
+	// Height (optional) is the height of the media object.
+	Height int `xml:"height,attr,omitempty"`
+
+	// Width (optional) is the width of the media object.
+	Width int `xml:"width,attr,omitempty"`
+
+	// Lang (optional) is the primary language encapsulated in the media
+	// object. Language codes possible are detailed in RFC 3066.
+	Lang string `xml:"lang,attr,omitempty"`
+
+	Title       *MediaTitle       `xml:"http://search.yahoo.com/mrss/ title,omitempty"`
+	Description *MediaDescription `xml:"http://search.yahoo.com/mrss/ description,omitempty"`
+	Thumbnails  []MediaThumbnail  `xml:"http://search.yahoo.com/mrss/ thumbnail,omitempty"`
+	Ratings     []MediaRating     `xml:"http://search.yahoo.com/mrss/ rating,omitempty"`
+	Credits     []MediaCredit     `xml:"http://search.yahoo.com/mrss/ credit,omitempty"`
+}
+
+// MediaTitle is the title of the particular media object.
+//
+// See [spec]
+//
+// [spec]: https://www.rssboard.org/media-rss#media-title
+type MediaTitle struct {
+	XMLName xml.Name `xml:"http://search.yahoo.com/mrss/ title"`
+
+	Value string `xml:",chardata"`
+
+	// Type specifies the type of text embedded. Possible values are either
+	// "plain" or "html". Default value is "plain".
+	Type MediaTextType `xml:"type,attr,omitempty"`
+}
+
+// MediaDescription is a short description describing the media object
+// typically a sentence in length.
+//
+// See [spec]
+//
+// [spec]: https://www.rssboard.org/media-rss#media-description
+type MediaDescription struct {
+	XMLName xml.Name `xml:"http://search.yahoo.com/mrss/ description"`
+
+	Value string `xml:",chardata"`
+
+	// Type specifies the type of text embedded. Possible values are either
+	// "plain" or "html". Default value is "plain".
+	Type MediaTextType `xml:"type,attr,omitempty"`
+}
+
+// MediaThumbnail allows particular images to be used as representative images
+// for the media object. If multiple thumbnails are included, and time coding
+// is not at play, it is assumed that the images are in order of importance.
+//
+// See [spec]
+//
+// [spec]: https://www.rssboard.org/media-rss#media-thumbnails
+type MediaThumbnail struct {
+	XMLName xml.Name `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+
+	// URL (required) specifies the url of the thumbnail.
+	URL string `xml:"url,attr"`
+
+	// Height (optional) specifies the height of the thumbnail.
+	Height int `xml:"height,attr,omitempty"`
+
+	// Width (optional) specifies the width of the thumbnail.
+	Width int `xml:"width,attr,omitempty"`
+
+	// Time (optional) specifies the time offset in relation to the media
+	// object, in [NTP] format such as 12:05:01.123
+	//
+	// [NTP]: https://www.ietf.org/rfc/rfc2326.txt
+	Time string `xml:"time,attr,omitempty"`
+}
+
+// MediaRating allows the permissible audience to be declared. If this element
+// is not included, it assumes that no restrictions are necessary.
+//
+// Example:
+//
+//	<media:rating scheme="urn:simple">adult</media:rating>
+//
+// See [spec]
+//
+// [spec]: https://www.rssboard.org/media-rss#media-rating
+type MediaRating struct {
+	XMLName xml.Name `xml:"http://search.yahoo.com/mrss/ rating"`
+
+	Value string `xml:",chardata"`
+
+	// Scheme is the URI that identifies the rating scheme. If this attribute
+	// is not included, the default scheme is urn:simple (adult | nonadult).
+	Scheme string `xml:"scheme,attr,omitempty"`
+}
+
+// MediaCredit notes the entity's contribution to the creation of the media
+// object. Current entities can include people, companies, locations, etc.
+//
+// Example:
+//
+//	<media:credit role="producer" scheme="urn:ebu">entity name</media:credit>
+//
+// See [spec]
+//
+// [spec]: https://www.rssboard.org/media-rss#media-credit
+type MediaCredit struct {
+	XMLName xml.Name `xml:"http://search.yahoo.com/mrss/ credit"`
+
+	Value string `xml:",chardata"`
+
+	// Role (optional) specifies the role the entity played. Must be lowercase.
+	Role string `xml:"role,attr,omitempty"`
+
+	// Scheme (optional) is the URI that identifies the role scheme. If this
+	// attribute is not included, the default scheme is 'urn:ebu'.
+	Scheme string `xml:"scheme,attr,omitempty"`
+}
+
+// AlternateEnclosure maps a media:content rendition onto the equivalent
+// [PodcastAlternateEnclosure]. Media RSS bitrates are in kilobits per second
+// and are converted to bits per second.
+func (c MediaContent) AlternateEnclosure() PodcastAlternateEnclosure {
+	alternate := PodcastAlternateEnclosure{
+		Type:    c.Type,
+		Length:  int(c.FileSize),
+		Bitrate: c.Bitrate * 1000,
+		Height:  c.Height,
+		Lang:    c.Lang,
+		Default: c.IsDefault,
+	}
+
+	if c.Title != nil {
+		alternate.Title = c.Title.Value
+	}
+
+	if c.URL != "" {
+		alternate.Source = []PodcastSource{{URI: c.URL}}
+	}
+
+	return alternate
+}
+
+// MediaAlternateEnclosures maps every media:content rendition of the episode,
+// including those inside a media:group, to a [PodcastAlternateEnclosure]. Use
+// this for feeds that offer video renditions via Media RSS rather than the
+// podcast namespace.
+func (e *Episode) MediaAlternateEnclosures() []PodcastAlternateEnclosure {
+	var alternates []PodcastAlternateEnclosure
+
+	for _, content := range e.MediaContents {
+		alternates = append(alternates, content.AlternateEnclosure())
+	}
+
+	for _, group := range e.MediaGroups {
+		for _, content := range group.Contents {
+			alternate := content.AlternateEnclosure()
+			if alternate.Title == "" && group.Title != nil {
+				alternate.Title = group.Title.Value
+			}
+			alternates = append(alternates, alternate)
+		}
+	}
+
+	return alternates
+}
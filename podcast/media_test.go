@@ -0,0 +1,97 @@
+package podcast
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+//go:embed samples/media.rss
+var MediaSample []byte
+
+func TestMediaRSS(t *testing.T) {
+	var pod RSSPodcast
+	if err := rss.GetDecoder(bytes.NewReader(MediaSample)).Decode(&pod); err != nil {
+		t.Fatalf("failure to unmarshal: %s", err)
+	}
+
+	if len(pod.Channel.MediaThumbnails) != 1 || pod.Channel.MediaThumbnails[0].Width != 1280 {
+		t.Errorf("channel thumbnail not decoded: %+v", pod.Channel.MediaThumbnails)
+	}
+	if len(pod.Channel.MediaCredits) != 1 || pod.Channel.MediaCredits[0].Role != "producer" {
+		t.Errorf("channel credit not decoded: %+v", pod.Channel.MediaCredits)
+	}
+
+	episode := pod.Channel.Items[0]
+	if episode.MediaTitle == nil || episode.MediaTitle.Value != "Episode 1: Pilot" {
+		t.Errorf("title not decoded: %+v", episode.MediaTitle)
+	}
+	if episode.MediaDescription == nil || episode.MediaDescription.Type != MediaTextHTML {
+		t.Errorf("description not decoded: %+v", episode.MediaDescription)
+	}
+	if len(episode.MediaRatings) != 1 || episode.MediaRatings[0].Value != "nonadult" {
+		t.Errorf("rating not decoded: %+v", episode.MediaRatings)
+	}
+	if len(episode.MediaGroups) != 1 || len(episode.MediaGroups[0].Contents) != 2 {
+		t.Fatalf("group not decoded: %+v", episode.MediaGroups)
+	}
+
+	marshalled, err := xml.Marshal(&pod)
+	if err != nil {
+		t.Fatalf("failure to marshal: %s", err)
+	}
+
+	var roundTwo RSSPodcast
+	if err := rss.GetDecoder(bytes.NewReader(marshalled)).Decode(&roundTwo); err != nil {
+		t.Fatalf("failure to unmarshal: %s", err)
+	}
+
+	if !cmp.Equal(pod, roundTwo) {
+		t.Errorf("document didn't match! %s", cmp.Diff(pod, roundTwo))
+	}
+}
+
+func TestMediaAlternateEnclosures(t *testing.T) {
+	var pod RSSPodcast
+	if err := rss.GetDecoder(bytes.NewReader(MediaSample)).Decode(&pod); err != nil {
+		t.Fatalf("failure to unmarshal: %s", err)
+	}
+
+	alternates := pod.Channel.Items[0].MediaAlternateEnclosures()
+
+	isDefault := true
+
+	expected := []PodcastAlternateEnclosure{
+		{
+			Type:   "video/mp4",
+			Source: []PodcastSource{{URI: "https://example.com/videocast/1-trailer.mp4"}},
+		},
+		{
+			Type:    "video/mp4",
+			Length:  90000000,
+			Bitrate: 4500000,
+			Title:   "Pilot",
+			Height:  1080,
+			Lang:    "en",
+			Default: &isDefault,
+			Source:  []PodcastSource{{URI: "https://example.com/videocast/1-1080.mp4"}},
+		},
+		{
+			Type:    "video/mp4",
+			Length:  20000000,
+			Bitrate: 900500,
+			Title:   "Low bandwidth",
+			Height:  480,
+			Lang:    "en",
+			Source:  []PodcastSource{{URI: "https://example.com/videocast/1-480.mp4"}},
+		},
+	}
+
+	if !cmp.Equal(expected, alternates) {
+		t.Errorf("alternate enclosures didn't match! %s", cmp.Diff(expected, alternates))
+	}
+}
@@ -0,0 +1,229 @@
+package podcast
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// DedupeKey is a way [Merge] identifies the same episode in several feeds
+type DedupeKey int
+
+const (
+	// DedupeGUID matches episodes with the same guid
+	DedupeGUID DedupeKey = iota
+	// DedupeFeedGUID matches episodes with the same guid from feeds with the
+	// same podcast:guid, which is safer than [DedupeGUID] when shows number
+	// their guids the same way. The podcast:guid of a feed without one is
+	// derived from its [MergeSource.URL].
+	DedupeFeedGUID
+	// DedupeEnclosureURL matches episodes with the same enclosure URL
+	DedupeEnclosureURL
+)
+
+// BackReference is how merged episodes point back to the feed they came from
+type BackReference int
+
+const (
+	// BackReferenceNone leaves the episodes as they are
+	BackReferenceNone BackReference = iota
+	// BackReferenceRemoteItem adds a podcast:remoteItem with the feed's
+	// podcast:guid and URL, and the episode's guid
+	BackReferenceRemoteItem
+	// BackReferenceSource sets the RSS source element to the feed's title and
+	// URL
+	BackReferenceSource
+)
+
+// MergeSource is a feed to merge, and the URL it's published at
+type MergeSource struct {
+	Feed *RSSPodcast
+	URL  string
+}
+
+// MergeCandidate is an episode from one of the feeds being merged
+type MergeCandidate struct {
+	Episode *Episode
+	Source  *MergeSource
+}
+
+// MergeOptions control how [Merge] combines feeds
+type MergeOptions struct {
+	// Dedupe lists the ways duplicate episodes are found. Episodes that match
+	// any of them are duplicates. Defaults to [DedupeFeedGUID] and
+	// [DedupeEnclosureURL].
+	Dedupe []DedupeKey
+
+	// Prefer reports if candidate should replace kept, a duplicate of it that
+	// was seen earlier. By default the episode from the first source wins.
+	Prefer func(candidate, kept MergeCandidate) bool
+
+	// BackReference is how episodes point back to their source feed
+	BackReference BackReference
+
+	// Limit is the most episodes to keep, the newest. Zero keeps them all.
+	Limit int
+}
+
+// PreferNewest is a [MergeOptions.Prefer] that keeps the duplicate with the
+// latest pubDate, such as a re-released episode
+func PreferNewest(candidate, kept MergeCandidate) bool {
+	candidateDate, err := candidate.Episode.PubDate.Time()
+	if err != nil {
+		return false
+	}
+	keptDate, err := kept.Episode.PubDate.Time()
+	return err != nil || candidateDate.After(keptDate)
+}
+
+// Merge combines the episodes of several feeds into a feed with the given
+// channel, such as a network's feed of all its shows. Duplicate episodes are
+// dropped, and the rest are sorted newest first, with episodes without a
+// valid pubDate last.
+//
+// Episodes keep their own podcast:person and podcast:value elements, and
+// those of the channel they came from are copied onto episodes without any,
+// so credits and payments still go to the right show. The feeds aren't
+// modified.
+func Merge(channel *Podcast, sources []MergeSource, options MergeOptions) *RSSPodcast {
+	keys := options.Dedupe
+	if len(keys) == 0 {
+		keys = []DedupeKey{DedupeFeedGUID, DedupeEnclosureURL}
+	}
+
+	var kept []MergeCandidate
+	index := map[string]int{}
+	for s := range sources {
+		source := &sources[s]
+		for e := range source.Feed.Channel.Items {
+			candidate := MergeCandidate{&source.Feed.Channel.Items[e], source}
+			candidateKeys := dedupeKeys(candidate, keys)
+
+			i, duplicate := -1, false
+			for _, key := range candidateKeys {
+				if i, duplicate = index[key]; duplicate {
+					break
+				}
+			}
+			switch {
+			case !duplicate:
+				i = len(kept)
+				kept = append(kept, candidate)
+			case options.Prefer != nil && options.Prefer(candidate, kept[i]):
+				kept[i] = candidate
+			}
+			for _, key := range candidateKeys {
+				if _, ok := index[key]; !ok {
+					index[key] = i
+				}
+			}
+		}
+	}
+
+	episodes := make([]Episode, len(kept))
+	for i, candidate := range kept {
+		episodes[i] = mergedEpisode(candidate, options.BackReference)
+	}
+	sortByPubDate(episodes, true)
+	if options.Limit > 0 && len(episodes) > options.Limit {
+		episodes = episodes[:options.Limit]
+	}
+
+	merged := &RSSPodcast{Version: rss.RSSVersion, Channel: *channel}
+	merged.Channel.Items = episodes
+	return merged
+}
+
+// dedupeKeys are the strings that identify the candidate for each of keys
+func dedupeKeys(candidate MergeCandidate, keys []DedupeKey) []string {
+	var values []string
+	guid := guidValue(candidate.Episode)
+	for _, key := range keys {
+		switch key {
+		case DedupeGUID:
+			if guid != "" {
+				values = append(values, "guid\x00"+guid)
+			}
+		case DedupeFeedGUID:
+			if feedGUID := sourceGUID(candidate.Source); guid != "" && feedGUID != "" {
+				values = append(values, "feedguid\x00"+feedGUID+"\x00"+guid)
+			}
+		case DedupeEnclosureURL:
+			if url := enclosureURL(candidate.Episode); url != "" {
+				values = append(values, "enclosure\x00"+url)
+			}
+		}
+	}
+	return values
+}
+
+// sourceGUID is the podcast:guid of the source, derived from its URL if it
+// doesn't have one
+func sourceGUID(source *MergeSource) string {
+	if guid := strings.TrimSpace(source.Feed.Channel.PodcastGUID); guid != "" {
+		return guid
+	}
+	if source.URL != "" {
+		return GUIDFromFeedURL(source.URL)
+	}
+	return ""
+}
+
+// mergedEpisode copies the candidate's episode for the merged feed, without
+// sharing any slices it changes with the source feed
+func mergedEpisode(candidate MergeCandidate, reference BackReference) Episode {
+	episode := *candidate.Episode
+	channel := &candidate.Source.Feed.Channel
+
+	if len(episode.PodcastPeople) == 0 && len(channel.PodcastPeople) > 0 {
+		episode.PodcastPeople = slices.Clone(channel.PodcastPeople)
+	}
+	if len(episode.PodcastValue) == 0 && len(channel.PodcastValue) > 0 {
+		episode.PodcastValue = slices.Clone(channel.PodcastValue)
+	}
+
+	switch reference {
+	case BackReferenceRemoteItem:
+		item := PodcastRemoteItem{
+			FeedGUID: sourceGUID(candidate.Source),
+			FeedURL:  candidate.Source.URL,
+			ItemGUID: guidValue(candidate.Episode),
+		}
+		if channel.PodcastMedium != "" && channel.PodcastMedium != MediumPodcast {
+			item.Medium = string(channel.PodcastMedium)
+		}
+		episode.PodcastRemoteItems = append(slices.Clip(episode.PodcastRemoteItems), item)
+	case BackReferenceSource:
+		episode.Source = &rss.Source{Value: channel.Title, URL: candidate.Source.URL}
+	}
+	return episode
+}
+
+// sortByPubDate sorts episodes by pubDate, newest or oldest first, with
+// episodes without a valid pubDate last in their original order
+func sortByPubDate(episodes []Episode, newestFirst bool) {
+	type entry struct {
+		episode Episode
+		unix    int64
+		valid   bool
+	}
+	entries := make([]entry, len(episodes))
+	for i := range episodes {
+		t, err := episodes[i].PubDate.Time()
+		entries[i] = entry{episodes[i], t.Unix(), err == nil}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].valid != entries[j].valid {
+			return entries[i].valid
+		}
+		if newestFirst {
+			return entries[i].unix > entries[j].unix
+		}
+		return entries[i].unix < entries[j].unix
+	})
+	for i := range entries {
+		episodes[i] = entries[i].episode
+	}
+}
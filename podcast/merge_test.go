@@ -0,0 +1,105 @@
+package podcast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func mergeFeed(guid string, people []PodcastPerson, episodes ...Episode) *RSSPodcast {
+	return &RSSPodcast{Channel: Podcast{
+		Channel:       rss.Channel{Title: "Show " + guid},
+		PodcastGUID:   guid,
+		PodcastPeople: people,
+		Items:         episodes,
+	}}
+}
+
+func mergeEpisode(guid, url, pubDate string) Episode {
+	return Episode{Item: rss.Item{
+		Title:     guid,
+		GUID:      &rss.GUID{Value: guid},
+		Enclosure: &rss.Enclosure{URL: url},
+		PubDate:   rss.RFC2822Date(pubDate),
+	}}
+}
+
+func TestMerge(t *testing.T) {
+	host := []PodcastPerson{{PersonName: "Jayden"}}
+	a := mergeFeed("a", host,
+		mergeEpisode("1", "https://example.com/a1.mp3", "Mon, 01 Jan 2024 00:00:00 +0000"),
+		mergeEpisode("2", "https://example.com/a2.mp3", "Wed, 03 Jan 2024 00:00:00 +0000"),
+	)
+	b := mergeFeed("b", nil,
+		// The same guid as a's first episode, but a different show
+		mergeEpisode("1", "https://example.com/b1.mp3", "Tue, 02 Jan 2024 00:00:00 +0000"),
+		// A re-upload of a's second episode
+		mergeEpisode("a2-rerun", "https://example.com/a2.mp3", "Thu, 04 Jan 2024 00:00:00 +0000"),
+		mergeEpisode("undated", "https://example.com/b3.mp3", ""),
+	)
+	sources := []MergeSource{{a, "https://example.com/a.xml"}, {b, "https://example.com/b.xml"}}
+
+	testCases := []struct {
+		name     string
+		options  MergeOptions
+		expected []string
+	}{
+		{"defaults", MergeOptions{}, []string{"2", "1", "1", "undated"}},
+		{"guid", MergeOptions{Dedupe: []DedupeKey{DedupeGUID}}, []string{"a2-rerun", "2", "1", "undated"}},
+		{"prefer newest", MergeOptions{Prefer: PreferNewest}, []string{"a2-rerun", "1", "1", "undated"}},
+		{"limit", MergeOptions{Limit: 2}, []string{"2", "1"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			merged := Merge(&Podcast{Channel: rss.Channel{Title: "Network"}}, sources, tc.options)
+			if merged.Channel.Title != "Network" {
+				t.Errorf("expected the given channel, got %q", merged.Channel.Title)
+			}
+			var guids []string
+			for _, episode := range merged.Channel.Items {
+				guids = append(guids, episode.GUID.Value)
+			}
+			if diff := cmp.Diff(tc.expected, guids); diff != "" {
+				t.Errorf("unexpected episodes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeBackReferences(t *testing.T) {
+	host := []PodcastPerson{{PersonName: "Jayden"}}
+	guest := []PodcastPerson{{PersonName: "Gopher", Role: "guest"}}
+	withGuest := mergeEpisode("2", "https://example.com/2.mp3", "Wed, 03 Jan 2024 00:00:00 +0000")
+	withGuest.PodcastPeople = guest
+	withRemoteItem := mergeEpisode("1", "https://example.com/1.mp3", "Mon, 01 Jan 2024 00:00:00 +0000")
+	withRemoteItem.PodcastRemoteItems = make([]PodcastRemoteItem, 1, 2)
+	feed := mergeFeed("", host, withGuest, withRemoteItem)
+	sources := []MergeSource{{feed, "https://example.com/feed.xml"}}
+
+	merged := Merge(&Podcast{}, sources, MergeOptions{BackReference: BackReferenceRemoteItem})
+	episodes := merged.Channel.Items
+	if diff := cmp.Diff(guest, episodes[0].PodcastPeople); diff != "" {
+		t.Errorf("expected the episode's own people (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(host, episodes[1].PodcastPeople); diff != "" {
+		t.Errorf("expected the channel's people (-want +got):\n%s", diff)
+	}
+	expected := PodcastRemoteItem{
+		FeedGUID: GUIDFromFeedURL("https://example.com/feed.xml"),
+		FeedURL:  "https://example.com/feed.xml",
+		ItemGUID: "1",
+	}
+	if diff := cmp.Diff([]PodcastRemoteItem{{}, expected}, episodes[1].PodcastRemoteItems); diff != "" {
+		t.Errorf("unexpected remote items (-want +got):\n%s", diff)
+	}
+	if len(feed.Channel.Items[1].PodcastRemoteItems) != 1 || feed.Channel.Items[1].PodcastRemoteItems[:2][1] != (PodcastRemoteItem{}) {
+		t.Error("expected the source feed to be left alone")
+	}
+
+	merged = Merge(&Podcast{}, sources, MergeOptions{BackReference: BackReferenceSource})
+	expectedSource := &rss.Source{Value: "Show ", URL: "https://example.com/feed.xml"}
+	if diff := cmp.Diff(expectedSource, merged.Channel.Items[0].Source); diff != "" {
+		t.Errorf("unexpected source (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,196 @@
+package podcast
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SeasonNumber is the episode's season, from podcast:season or else
+// itunes:season, or 0 if it doesn't have one
+func (e *Episode) SeasonNumber() int {
+	if e.PodcastSeason != nil {
+		return e.PodcastSeason.SeasonNumber
+	}
+	return e.ItunesSeason
+}
+
+// EpisodeNumber is the episode's number, from podcast:episode or else
+// itunes:episode, or 0 if it doesn't have one. Numbers from podcast:episode
+// can have a fraction, for episodes between two others.
+func (e *Episode) EpisodeNumber() float64 {
+	if e.PodcastEpisode != nil {
+		return float64(e.PodcastEpisode.EpisodeNumber)
+	}
+	return float64(e.ItunesEpisode)
+}
+
+// Season is a group of episodes from [Podcast.OrderedEpisodes]
+type Season struct {
+	// Number is the season number, or 0 for episodes without a season
+	Number int
+
+	// Name is the podcast:season name of the season's episodes, if they have
+	// one
+	Name string
+
+	Episodes []*Episode
+}
+
+// OrderedEpisodes groups the episodes by season, in the order apps should
+// list them for the show's itunes:type.
+//
+// Episodic shows list the newest season first, and the newest episodes
+// first within each season. Serial shows list the first season first, and
+// episodes by episode number, with those without a number after them by
+// pubDate. Episodes without a season are grouped in season 0, which comes
+// first for serial shows and last for episodic shows.
+//
+// Within a season, show and season trailers (trailers without an episode
+// number) come first. In serial shows, a trailer with an episode number comes
+// just before that episode, a bonus episode with an episode number just after
+// it, and bonus episodes without a number at the end of the season.
+func (p *Podcast) OrderedEpisodes() []Season {
+	serial := p.ItunesType == ItunesShowTypeSerial
+
+	var seasons []Season
+	index := map[int]int{}
+	for i := range p.Items {
+		episode := &p.Items[i]
+		number := episode.SeasonNumber()
+		s, ok := index[number]
+		if !ok {
+			s = len(seasons)
+			index[number] = s
+			seasons = append(seasons, Season{Number: number})
+		}
+		if seasons[s].Name == "" && episode.PodcastSeason != nil {
+			seasons[s].Name = episode.PodcastSeason.Name
+		}
+		seasons[s].Episodes = append(seasons[s].Episodes, episode)
+	}
+
+	sort.SliceStable(seasons, func(i, j int) bool {
+		a, b := seasons[i].Number, seasons[j].Number
+		switch {
+		case a == 0 || b == 0:
+			// Season 0 is first for serial shows, last for episodic ones
+			return (a == 0) == serial && a != b
+		case serial:
+			return a < b
+		}
+		return a > b
+	})
+	for _, season := range seasons {
+		sortSeason(season.Episodes, serial)
+	}
+	return seasons
+}
+
+// sortSeason sorts the episodes of one season
+func sortSeason(episodes []*Episode, serial bool) {
+	type key struct {
+		// group orders unnumbered trailers, then the rest, then (in serial
+		// shows) unnumbered episodes and unnumbered bonus episodes
+		group  int
+		number float64
+		// rank orders an episode's trailer, the episode and its bonus episodes
+		rank int
+		unix int64
+		// dated episodes come before undated ones
+		dated bool
+	}
+	keys := make(map[*Episode]key, len(episodes))
+	for _, episode := range episodes {
+		t, err := episode.PubDate.Time()
+		k := key{group: 1, number: episode.EpisodeNumber(), rank: 1, unix: t.Unix(), dated: err == nil}
+		switch episode.ItunesEpisodeType {
+		case TrailerEpisode:
+			k.rank = 0
+		case BonusEpisode:
+			k.rank = 2
+		}
+		switch {
+		case k.number == 0 && k.rank == 0:
+			k.group = 0
+		case !serial || k.number != 0:
+		case k.rank == 2:
+			k.group = 3
+		default:
+			k.group = 2
+		}
+		keys[episode] = k
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool {
+		a, b := keys[episodes[i]], keys[episodes[j]]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		if serial && a.group == 1 {
+			if a.number != b.number {
+				return a.number < b.number
+			}
+			if a.rank != b.rank {
+				return a.rank < b.rank
+			}
+		}
+		if a.dated != b.dated {
+			return a.dated
+		}
+		if serial {
+			return a.unix < b.unix
+		}
+		return a.unix > b.unix
+	})
+}
+
+// CheckNumbering looks for inconsistent season and episode numbers: itunes
+// and podcast namespace numbers that disagree, full episodes sharing a
+// number, and seasons with more than one name. [Validate] includes these
+// problems.
+func (p *Podcast) CheckNumbering() []Problem {
+	v := &validator{}
+	type numbered struct {
+		season int
+		number float64
+	}
+	full := map[numbered]int{}
+	names := map[int]string{}
+
+	for i := range p.Items {
+		episode := &p.Items[i]
+		ip := fmt.Sprintf("channel.item[%d]", i)
+
+		if season := episode.PodcastSeason; season != nil {
+			if episode.ItunesSeason != 0 && episode.ItunesSeason != season.SeasonNumber {
+				v.report(SeverityWarning, SpecPodcastIndex, ip+".podcast:season", "season %d doesn't match itunes:season %d", season.SeasonNumber, episode.ItunesSeason)
+			}
+			if season.Name != "" {
+				if name, ok := names[season.SeasonNumber]; ok && name != season.Name {
+					v.report(SeverityWarning, SpecPodcastIndex, ip+".podcast:season", "season %d is named %q, but also %q", season.SeasonNumber, season.Name, name)
+				} else {
+					names[season.SeasonNumber] = season.Name
+				}
+			}
+		}
+		if number := episode.PodcastEpisode; number != nil && episode.ItunesEpisode != 0 {
+			if whole := math.Floor(float64(number.EpisodeNumber)); whole != float64(episode.ItunesEpisode) {
+				v.report(SeverityWarning, SpecPodcastIndex, ip+".podcast:episode", "episode %v doesn't match itunes:episode %d", number.EpisodeNumber, episode.ItunesEpisode)
+			}
+		}
+
+		switch episode.ItunesEpisodeType {
+		case TrailerEpisode, BonusEpisode:
+			continue
+		}
+		if key := (numbered{episode.SeasonNumber(), episode.EpisodeNumber()}); key.number != 0 {
+			if first, ok := full[key]; ok {
+				v.report(SeverityWarning, SpecApple, ip+".itunes:episode", "season %d episode %v is also item[%d]", key.season, key.number, first)
+			} else {
+				full[key] = i
+			}
+		}
+	}
+	return v.problems
+}
@@ -0,0 +1,119 @@
+package podcast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// numbered is an episode titled title, published day days into 2024
+func numbered(title string, day, season, episode int, episodeType EpisodeType) Episode {
+	return Episode{
+		Item: rss.Item{
+			Title:   title,
+			PubDate: rss.NewRFC2822Date(time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)),
+		},
+		ItunesSeason:      season,
+		ItunesEpisode:     episode,
+		ItunesEpisodeType: episodeType,
+	}
+}
+
+type orderedSeason struct {
+	Number   int
+	Name     string
+	Episodes []string
+}
+
+func ordered(p *Podcast) []orderedSeason {
+	var seasons []orderedSeason
+	for _, season := range p.OrderedEpisodes() {
+		s := orderedSeason{Number: season.Number, Name: season.Name}
+		for _, episode := range season.Episodes {
+			s.Episodes = append(s.Episodes, episode.Title)
+		}
+		seasons = append(seasons, s)
+	}
+	return seasons
+}
+
+func TestOrderedEpisodesSerial(t *testing.T) {
+	named := numbered("S2E1", 10, 0, 1, FullEpisode)
+	named.PodcastSeason = &PodcastSeason{SeasonNumber: 2, Name: "The Sequel"}
+
+	p := &Podcast{
+		ItunesType: ItunesShowTypeSerial,
+		Items: []Episode{
+			numbered("S2E2", 11, 2, 2, FullEpisode),
+			named,
+			numbered("S2 trailer", 9, 2, 0, TrailerEpisode),
+			numbered("S1E2 bonus", 5, 1, 2, BonusEpisode),
+			numbered("S1E2", 4, 1, 2, FullEpisode),
+			numbered("S1E1", 2, 1, 1, FullEpisode),
+			numbered("S1E2 teaser", 3, 1, 2, TrailerEpisode),
+			numbered("S1 outtakes", 8, 1, 0, BonusEpisode),
+			numbered("S1 unnumbered", 6, 1, 0, FullEpisode),
+			numbered("Show trailer", 1, 0, 0, TrailerEpisode),
+		},
+	}
+
+	expected := []orderedSeason{
+		{Number: 0, Episodes: []string{"Show trailer"}},
+		{Number: 1, Episodes: []string{"S1E1", "S1E2 teaser", "S1E2", "S1E2 bonus", "S1 unnumbered", "S1 outtakes"}},
+		{Number: 2, Name: "The Sequel", Episodes: []string{"S2 trailer", "S2E1", "S2E2"}},
+	}
+	if diff := cmp.Diff(expected, ordered(p)); diff != "" {
+		t.Errorf("unexpected order (-want +got):\n%s", diff)
+	}
+}
+
+func TestOrderedEpisodesEpisodic(t *testing.T) {
+	p := &Podcast{
+		ItunesType: ItunesShowTypeEpisodic,
+		Items: []Episode{
+			numbered("Old", 1, 0, 0, FullEpisode),
+			numbered("S1 A", 2, 1, 0, FullEpisode),
+			numbered("S1 trailer", 1, 1, 0, TrailerEpisode),
+			numbered("S1 bonus", 4, 1, 0, BonusEpisode),
+			numbered("S1 B", 3, 1, 0, FullEpisode),
+			numbered("S2 A", 5, 2, 0, FullEpisode),
+		},
+	}
+
+	expected := []orderedSeason{
+		{Number: 2, Episodes: []string{"S2 A"}},
+		{Number: 1, Episodes: []string{"S1 trailer", "S1 bonus", "S1 B", "S1 A"}},
+		{Number: 0, Episodes: []string{"Old"}},
+	}
+	if diff := cmp.Diff(expected, ordered(p)); diff != "" {
+		t.Errorf("unexpected order (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckNumbering(t *testing.T) {
+	mismatched := numbered("Mismatched", 1, 1, 1, FullEpisode)
+	mismatched.PodcastSeason = &PodcastSeason{SeasonNumber: 2, Name: "Two"}
+	mismatched.PodcastEpisode = &PodcastEpisode{EpisodeNumber: 3}
+	between := numbered("Between", 2, 0, 3, FullEpisode)
+	between.PodcastSeason = &PodcastSeason{SeasonNumber: 2, Name: "Second"}
+	between.PodcastEpisode = &PodcastEpisode{EpisodeNumber: 3.5}
+
+	p := &Podcast{Items: []Episode{
+		mismatched,
+		between,
+		numbered("Duplicate", 3, 2, 3, FullEpisode),
+		numbered("Bonus", 4, 2, 3, BonusEpisode),
+	}}
+
+	expected := []Problem{
+		{SeverityWarning, SpecPodcastIndex, "channel.item[0].podcast:season", "season 2 doesn't match itunes:season 1"},
+		{SeverityWarning, SpecPodcastIndex, "channel.item[0].podcast:episode", "episode 3 doesn't match itunes:episode 1"},
+		{SeverityWarning, SpecPodcastIndex, "channel.item[1].podcast:season", `season 2 is named "Second", but also "Two"`},
+		{SeverityWarning, SpecApple, "channel.item[2].itunes:episode", "season 2 episode 3 is also item[0]"},
+	}
+	if diff := cmp.Diff(expected, p.CheckNumbering()); diff != "" {
+		t.Errorf("unexpected problems (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,299 @@
+package podcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// Page is one document of a feed split up by [Paginate]
+type Page struct {
+	URL  string
+	Feed *RSSPodcast
+}
+
+// PaginateOptions control how [Paginate] splits up a feed
+type PaginateOptions struct {
+	// FeedURL (required) is the URL of the feed apps subscribe to, the first
+	// page
+	FeedURL string
+
+	// HeadSize (required) is how many of the newest episodes the first page
+	// has
+	HeadSize int
+
+	// PageSize is how many episodes the other pages have. Defaults to
+	// HeadSize.
+	PageSize int
+
+	// PageURL returns the URL of page n of the other pages. Archive pages are
+	// numbered from 1 for the oldest, and paged feed pages from 1 for the page
+	// after the first. Defaults to FeedURL with a page query parameter.
+	PageURL func(n int) string
+
+	// Paged splits the feed into an RFC 5005 paged feed, whose pages are
+	// linked with next and previous links and change as episodes are added,
+	// instead of an archived feed.
+	Paged bool
+}
+
+// maxPages limits how many pages [FetchPages] follows
+const maxPages = 10000
+
+// Paginate splits a feed with many episodes, which some apps won't fetch all
+// of, into pages as [RFC 5005] describes.
+//
+// By default the result is an archived feed: the first page, at FeedURL, has
+// the newest episodes, and links to archive pages with prev-archive links.
+// Archive pages are only ever full, and the first page has up to PageSize-1
+// more than HeadSize episodes, so archive pages never change once published
+// and can be cached forever. With [PaginateOptions.Paged] the result is a
+// paged feed instead.
+//
+// Each page has the feed's channel, and its episodes are newest first, or
+// oldest first for serial shows. A feed that fits on the first page is marked
+// complete. The pages are returned first page first.
+//
+// [RFC 5005]: https://www.rfc-editor.org/rfc/rfc5005
+func Paginate(feed *RSSPodcast, options PaginateOptions) ([]Page, error) {
+	if options.FeedURL == "" {
+		return nil, errors.New("podcast: paginating needs the feed's URL")
+	}
+	if options.HeadSize <= 0 {
+		return nil, fmt.Errorf("podcast: invalid head size %d", options.HeadSize)
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = options.HeadSize
+	}
+	if options.PageURL == nil {
+		options.PageURL = func(n int) string {
+			return withPageQuery(options.FeedURL, n)
+		}
+	}
+
+	episodes := slices.Clone(feed.Channel.Items)
+	sortByPubDate(episodes, true)
+
+	if options.Paged {
+		return pagedFeed(feed, episodes, options), nil
+	}
+	return archivedFeed(feed, episodes, options), nil
+}
+
+// archivedFeed splits the episodes, newest first, into a feed and full
+// archive pages
+func archivedFeed(feed *RSSPodcast, episodes []Episode, options PaginateOptions) []Page {
+	archives := 0
+	if len(episodes) > options.HeadSize {
+		archives = (len(episodes) - options.HeadSize) / options.PageSize
+	}
+	headSize := len(episodes) - archives*options.PageSize
+
+	head := page(feed, episodes[:headSize], options.FeedURL)
+	if archives == 0 {
+		head.Feed.Channel.FeedHistoryComplete = &FeedHistoryMarker{}
+	} else {
+		head.link(RelPrevArchive, options.PageURL(archives))
+	}
+	pages := []Page{head}
+
+	// Archives are numbered from the oldest, but returned newest first
+	for n := archives; n > 0; n-- {
+		end := len(episodes) - (n-1)*options.PageSize
+		archive := page(feed, episodes[end-options.PageSize:end], options.PageURL(n))
+		archive.Feed.Channel.FeedHistoryArchive = &FeedHistoryMarker{}
+		archive.link(RelCurrent, options.FeedURL)
+		if n > 1 {
+			archive.link(RelPrevArchive, options.PageURL(n-1))
+		}
+		if n < archives {
+			archive.link(RelNextArchive, options.PageURL(n+1))
+		}
+		pages = append(pages, archive)
+	}
+	return pages
+}
+
+// pagedFeed splits the episodes, newest first, into pages from the newest
+func pagedFeed(feed *RSSPodcast, episodes []Episode, options PaginateOptions) []Page {
+	headSize := min(options.HeadSize, len(episodes))
+	urls := []string{options.FeedURL}
+	bounds := []int{0, headSize}
+	for start := headSize; start < len(episodes); start += options.PageSize {
+		urls = append(urls, options.PageURL(len(urls)))
+		bounds = append(bounds, min(start+options.PageSize, len(episodes)))
+	}
+
+	pages := make([]Page, len(urls))
+	for i, pageURL := range urls {
+		pages[i] = page(feed, episodes[bounds[i]:bounds[i+1]], pageURL)
+		if len(urls) == 1 {
+			pages[i].Feed.Channel.FeedHistoryComplete = &FeedHistoryMarker{}
+			break
+		}
+		pages[i].link(RelFirst, urls[0])
+		pages[i].link(RelLast, urls[len(urls)-1])
+		if i > 0 {
+			pages[i].link(RelPrevious, urls[i-1])
+		}
+		if i < len(urls)-1 {
+			pages[i].link(RelNext, urls[i+1])
+		}
+	}
+	return pages
+}
+
+// page makes a page at pageURL with the episodes, which are newest first
+func page(feed *RSSPodcast, episodes []Episode, pageURL string) Page {
+	paged := *feed
+	paged.Channel.FeedHistoryComplete = nil
+	paged.Channel.FeedHistoryArchive = nil
+	paged.Channel.Items = slices.Clone(episodes)
+	if paged.Channel.ItunesType == ItunesShowTypeSerial {
+		slices.Reverse(paged.Channel.Items)
+	}
+
+	paged.Channel.AtomLinks = nil
+	for _, link := range feed.Channel.AtomLinks {
+		if !isPageRel(link.Rel) {
+			paged.Channel.AtomLinks = append(paged.Channel.AtomLinks, link)
+		}
+	}
+	p := Page{URL: pageURL, Feed: &paged}
+	p.link(RelSelf, pageURL)
+	return p
+}
+
+func (p *Page) link(rel, href string) {
+	p.Feed.Channel.AtomLinks = append(p.Feed.Channel.AtomLinks, AtomLink{Href: href, Rel: rel, Type: "application/rss+xml"})
+}
+
+// isPageRel reports if rel is a link between the pages of a feed
+func isPageRel(rel string) bool {
+	switch rel {
+	case RelSelf, RelNext, RelPrevious, RelFirst, RelLast, RelCurrent, RelPrevArchive, RelNextArchive:
+		return true
+	}
+	return false
+}
+
+func withPageQuery(feedURL string, n int) string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return feedURL + "?page=" + strconv.Itoa(n)
+	}
+	query := u.Query()
+	query.Set("page", strconv.Itoa(n))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// FetchPages downloads a feed that was split into pages, following the
+// prev-archive links of an archived feed and the next links of a paged feed,
+// and puts the feed back together: the first page's channel with the
+// episodes of every page. An episode on more than one page, as happens when
+// a paged feed changes while it's fetched, is taken from the newest page.
+// Episodes are sorted newest first, or oldest first for serial shows.
+//
+// A nil client uses [http.DefaultClient].
+func FetchPages(ctx context.Context, client *http.Client, feedURL string) (*RSSPodcast, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var head *RSSPodcast
+	var episodes []Episode
+	seen := map[string]bool{}
+	visited := map[string]bool{}
+	for next := feedURL; next != ""; {
+		if len(visited) == maxPages {
+			return nil, fmt.Errorf("podcast: %s has more than %d pages", feedURL, maxPages)
+		}
+		visited[next] = true
+
+		feed, err := fetchPage(ctx, client, next)
+		if err != nil {
+			return nil, err
+		}
+		if head == nil {
+			head = feed
+		}
+		for _, episode := range feed.Channel.Items {
+			key := guidValue(&episode)
+			if key == "" {
+				key = enclosureURL(&episode)
+			}
+			if key != "" && seen[key] {
+				continue
+			}
+			seen[key] = true
+			episodes = append(episodes, episode)
+		}
+
+		pageURL := next
+		next = ""
+		for _, rel := range []string{RelPrevArchive, RelNext} {
+			href := feed.Channel.AtomLinkHref(rel)
+			if href == "" {
+				continue
+			}
+			resolved, err := resolveURL(pageURL, href)
+			if err != nil {
+				return nil, fmt.Errorf("podcast: %s: invalid %s link: %w", pageURL, rel, err)
+			}
+			if !visited[resolved] {
+				next = resolved
+				break
+			}
+		}
+	}
+
+	feed := *head
+	feed.Channel.FeedHistoryArchive = nil
+	feed.Channel.AtomLinks = nil
+	for _, link := range head.Channel.AtomLinks {
+		if link.Rel == RelSelf || !isPageRel(link.Rel) {
+			feed.Channel.AtomLinks = append(feed.Channel.AtomLinks, link)
+		}
+	}
+	sortByPubDate(episodes, feed.Channel.ItunesType != ItunesShowTypeSerial)
+	feed.Channel.Items = episodes
+	return &feed, nil
+}
+
+func fetchPage(ctx context.Context, client *http.Client, pageURL string) (*RSSPodcast, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("podcast: fetching %s: %s", pageURL, response.Status)
+	}
+
+	feed, err := Decode(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("podcast: %s: %w", pageURL, err)
+	}
+	return feed, nil
+}
+
+func resolveURL(base, href string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(ref).String(), nil
+}
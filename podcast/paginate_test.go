@@ -0,0 +1,202 @@
+package podcast
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// numberedFeed has count episodes, numbered from 1 for the oldest, published
+// a day apart
+func numberedFeed(count int) *RSSPodcast {
+	feed := &RSSPodcast{Version: rss.RSSVersion, Channel: Podcast{Channel: rss.Channel{Title: "Daily"}}}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= count; i++ {
+		feed.Channel.Items = append(feed.Channel.Items, Episode{Item: rss.Item{
+			Title:   fmt.Sprint(i),
+			GUID:    &rss.GUID{Value: fmt.Sprint(i)},
+			PubDate: rss.NewRFC2822Date(start.AddDate(0, 0, i)),
+		}})
+	}
+	return feed
+}
+
+func titles(feed *RSSPodcast) []string {
+	var values []string
+	for _, episode := range feed.Channel.Items {
+		values = append(values, episode.Title)
+	}
+	return values
+}
+
+func TestPaginateArchived(t *testing.T) {
+	pages, err := Paginate(numberedFeed(25), PaginateOptions{FeedURL: "https://example.com/feed.xml", HeadSize: 5, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		url     string
+		titles  []string
+		links   []AtomLink
+		archive bool
+	}{
+		{
+			url:    "https://example.com/feed.xml",
+			titles: []string{"25", "24", "23", "22", "21"},
+			links: []AtomLink{
+				{Href: "https://example.com/feed.xml", Rel: RelSelf, Type: "application/rss+xml"},
+				{Href: "https://example.com/feed.xml?page=2", Rel: RelPrevArchive, Type: "application/rss+xml"},
+			},
+		},
+		{
+			url:    "https://example.com/feed.xml?page=2",
+			titles: []string{"20", "19", "18", "17", "16", "15", "14", "13", "12", "11"},
+			links: []AtomLink{
+				{Href: "https://example.com/feed.xml?page=2", Rel: RelSelf, Type: "application/rss+xml"},
+				{Href: "https://example.com/feed.xml", Rel: RelCurrent, Type: "application/rss+xml"},
+				{Href: "https://example.com/feed.xml?page=1", Rel: RelPrevArchive, Type: "application/rss+xml"},
+			},
+			archive: true,
+		},
+		{
+			url:    "https://example.com/feed.xml?page=1",
+			titles: []string{"10", "9", "8", "7", "6", "5", "4", "3", "2", "1"},
+			links: []AtomLink{
+				{Href: "https://example.com/feed.xml?page=1", Rel: RelSelf, Type: "application/rss+xml"},
+				{Href: "https://example.com/feed.xml", Rel: RelCurrent, Type: "application/rss+xml"},
+				{Href: "https://example.com/feed.xml?page=2", Rel: RelNextArchive, Type: "application/rss+xml"},
+			},
+			archive: true,
+		},
+	}
+	if len(pages) != len(expected) {
+		t.Fatalf("expected %d pages, got %d", len(expected), len(pages))
+	}
+	for i, page := range pages {
+		want := expected[i]
+		if page.URL != want.url {
+			t.Errorf("page %d: expected %s, got %s", i, want.url, page.URL)
+		}
+		if diff := cmp.Diff(want.titles, titles(page.Feed)); diff != "" {
+			t.Errorf("page %d: unexpected episodes (-want +got):\n%s", i, diff)
+		}
+		if diff := cmp.Diff(want.links, page.Feed.Channel.AtomLinks); diff != "" {
+			t.Errorf("page %d: unexpected links (-want +got):\n%s", i, diff)
+		}
+		if (page.Feed.Channel.FeedHistoryArchive != nil) != want.archive {
+			t.Errorf("page %d: expected archive %v", i, want.archive)
+		}
+	}
+
+	// Another episode only changes the first page
+	more, err := Paginate(numberedFeed(26), PaginateOptions{FeedURL: "https://example.com/feed.xml", HeadSize: 5, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(pages[1:], more[1:]); diff != "" {
+		t.Errorf("expected the archive pages to stay the same (-want +got):\n%s", diff)
+	}
+}
+
+func TestPaginateComplete(t *testing.T) {
+	for _, paged := range []bool{false, true} {
+		pages, err := Paginate(numberedFeed(3), PaginateOptions{FeedURL: "https://example.com/feed.xml", HeadSize: 5, Paged: paged})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pages) != 1 || pages[0].Feed.Channel.FeedHistoryComplete == nil {
+			t.Errorf("paged %v: expected one complete page, got %+v", paged, pages)
+		}
+	}
+}
+
+func TestPaginatePaged(t *testing.T) {
+	feed := numberedFeed(7)
+	feed.Channel.ItunesType = ItunesShowTypeSerial
+	pages, err := Paginate(feed, PaginateOptions{
+		FeedURL:  "https://example.com/feed.xml",
+		HeadSize: 3,
+		PageURL:  func(n int) string { return fmt.Sprintf("https://example.com/feed-%d.xml", n) },
+		Paged:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{{"5", "6", "7"}, {"2", "3", "4"}, {"1"}}
+	if len(pages) != len(expected) {
+		t.Fatalf("expected %d pages, got %d", len(expected), len(pages))
+	}
+	for i, page := range pages {
+		if diff := cmp.Diff(expected[i], titles(page.Feed)); diff != "" {
+			t.Errorf("page %d: unexpected episodes (-want +got):\n%s", i, diff)
+		}
+	}
+	if next := pages[1].Feed.Channel.AtomLinkHref(RelNext); next != "https://example.com/feed-2.xml" {
+		t.Errorf("unexpected next link %q", next)
+	}
+	if last := pages[0].Feed.Channel.AtomLinkHref(RelLast); last != "https://example.com/feed-2.xml" {
+		t.Errorf("unexpected last link %q", last)
+	}
+}
+
+func TestFetchPages(t *testing.T) {
+	for _, paged := range []bool{false, true} {
+		t.Run(fmt.Sprintf("paged %v", paged), func(t *testing.T) {
+			documents := map[string][]byte{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				document, ok := documents[r.URL.String()]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write(document)
+			}))
+			defer server.Close()
+
+			feed := numberedFeed(23)
+			pages, err := Paginate(feed, PaginateOptions{
+				FeedURL:  server.URL + "/feed.xml",
+				HeadSize: 4,
+				PageSize: 6,
+				// Relative links are resolved against the page
+				PageURL: func(n int) string { return fmt.Sprintf("/archive/%d.xml", n) },
+				Paged:   paged,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, page := range pages {
+				var encoded bytes.Buffer
+				if err := Encode(&encoded, page.Feed); err != nil {
+					t.Fatal(err)
+				}
+				path := page.URL
+				if i == 0 {
+					path = "/feed.xml"
+				}
+				documents[path] = encoded.Bytes()
+			}
+
+			fetched, err := FetchPages(context.Background(), server.Client(), server.URL+"/feed.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sortByPubDate(feed.Channel.Items, true)
+			if diff := cmp.Diff(titles(feed), titles(fetched)); diff != "" {
+				t.Errorf("unexpected episodes (-want +got):\n%s", diff)
+			}
+			if fetched.Channel.AtomLinkHref(RelPrevArchive) != "" || fetched.Channel.AtomLinkHref(RelNext) != "" {
+				t.Errorf("expected the page links to be removed, got %+v", fetched.Channel.AtomLinks)
+			}
+		})
+	}
+}
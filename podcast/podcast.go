@@ -223,6 +223,87 @@ type Podcast struct {
 	// [Learn more about how to claim your show]: https://podcasters.apple.com/support/5497-claim-your-show
 	ItunesApplePodcastVerify string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd applepodcastsverify,omitempty"`
 
+	// # Google Podcasts:
+	//
+	// GoogleplayAuthor (optional) is the name of the content creator or
+	// podcast network.
+	//
+	// Example:
+	//  <googleplay:author>Dafna</googleplay:author>
+	GoogleplayAuthor string `xml:"http://www.google.com/schemas/play-podcasts/1.0 author,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayEmail (optional) is the email address of the podcast owner. It
+	// is used to verify ownership of the podcast.
+	//
+	// Example:
+	//  <googleplay:email>dafna@example.com</googleplay:email>
+	GoogleplayEmail string `xml:"http://www.google.com/schemas/play-podcasts/1.0 email,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayDescription (optional) is a description of the podcast. If it
+	// is not present, the channel <description> is used instead.
+	//
+	// Maximum length is 4000 characters.
+	GoogleplayDescription string `xml:"http://www.google.com/schemas/play-podcasts/1.0 description,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayImage (optional) is the artwork for the show. If it is not
+	// present, <itunes:image> and then the RSS <image> is used instead.
+	//
+	// The image must be square, with a minimum size of 1200 x 1200 pixels.
+	GoogleplayImage *GoogleplayImage `xml:"http://www.google.com/schemas/play-podcasts/1.0 image,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayCategory (optional) is the category of the podcast. Google
+	// Podcasts uses the same list of categories as Apple Podcasts, see
+	// [Podcast.ItunesCategory].
+	//
+	// Example:
+	//  <googleplay:category text="Technology"/>
+	GoogleplayCategory []GoogleplayCategory `xml:"http://www.google.com/schemas/play-podcasts/1.0 category,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayExplicit (optional) is whether or not the podcast contains
+	// explicit content. It can be one of yes, no or clean.
+	GoogleplayExplicit GoogleplayExplicit `xml:"http://www.google.com/schemas/play-podcasts/1.0 explicit,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayBlock (optional) prevents the entire podcast from appearing in
+	// Google Podcasts.
+	//
+	// Specifying the <googleplay:block> tag with a yes value blocks the
+	// podcast. Any other value has no effect.
+	GoogleplayBlock YesOrNo `xml:"http://www.google.com/schemas/play-podcasts/1.0 block,omitempty"`
+
+	// # Spotify:
+	//
+	// SpotifyLimit (optional) limits the number of episodes that appear in the
+	// Spotify client. Use this for shows where only the latest episodes are
+	// relevant, such as daily news.
+	//
+	// Example:
+	//  <spotify:limit recentCount="1"/>
+	SpotifyLimit *SpotifyLimit `xml:"http://www.spotify.com/ns/rss limit,omitempty"`
+
+	// # Spotify:
+	//
+	// SpotifyCountryOfOrigin (optional) is the intended market or territory of
+	// the podcast, as a space separated list of [ISO 3166] country codes.
+	// It is used for recommendations and does not restrict availability.
+	//
+	// Example:
+	//  <spotify:countryOfOrigin>us gb</spotify:countryOfOrigin>
+	//
+	// [ISO 3166]: https://www.iso.org/iso-3166-country-codes.html
+	SpotifyCountryOfOrigin string `xml:"http://www.spotify.com/ns/rss countryOfOrigin,omitempty"`
+
 	// # Apple Podcasts:
 	//
 	// PodcastTxt (situational) is an alternate method to verify your show
@@ -275,6 +356,47 @@ type Podcast struct {
 	// PodcastLicense indicates the show's license
 	PodcastLicense *PodcastLicense `xml:"https://podcastindex.org/namespace/1.0 license"`
 
+	// PodcastImages allows multiple image resources for the podcast
+	PodcastImages *PodcastImages `xml:"https://podcastindex.org/namespace/1.0 images,omitempty"`
+
+	// PodcastUpdateFrequency documents the intended release schedule
+	PodcastUpdateFrequency *PodcastUpdateFrequency `xml:"https://podcastindex.org/namespace/1.0 updateFrequency,omitempty"`
+
+	// PodcastPodping indicates if the podcast uses Podping
+	PodcastPodping *PodcastPodping `xml:"https://podcastindex.org/namespace/1.0 podping,omitempty"`
+
+	// PodcastPublisher links the podcast to the feed of its publisher
+	PodcastPublisher *PodcastPublisher `xml:"https://podcastindex.org/namespace/1.0 publisher,omitempty"`
+
+	// PodcastChat provides a chat room for the podcast
+	PodcastChat *PodcastChat `xml:"https://podcastindex.org/namespace/1.0 chat,omitempty"`
+
+	// PodcastRemoteItems point to other feeds or items, such as the tracks of a
+	// music playlist
+	PodcastRemoteItems []PodcastRemoteItem `xml:"https://podcastindex.org/namespace/1.0 remoteItem,omitempty"`
+
+	// MediaThumbnails are representative images for the show. See [MediaThumbnail]
+	MediaThumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail,omitempty"`
+
+	// MediaCredits credit the people and companies behind the show. See [MediaCredit]
+	MediaCredits []MediaCredit `xml:"http://search.yahoo.com/mrss/ credit,omitempty"`
+
+	// AtomLinks link the feed to itself and to related documents, such as the
+	// pages of a feed split up by [Paginate]. See [AtomLink]
+	AtomLinks []AtomLink `xml:"http://www.w3.org/2005/Atom link,omitempty"`
+
+	// FeedHistoryComplete marks a feed that has all of the show's episodes,
+	// so apps can forget episodes that are no longer in it. See [RFC 5005].
+	//
+	// [RFC 5005]: https://www.rfc-editor.org/rfc/rfc5005#section-2
+	FeedHistoryComplete *FeedHistoryMarker `xml:"http://purl.org/syndication/history/1.0 complete,omitempty"`
+
+	// FeedHistoryArchive marks an archive page of a feed, whose episodes
+	// won't change. See [RFC 5005].
+	//
+	// [RFC 5005]: https://www.rfc-editor.org/rfc/rfc5005#section-4
+	FeedHistoryArchive *FeedHistoryMarker `xml:"http://purl.org/syndication/history/1.0 archive,omitempty"`
+
 	Items []Episode `xml:"https://www.rssboard.org/rss-specification item"`
 }
 
@@ -425,6 +547,33 @@ type Episode struct {
 	// Specifying any value other than Yes has no effect.
 	ItunesBlock ItunesYesType `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd block,omitempty"`
 
+	// # Google Podcasts:
+	//
+	// GoogleplayAuthor (optional) is the name of the content creator of the
+	// episode, if different from the show's.
+	GoogleplayAuthor string `xml:"http://www.google.com/schemas/play-podcasts/1.0 author,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayDescription (optional) is a description of the episode. If it
+	// is not present, the item <description> is used instead.
+	GoogleplayDescription string `xml:"http://www.google.com/schemas/play-podcasts/1.0 description,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayExplicit (optional) is whether or not the episode contains
+	// explicit content. It can be one of yes, no or clean.
+	GoogleplayExplicit GoogleplayExplicit `xml:"http://www.google.com/schemas/play-podcasts/1.0 explicit,omitempty"`
+
+	// # Google Podcasts:
+	//
+	// GoogleplayBlock (optional) prevents the episode from appearing in Google
+	// Podcasts.
+	//
+	// Specifying the <googleplay:block> tag with a yes value blocks the
+	// episode. Any other value has no effect.
+	GoogleplayBlock YesOrNo `xml:"http://www.google.com/schemas/play-podcasts/1.0 block,omitempty"`
+
 	// # Apple Podcasts:
 	//
 	// A link to the episode transcript in the Closed Caption format. You should
@@ -449,6 +598,11 @@ type Episode struct {
 	// PodcastChapters provides independently editable, enhanced chapters
 	PodcastChapters *PodcastChapters `xml:"https://podcastindex.org/namespace/1.0 chapters,omitempty"`
 
+	// PscChapters are chapters embedded in the feed with Podlove Simple
+	// Chapters. See [PscChapters.JSONChapters] to treat them like
+	// PodcastChapters
+	PscChapters *PscChapters `xml:"http://podlove.org/simple-chapters chapters,omitempty"`
+
 	// PodcastSoundbite specifies suggested clips for sharing and promotion
 	PodcastSoundbite []PodcastSoundbite `xml:"https://podcastindex.org/namespace/1.0 soundbite,omitempty"`
 
@@ -485,6 +639,47 @@ type Episode struct {
 
 	// PodcastPodping indicates if the podcast uses Podping
 	PodcastPodping *PodcastPodping `xml:"https://podcastindex.org/namespace/1.0 podping,omitempty"`
+
+	// PodcastTxt holds free-form text, such as verification codes, for the
+	// episode
+	PodcastTxt []PodcastTxt `xml:"https://podcastindex.org/namespace/1.0 txt,omitempty"`
+
+	// PodcastLocation tells what the episode is about
+	PodcastLocation *PodcastLocation `xml:"https://podcastindex.org/namespace/1.0 location,omitempty"`
+
+	// PodcastChat provides a chat room for the episode, most often used with
+	// [PodcastLiveItem]
+	PodcastChat *PodcastChat `xml:"https://podcastindex.org/namespace/1.0 chat,omitempty"`
+
+	// PodcastRemoteItems point to items in other feeds, such as the song being
+	// played in a music show
+	PodcastRemoteItems []PodcastRemoteItem `xml:"https://podcastindex.org/namespace/1.0 remoteItem,omitempty"`
+
+	// MediaContents are the Media RSS renditions of the episode that aren't
+	// part of a group. See [MediaContent] and [Episode.MediaAlternateEnclosures]
+	MediaContents []MediaContent `xml:"http://search.yahoo.com/mrss/ content,omitempty"`
+
+	// MediaGroups group together different representations of the same
+	// content. See [MediaGroup]
+	MediaGroups []MediaGroup `xml:"http://search.yahoo.com/mrss/ group,omitempty"`
+
+	// MediaTitle is the title of the episode's media. See [MediaTitle]
+	MediaTitle *MediaTitle `xml:"http://search.yahoo.com/mrss/ title,omitempty"`
+
+	// MediaDescription is a short description of the episode's media. See
+	// [MediaDescription]
+	MediaDescription *MediaDescription `xml:"http://search.yahoo.com/mrss/ description,omitempty"`
+
+	// MediaThumbnails are representative images for the episode. See
+	// [MediaThumbnail]
+	MediaThumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail,omitempty"`
+
+	// MediaRatings declare the permissible audience. See [MediaRating]
+	MediaRatings []MediaRating `xml:"http://search.yahoo.com/mrss/ rating,omitempty"`
+
+	// MediaCredits credit the people and companies behind the episode. See
+	// [MediaCredit]
+	MediaCredits []MediaCredit `xml:"http://search.yahoo.com/mrss/ credit,omitempty"`
 }
 
 // ItunesYes is meant to be used with various properties that either accept a
@@ -673,6 +868,49 @@ type PodcastRemoteItem struct {
 	Medium string `xml:"medium,attr,omitempty"`
 }
 
+// PodcastPublisher allows a podcast feed to link to its "publisher feed"
+// parent. A publisher feed is a feed with a <podcast:medium> of "publisher"
+// that lists all of the podcasts made by that publisher.
+//
+// See [spec]
+//
+// [spec]: https://podcastindex.org/namespace/1.0#publisher
+type PodcastPublisher struct {
+	XMLName xml.Name `xml:"https://podcastindex.org/namespace/1.0 publisher"`
+
+	// RemoteItem (required) points to the publisher feed. Its medium should
+	// be "publisher".
+	RemoteItem PodcastRemoteItem `xml:"https://podcastindex.org/namespace/1.0 remoteItem"`
+}
+
+// PodcastChat allows a podcaster to attach information about either a
+// permanent or ad-hoc chat room to their podcast or to an episode.
+//
+// See [spec]
+//
+// [spec]: https://podcastindex.org/namespace/1.0#chat
+type PodcastChat struct {
+	XMLName xml.Name `xml:"https://podcastindex.org/namespace/1.0 chat"`
+
+	// Server (required) is the fqdn of a chat server that serves as the
+	// "authority" for the chat room, such as irc.zeronode.net
+	Server string `xml:"server,attr"`
+
+	// Protocol (required) is the [protocol] in use on the server, such as irc,
+	// xmpp, nostr or matrix.
+	//
+	// [protocol]: https://github.com/Podcastindex-org/podcast-namespace/blob/main/chatprotocols.txt
+	Protocol string `xml:"protocol,attr"`
+
+	// AccountID (recommended) is the account id of the podcaster on the
+	// server or platform being pointed to.
+	AccountID string `xml:"accountId,attr,omitempty"`
+
+	// Space (optional) is the "space" within the server, such as a channel or
+	// room name like #podcastindex
+	Space string `xml:"space,attr,omitempty"`
+}
+
 // PodcastLocked requires permission to migrate a feed
 //
 // See [spec]
@@ -958,7 +1196,7 @@ type PodcastAlternateEnclosure struct {
 	Type string `xml:"type,attr"`
 
 	// Length (recommended): Length of the file in bytes.
-	Length string `xml:"length,attr,omitempty"`
+	Length int `xml:"length,attr,omitempty"`
 
 	// Bitrate (optional): Average encoding bitrate of the media asset,
 	// expressed in bits per second.
@@ -975,7 +1213,7 @@ type PodcastAlternateEnclosure struct {
 	// language of this media.
 	//
 	// [IETF language tag (BCP 47) code]: https://en.wikipedia.org/wiki/BCP_47
-	Lang int `xml:"lang,attr,omitempty"`
+	Lang string `xml:"lang,attr,omitempty"`
 
 	// Rel (optional): Provides a method of offering and/or grouping together
 	// different media elements. If not set, or set to "default", the media will
@@ -983,12 +1221,12 @@ type PodcastAlternateEnclosure struct {
 	// enclosure's encoding/transport. This attribute can and should be the same
 	// for items with the same content encoded by different means. Should be
 	// limited to 32 characters for UX.
-	Rel int `xml:"rel,attr,omitempty"`
+	Rel string `xml:"rel,attr,omitempty"`
 
 	// Codecs (optional): An [RFC 6381] string specifying the codecs available in this media.
 	//
 	// [RFC 6381]: https://tools.ietf.org/html/rfc6381
-	Codecs int `xml:"codecs,attr,omitempty"`
+	Codecs string `xml:"codecs,attr,omitempty"`
 
 	// default: Boolean specifying whether or not the given media is the same as
 	// the file from the enclosure element and should be the preferred media
@@ -1178,7 +1416,8 @@ type PodcastLiveItem struct {
 	XMLName xml.Name `xml:"https://podcastindex.org/namespace/1.0 liveItem"`
 
 	// Status (required): A string that must be one of pending, live or ended.
-	Status string `xml:"status,attr"`
+	// See [PodcastLiveItem.Transition].
+	Status PodcastLiveStreamStatus `xml:"status,attr"`
 
 	// Start (required): A string representing an ISO8601 timestamp that denotes
 	//  the time when the stream is intended to start.
@@ -1205,6 +1444,8 @@ const (
 	StatusEnded   PodcastLiveStreamStatus = "ended"
 )
 
+// ISO8601Timestamp is a date and time, such as 2021-09-26T07:30:00.000-06:00.
+// See [NewISO8601Timestamp] and [ISO8601Timestamp.Time].
 type ISO8601Timestamp string
 
 // PodcastContentLink is used to indicate that the content begin delivered by
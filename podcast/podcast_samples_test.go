@@ -761,7 +761,7 @@ var MoreComplexSampleExpected = RSSPodcast{
 							Local: "source",
 						},
 						Value: "https://stuff.com",
-						URL:   "",
+						URL:   "has_url",
 					},
 				},
 				ItunesDuration: "234567",
@@ -1066,13 +1066,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 								Local: "alternateEnclosure",
 							},
 							Type:             "audio/mpeg",
-							Length:           "312",
+							Length:           312,
 							Bitrate:          0.0,
 							Title:            "",
 							Height:           0,
-							Lang:             0,
-							Rel:              0,
-							Codecs:           0,
+							Lang:             "",
+							Rel:              "",
+							Codecs:           "",
 							Default:          &t,
 							PodcastIntegrity: nil,
 							Source: []PodcastSource{
@@ -1298,13 +1298,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/mpeg",
-						Length:           "43200000",
+						Length:           43200000,
 						Bitrate:          128000.0,
 						Title:            "Standard",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          &t,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -1332,13 +1332,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/opus",
-						Length:           "32400000",
+						Length:           32400000,
 						Bitrate:          96000.0,
 						Title:            "High quality",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -1366,13 +1366,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/aac",
-						Length:           "54000000",
+						Length:           54000000,
 						Bitrate:          160000.0,
 						Title:            "High quality AAC",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -1400,13 +1400,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/opus",
-						Length:           "5400000",
+						Length:           5400000,
 						Bitrate:          16000.0,
 						Title:            "Low bandwidth",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -1434,13 +1434,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:    "video/mp4",
-						Length:  "7924786",
+						Length:  7924786,
 						Bitrate: 511276.53,
 						Title:   "Video version",
 						Height:  720,
-						Lang:    0,
-						Rel:     0,
-						Codecs:  0,
+						Lang:    "",
+						Rel:     "",
+						Codecs:  "",
 						Default: nil,
 						PodcastIntegrity: &PodcastIntegrity{
 							XMLName: xml.Name{
@@ -1699,13 +1699,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/mpeg",
-						Length:           "43200000",
+						Length:           43200000,
 						Bitrate:          128000.0,
 						Title:            "Standard",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          &t,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -1733,13 +1733,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/opus",
-						Length:           "32400000",
+						Length:           32400000,
 						Bitrate:          96000.0,
 						Title:            "High quality",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -1767,13 +1767,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/aac",
-						Length:           "54000000",
+						Length:           54000000,
 						Bitrate:          160000.0,
 						Title:            "High quality AAC",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -1801,13 +1801,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/opus",
-						Length:           "5400000",
+						Length:           5400000,
 						Bitrate:          16000.0,
 						Title:            "Low bandwidth",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -2036,13 +2036,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/mpeg",
-						Length:           "43203200",
+						Length:           43203200,
 						Bitrate:          128000.0,
 						Title:            "Standard",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          &t,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -2070,13 +2070,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/opus",
-						Length:           "32406000",
+						Length:           32406000,
 						Bitrate:          96000.0,
 						Title:            "High quality",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -2104,13 +2104,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/aac",
-						Length:           "5400300",
+						Length:           5400300,
 						Bitrate:          160000.0,
 						Title:            "High quality AAC",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -2138,13 +2138,13 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 							Local: "alternateEnclosure",
 						},
 						Type:             "audio/opus",
-						Length:           "5042000",
+						Length:           5042000,
 						Bitrate:          16000.0,
 						Title:            "Low bandwidth",
 						Height:           0,
-						Lang:             0,
-						Rel:              0,
-						Codecs:           0,
+						Lang:             "",
+						Rel:              "",
+						Codecs:           "",
 						Default:          nil,
 						PodcastIntegrity: nil,
 						Source: []PodcastSource{
@@ -2183,3 +2183,258 @@ var Podcasting20ExampleExpected RSSPodcast = RSSPodcast{
 	},
 	Version: "2.0",
 }
+
+//go:embed samples/platforms.rss
+var PlatformsSample []byte
+
+var PlatformsSampleExpected = RSSPodcast{
+	XMLName: xml.Name{
+		Space: "https://www.rssboard.org/rss-specification",
+		Local: "rss",
+	},
+	Channel: Podcast{
+		Channel: rss.Channel{
+			Title: "Dafna's Zebra Podcast",
+			Link:  "https://www.example.com/podcasts/dafnas-zebras/",
+			Description: rss.Description{
+				XMLName: xml.Name{
+					Space: "https://www.rssboard.org/rss-specification",
+					Local: "description",
+				},
+				Value: "A pet-owner's guide to the popular striped equine.",
+			},
+		},
+		ItunesImage: ItunesImageTag{
+			XMLName: xml.Name{
+				Space: "http://www.itunes.com/dtds/podcast-1.0.dtd",
+				Local: "image",
+			},
+			Href: "https://www.example.com/podcasts/dafnas-zebras/img/dafna-zebra-pod-logo.jpg",
+		},
+		GoogleplayAuthor:      "Dafna",
+		GoogleplayEmail:       "dafna@example.com",
+		GoogleplayDescription: "A pet-owner's guide to the popular striped equine.",
+		GoogleplayImage: &GoogleplayImage{
+			XMLName: xml.Name{
+				Space: "http://www.google.com/schemas/play-podcasts/1.0",
+				Local: "image",
+			},
+			Href: "https://www.example.com/podcasts/dafnas-zebras/img/dafna-zebra-pod-logo.jpg",
+		},
+		GoogleplayCategory: []GoogleplayCategory{
+			GoogleplayCategory{
+				XMLName: xml.Name{
+					Space: "http://www.google.com/schemas/play-podcasts/1.0",
+					Local: "category",
+				},
+				Text: "Kids & Family",
+			},
+		},
+		GoogleplayExplicit: GoogleplayExplicitNo,
+		GoogleplayBlock:    Yes,
+		SpotifyLimit: &SpotifyLimit{
+			XMLName: xml.Name{
+				Space: "http://www.spotify.com/ns/rss",
+				Local: "limit",
+			},
+			RecentCount: 5,
+		},
+		SpotifyCountryOfOrigin: "us ca",
+		Items: []Episode{
+			Episode{
+				Item: rss.Item{
+					Title: "Top 10 myths about caring for a zebra",
+					Enclosure: &rss.Enclosure{
+						XMLName: xml.Name{
+							Space: "https://www.rssboard.org/rss-specification",
+							Local: "enclosure",
+						},
+						URL:    "https://www.example.com/podcasts/dafnas-zebras/audio/toptenmyths.mp3",
+						Length: 34216300,
+						Type:   "audio/mpeg",
+					},
+					GUID: &rss.GUID{
+						XMLName: xml.Name{
+							Space: "https://www.rssboard.org/rss-specification",
+							Local: "guid",
+						},
+						Value: "dzpodtop10",
+					},
+				},
+				GoogleplayAuthor:      "Dafna's friend",
+				GoogleplayDescription: "Here are the top 10 misunderstandings about the care, feeding, and breeding of these lovable striped animals.",
+				GoogleplayExplicit:    GoogleplayExplicitClean,
+				GoogleplayBlock:       Yes,
+			},
+		},
+	},
+	Version: "2.0",
+}
+
+//go:embed samples/podcastindex.rss
+var PodcastIndexSample []byte
+
+var PodcastIndexSampleExpected = RSSPodcast{
+	XMLName: xml.Name{
+		Space: "https://www.rssboard.org/rss-specification",
+		Local: "rss",
+	},
+	Channel: Podcast{
+		Channel: rss.Channel{
+			Title: "Podcasting 2.0 Phase 6 Example",
+			Link:  "http://example.com/podcast",
+			Description: rss.Description{
+				XMLName: xml.Name{
+					Space: "https://www.rssboard.org/rss-specification",
+					Local: "description",
+				},
+				Value: "Tags that were added to the namespace in later phases.",
+			},
+		},
+		ItunesImage: ItunesImageTag{
+			XMLName: xml.Name{
+				Space: "http://www.itunes.com/dtds/podcast-1.0.dtd",
+				Local: "image",
+			},
+			Href: "https://example.com/images/pci_avatar-massive.jpg",
+		},
+		PodcastImages: &PodcastImages{
+			XMLName: xml.Name{
+				Space: "https://podcastindex.org/namespace/1.0",
+				Local: "images",
+			},
+			Srcset: "https://example.com/images/pci_avatar-massive.jpg 1500w, https://example.com/images/pci_avatar-tiny.jpg 150w",
+		},
+		PodcastUpdateFrequency: &PodcastUpdateFrequency{
+			XMLName: xml.Name{
+				Space: "https://podcastindex.org/namespace/1.0",
+				Local: "updateFrequency",
+			},
+			UpdateFrequencyText: "Weekly on Tuesdays",
+			Dtstart:             "2023-01-03T10:00:00.000Z",
+			Rrule:               "FREQ=WEEKLY;BYDAY=TU",
+		},
+		PodcastPodping: &PodcastPodping{
+			XMLName: xml.Name{
+				Space: "https://podcastindex.org/namespace/1.0",
+				Local: "podping",
+			},
+			UsesPodping: true,
+		},
+		PodcastPublisher: &PodcastPublisher{
+			XMLName: xml.Name{
+				Space: "https://podcastindex.org/namespace/1.0",
+				Local: "publisher",
+			},
+			RemoteItem: PodcastRemoteItem{
+				XMLName: xml.Name{
+					Space: "https://podcastindex.org/namespace/1.0",
+					Local: "remoteItem",
+				},
+				FeedGUID: "003af0a0-6a45-55cf-b765-68e3d349551a",
+				FeedURL:  "https://agilesetmedia.com/assets/static/agilesetmedia/publisher.xml",
+				Medium:   "publisher",
+			},
+		},
+		PodcastChat: &PodcastChat{
+			XMLName: xml.Name{
+				Space: "https://podcastindex.org/namespace/1.0",
+				Local: "chat",
+			},
+			Server:    "irc.zeronode.net",
+			Protocol:  "irc",
+			AccountID: "@jsmith",
+			Space:     "#podcastindex",
+		},
+		PodcastRemoteItems: []PodcastRemoteItem{
+			PodcastRemoteItem{
+				XMLName: xml.Name{
+					Space: "https://podcastindex.org/namespace/1.0",
+					Local: "remoteItem",
+				},
+				FeedGUID: "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+				ItemGUID: "asdf089j0-ep240-20230510",
+				Medium:   "music",
+			},
+		},
+		Items: []Episode{
+			Episode{
+				Item: rss.Item{
+					Title: "Episode 4 - The Music",
+					Enclosure: &rss.Enclosure{
+						XMLName: xml.Name{
+							Space: "https://www.rssboard.org/rss-specification",
+							Local: "enclosure",
+						},
+						URL:    "https://example.com/file-04.mp3",
+						Length: 43200000,
+						Type:   "audio/mpeg",
+					},
+					GUID: &rss.GUID{
+						XMLName: xml.Name{
+							Space: "https://www.rssboard.org/rss-specification",
+							Local: "guid",
+						},
+						Value:       "https://example.com/ep0004",
+						IsPermaLink: &t,
+					},
+				},
+				PodcastTxt: []PodcastTxt{
+					PodcastTxt{
+						XMLName: xml.Name{
+							Space: "https://podcastindex.org/namespace/1.0",
+							Local: "txt",
+						},
+						Value:   "S6lpp-7ZCn8-dZfGc-OoyaG",
+						Purpose: "verify",
+					},
+					PodcastTxt{
+						XMLName: xml.Name{
+							Space: "https://podcastindex.org/namespace/1.0",
+							Local: "txt",
+						},
+						Value: "Plain note",
+					},
+				},
+				PodcastLocation: &PodcastLocation{
+					XMLName: xml.Name{
+						Space: "https://podcastindex.org/namespace/1.0",
+						Local: "location",
+					},
+					LocationName: "Kansas",
+					Geo:          "geo:39.7837304,-100.445882",
+					Osm:          "R148838",
+				},
+				PodcastChat: &PodcastChat{
+					XMLName: xml.Name{
+						Space: "https://podcastindex.org/namespace/1.0",
+						Local: "chat",
+					},
+					Server:   "matrix.example.com",
+					Protocol: "matrix",
+					Space:    "#episode4:matrix.example.com",
+				},
+				PodcastRemoteItems: []PodcastRemoteItem{
+					PodcastRemoteItem{
+						XMLName: xml.Name{
+							Space: "https://podcastindex.org/namespace/1.0",
+							Local: "remoteItem",
+						},
+						FeedGUID: "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+						ItemGUID: "track-1",
+					},
+					PodcastRemoteItem{
+						XMLName: xml.Name{
+							Space: "https://podcastindex.org/namespace/1.0",
+							Local: "remoteItem",
+						},
+						FeedGUID: "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+						FeedURL:  "https://example.com/album.xml",
+						ItemGUID: "track-2",
+					},
+				},
+			},
+		},
+	},
+	Version: "2.0",
+}
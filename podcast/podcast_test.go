@@ -21,6 +21,8 @@ var testCases = []struct {
 	{"apple_sample", ApplePodcastSample, ApplePodcastSampleExpected},
 	{"more_complex_sample", MoreComplexSample, MoreComplexSampleExpected},
 	{"podcasting_2.0_example", Podcasting20Example, Podcasting20ExampleExpected},
+	{"platforms_sample", PlatformsSample, PlatformsSampleExpected},
+	{"podcastindex_sample", PodcastIndexSample, PodcastIndexSampleExpected},
 }
 
 func TestUnmarshal(t *testing.T) {
@@ -202,6 +204,15 @@ func ExamplePodcast_decode() {
 	//		"ItunesComplete": "",
 	//		"ItunesBlock": "",
 	//		"ItunesApplePodcastVerify": "",
+	//		"GoogleplayAuthor": "",
+	//		"GoogleplayEmail": "",
+	//		"GoogleplayDescription": "",
+	//		"GoogleplayImage": null,
+	//		"GoogleplayCategory": null,
+	//		"GoogleplayExplicit": "",
+	//		"GoogleplayBlock": "",
+	//		"SpotifyLimit": null,
+	//		"SpotifyCountryOfOrigin": "",
 	//		"PodcastTxt": null,
 	//		"PodcastPodroll": null,
 	//		"PodcastLocked": null,
@@ -227,6 +238,17 @@ func ExamplePodcast_decode() {
 	//		"PodcastGUID": "",
 	//		"PodcastBlock": null,
 	//		"PodcastLicense": null,
+	//		"PodcastImages": null,
+	//		"PodcastUpdateFrequency": null,
+	//		"PodcastPodping": null,
+	//		"PodcastPublisher": null,
+	//		"PodcastChat": null,
+	//		"PodcastRemoteItems": null,
+	//		"MediaThumbnails": null,
+	//		"MediaCredits": null,
+	//		"AtomLinks": null,
+	//		"FeedHistoryComplete": null,
+	//		"FeedHistoryArchive": null,
 	//		"Items": [
 	//			{
 	//				"XMLName": {
@@ -259,8 +281,13 @@ func ExamplePodcast_decode() {
 	//				"ItunesSeason": 0,
 	//				"ItunesEpisodeType": "",
 	//				"ItunesBlock": "",
+	//				"GoogleplayAuthor": "",
+	//				"GoogleplayDescription": "",
+	//				"GoogleplayExplicit": "",
+	//				"GoogleplayBlock": "",
 	//				"PodcastTranscript": null,
 	//				"PodcastChapters": null,
+	//				"PscChapters": null,
 	//				"PodcastSoundbite": null,
 	//				"PodcastPeople": null,
 	//				"PodcastSeason": null,
@@ -271,7 +298,18 @@ func ExamplePodcast_decode() {
 	//				"PodcastImages": null,
 	//				"PodcastSocialInteracts": null,
 	//				"PodcastUpdateFrequency": null,
-	//				"PodcastPodping": null
+	//				"PodcastPodping": null,
+	//				"PodcastTxt": null,
+	//				"PodcastLocation": null,
+	//				"PodcastChat": null,
+	//				"PodcastRemoteItems": null,
+	//				"MediaContents": null,
+	//				"MediaGroups": null,
+	//				"MediaTitle": null,
+	//				"MediaDescription": null,
+	//				"MediaThumbnails": null,
+	//				"MediaRatings": null,
+	//				"MediaCredits": null
 	//			}
 	//		]
 	//	},
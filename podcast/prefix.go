@@ -0,0 +1,146 @@
+package podcast
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TrackingPrefix is the URL prefix of a measurement service, which counts
+// downloads by redirecting requests for media to the origin URL that follows
+// the prefix.
+type TrackingPrefix struct {
+	Name string
+
+	// Pattern matches the prefix at the start of a URL without its scheme
+	Pattern *regexp.Regexp
+}
+
+// TrackingPrefixes are the prefixes of well-known measurement services,
+// recognized by [UnprefixURL]
+var TrackingPrefixes = []TrackingPrefix{
+	{"Podtrac", regexp.MustCompile(`^(?:dts\.|www\.)?podtrac\.com/(?:pts/)?redirect\.[a-z0-9]+/`)},
+	{"Chartable", regexp.MustCompile(`^chtbl\.com/track/[^/]+/`)},
+	{"Chartr", regexp.MustCompile(`^chrt\.fm/track/[^/]+/`)},
+	{"Podsights", regexp.MustCompile(`^pdst\.fm/e/`)},
+	{"Spotify Ad Analytics", regexp.MustCompile(`^prfx\.byspotify\.com/e/`)},
+	{"OP3", regexp.MustCompile(`^op3\.dev/e(?:,[^/]*)?/`)},
+	{"Podscribe", regexp.MustCompile(`^verifi\.podscribe\.com/rss/p/`)},
+	{"Magellan AI", regexp.MustCompile(`^mgln\.ai/e/[^/]+/`)},
+	{"Claritas", regexp.MustCompile(`^clrtpod\.com/m/`)},
+	{"Artsai", regexp.MustCompile(`^arttrk\.com/p/[^/]+/`)},
+}
+
+// splitScheme splits an HTTP URL into its scheme and the rest. The scheme is
+// "" for other URLs.
+func splitScheme(u string) (scheme, rest string) {
+	for _, scheme := range []string{"https", "http"} {
+		if len(u) > len(scheme)+3 && strings.EqualFold(u[:len(scheme)+3], scheme+"://") {
+			return scheme, u[len(scheme)+3:]
+		}
+	}
+	return "", u
+}
+
+// UnprefixURL recovers the origin URL of media from a URL with tracking
+// prefixes, by removing the prefixes in [TrackingPrefixes] and prefixes from
+// its start. The origin gets the scheme of the innermost prefix that kept it,
+// or of the URL. URLs without prefixes are returned unchanged.
+func UnprefixURL(u string, prefixes ...string) string {
+	scheme, rest := splitScheme(u)
+	if scheme == "" {
+		return u
+	}
+	for {
+		trimmed := trimTrackingPrefix(rest, prefixes)
+		if trimmed == rest || trimmed == "" {
+			break
+		}
+		rest = trimmed
+		if innerScheme, innerRest := splitScheme(rest); innerScheme != "" {
+			scheme, rest = innerScheme, innerRest
+		}
+	}
+	return scheme + "://" + rest
+}
+
+// trimTrackingPrefix removes the first prefix found at the start of u,
+// without its scheme
+func trimTrackingPrefix(u string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		if _, prefix := splitScheme(normalizePrefix(prefix)); strings.HasPrefix(u, prefix) {
+			return u[len(prefix):]
+		}
+	}
+	for _, known := range TrackingPrefixes {
+		if match := known.Pattern.FindString(u); match != "" {
+			return u[len(match):]
+		}
+	}
+	return u
+}
+
+func normalizePrefix(prefix string) string {
+	if !strings.HasSuffix(prefix, "/") {
+		return prefix + "/"
+	}
+	return prefix
+}
+
+// PrefixURL applies tracking prefixes, such as
+// "https://dts.podtrac.com/redirect.mp3/", to the URL of media. The first
+// prefix is the outermost, so requests go through the prefixes in order.
+// Prefixes already on the URL are removed first with [UnprefixURL], so URLs
+// are never prefixed twice. Each prefix is followed by the URL it wraps
+// without its scheme. URLs other than HTTP URLs are returned unchanged.
+func PrefixURL(u string, prefixes ...string) string {
+	if scheme, _ := splitScheme(u); scheme == "" {
+		return u
+	}
+	u = UnprefixURL(u, prefixes...)
+	for i := len(prefixes) - 1; i >= 0; i-- {
+		if prefixes[i] == "" {
+			continue
+		}
+		_, rest := splitScheme(u)
+		u = normalizePrefix(prefixes[i]) + rest
+	}
+	return u
+}
+
+// PrefixEnclosures applies tracking prefixes with [PrefixURL] to the URLs of
+// the feed's media: its episodes' enclosures and alternate enclosure
+// sources, and its trailers. Without prefixes, it removes known ones.
+func (p *Podcast) PrefixEnclosures(prefixes ...string) {
+	p.rewriteMediaURLs(func(u string) string {
+		return PrefixURL(u, prefixes...)
+	})
+}
+
+// UnprefixEnclosures recovers the origin URLs of the feed's media with
+// [UnprefixURL], such as when ingesting another publisher's feed
+func (p *Podcast) UnprefixEnclosures(prefixes ...string) {
+	p.rewriteMediaURLs(func(u string) string {
+		return UnprefixURL(u, prefixes...)
+	})
+}
+
+func (p *Podcast) rewriteMediaURLs(rewrite func(string) string) {
+	for i := range p.PodcastTrailers {
+		p.PodcastTrailers[i].URL = rewrite(p.PodcastTrailers[i].URL)
+	}
+	for i := range p.Items {
+		episode := &p.Items[i]
+		if episode.Enclosure != nil {
+			episode.Enclosure.URL = rewrite(episode.Enclosure.URL)
+		}
+		for j := range episode.PodcastAlternateEnclosures {
+			sources := episode.PodcastAlternateEnclosures[j].Source
+			for k := range sources {
+				sources[k].URI = rewrite(sources[k].URI)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package podcast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestPrefixURL(t *testing.T) {
+	const (
+		podtrac = "https://dts.podtrac.com/redirect.mp3/"
+		op3     = "https://op3.dev/e/"
+		custom  = "https://stats.example.net/t"
+	)
+	testCases := []struct {
+		name     string
+		url      string
+		prefixes []string
+		expected string
+	}{
+		{"one", "https://example.com/1.mp3", []string{podtrac}, "https://dts.podtrac.com/redirect.mp3/example.com/1.mp3"},
+		{"chained", "https://example.com/1.mp3", []string{podtrac, op3}, "https://dts.podtrac.com/redirect.mp3/op3.dev/e/example.com/1.mp3"},
+		{"custom", "http://example.com/1.mp3?a=b", []string{custom}, "https://stats.example.net/t/example.com/1.mp3?a=b"},
+		{"already prefixed", "https://dts.podtrac.com/redirect.mp3/example.com/1.mp3", []string{podtrac}, "https://dts.podtrac.com/redirect.mp3/example.com/1.mp3"},
+		{"reordered", "https://op3.dev/e/dts.podtrac.com/redirect.mp3/example.com/1.mp3", []string{podtrac, op3}, "https://dts.podtrac.com/redirect.mp3/op3.dev/e/example.com/1.mp3"},
+		{"replaced", "https://chtbl.com/track/AB12C/example.com/1.mp3", []string{op3}, "https://op3.dev/e/example.com/1.mp3"},
+		{"custom already prefixed", "https://stats.example.net/t/example.com/1.mp3", []string{custom}, "https://stats.example.net/t/example.com/1.mp3"},
+		{"removed", "https://pdst.fm/e/chrt.fm/track/X1/example.com/1.mp3", nil, "https://example.com/1.mp3"},
+		{"not http", "ipfs://QmExample", []string{podtrac}, "ipfs://QmExample"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := PrefixURL(tc.url, tc.prefixes...); actual != tc.expected {
+				t.Errorf("PrefixURL(%q) = %q, expected %q", tc.url, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestUnprefixURL(t *testing.T) {
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/1.mp3", "https://example.com/1.mp3"},
+		{"https://dts.podtrac.com/redirect.mp3/example.com/1.mp3", "https://example.com/1.mp3"},
+		{"http://www.podtrac.com/pts/redirect.m4a/example.com/1.m4a", "http://example.com/1.m4a"},
+		{"https://op3.dev/e,pg=9b024349-ccf0-5f69-a609-6b82873eab3c/https://pdst.fm/e/http://example.com/1.mp3", "http://example.com/1.mp3"},
+		{"https://verifi.podscribe.com/rss/p/mgln.ai/e/123/arttrk.com/p/ABC/clrtpod.com/m/prfx.byspotify.com/e/example.com/1.mp3", "https://example.com/1.mp3"},
+		{"https://chtbl.com/track/", "https://chtbl.com/track/"},
+		{"https://pdst.fm/e/", "https://pdst.fm/e/"},
+	}
+	for _, tc := range testCases {
+		if actual := UnprefixURL(tc.url); actual != tc.expected {
+			t.Errorf("UnprefixURL(%q) = %q, expected %q", tc.url, actual, tc.expected)
+		}
+	}
+}
+
+func TestPrefixEnclosures(t *testing.T) {
+	feed := func(prefix string) *Podcast {
+		return &Podcast{
+			PodcastTrailers: []PodcastTrailer{{URL: prefix + "example.com/trailer.mp3"}},
+			Items: []Episode{
+				{Item: rss.Item{Title: "No media"}},
+				{
+					Item: rss.Item{Enclosure: &rss.Enclosure{URL: prefix + "example.com/1.mp3"}},
+					PodcastAlternateEnclosures: []PodcastAlternateEnclosure{{Source: []PodcastSource{
+						{URI: prefix + "example.com/1.opus"},
+						{URI: "ipfs://QmExample"},
+					}}},
+				},
+			},
+		}
+	}
+
+	actual := feed("https://")
+	actual.PrefixEnclosures("https://op3.dev/e/")
+	if diff := cmp.Diff(feed("https://op3.dev/e/"), actual); diff != "" {
+		t.Errorf("PrefixEnclosures() mismatch (-want +got):\n%s", diff)
+	}
+	actual.UnprefixEnclosures()
+	if diff := cmp.Diff(feed("https://"), actual); diff != "" {
+		t.Errorf("UnprefixEnclosures() mismatch (-want +got):\n%s", diff)
+	}
+}
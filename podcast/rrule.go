@@ -0,0 +1,597 @@
+package podcast
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a [RecurrenceRule] repeats
+type Frequency string
+
+// The frequencies [ParseRecurrenceRule] supports
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+// RuleWeekday is a BYDAY value: a day of the week, and for monthly and yearly
+// rules, which one of them in the month or year, counting back from the end
+// if negative. Zero means every one.
+type RuleWeekday struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// RecurrenceRule is an iCalendar recurrence rule, as used by
+// podcast:updateFrequency. It supports the FREQ, INTERVAL, BYDAY, BYMONTHDAY,
+// COUNT, UNTIL and WKST parts of [RFC 5545].
+//
+// [RFC 5545]: https://www.rfc-editor.org/rfc/rfc5545#section-3.3.10
+type RecurrenceRule struct {
+	Freq Frequency
+
+	// Interval is how many periods of Freq are between occurrences.
+	// Defaults to 1.
+	Interval int
+
+	ByDay      []RuleWeekday
+	ByMonthDay []int
+
+	// Count limits the number of occurrences, if positive
+	Count int
+
+	// Until is the last time the rule can occur, if set
+	Until time.Time
+
+	// WeekStart is the day weeks start on, for weekly rules with an interval.
+	// ParseRecurrenceRule defaults it to Monday, as RFC 5545 does.
+	WeekStart time.Weekday
+
+	// Start is the first time the rule can occur, from the dtstart
+	// attribute. Occurrences are at its time of day. If it's zero, the rule
+	// is anchored at the time it's evaluated from.
+	Start time.Time
+}
+
+var ruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceRule parses the update frequency's rrule and dtstart
+func (f *PodcastUpdateFrequency) RecurrenceRule() (*RecurrenceRule, error) {
+	return ParseRecurrenceRule(f.Rrule, f.Dtstart)
+}
+
+// ParseRecurrenceRule parses an RFC 5545 RRULE value, with or without the
+// "RRULE:" prefix, and dtstart, an ISO 8601 date or date-time, which may be
+// empty unless the rule has a COUNT.
+func ParseRecurrenceRule(rrule, dtstart string) (*RecurrenceRule, error) {
+	r := &RecurrenceRule{Interval: 1, WeekStart: time.Monday}
+	if dtstart = strings.TrimSpace(dtstart); dtstart != "" {
+		start, _, err := parseRuleTime(dtstart, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("podcast: invalid dtstart %q", dtstart)
+		}
+		r.Start = start
+	}
+
+	rrule = strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:")
+	if rrule == "" {
+		return nil, fmt.Errorf("podcast: empty rrule")
+	}
+	for _, part := range strings.Split(rrule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("podcast: invalid rrule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			switch r.Freq {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+			default:
+				return nil, fmt.Errorf("podcast: unsupported rrule frequency %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "UNTIL":
+			location := time.UTC
+			if !r.Start.IsZero() {
+				location = r.Start.Location()
+			}
+			var dateOnly bool
+			r.Until, dateOnly, err = parseRuleTime(value, location)
+			if dateOnly {
+				// The whole day is included
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				var weekday RuleWeekday
+				weekday, err = parseRuleWeekday(day)
+				if err != nil {
+					break
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				var n int
+				n, err = strconv.Atoi(day)
+				if err == nil && (n == 0 || n < -31 || n > 31) {
+					err = fmt.Errorf("out of range")
+				}
+				if err != nil {
+					break
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "WKST":
+			var ok bool
+			r.WeekStart, ok = ruleWeekdays[strings.ToUpper(value)]
+			if !ok {
+				err = fmt.Errorf("unknown weekday")
+			}
+		default:
+			return nil, fmt.Errorf("podcast: unsupported rrule part %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("podcast: invalid rrule part %q: %w", part, err)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return nil, fmt.Errorf("podcast: rrule %q has no FREQ", rrule)
+	case r.Count > 0 && !r.Until.IsZero():
+		return nil, fmt.Errorf("podcast: rrule %q has both COUNT and UNTIL", rrule)
+	case r.Count > 0 && r.Start.IsZero():
+		return nil, fmt.Errorf("podcast: rrule %q has a COUNT but there is no dtstart", rrule)
+	case len(r.ByMonthDay) > 0 && r.Freq == FrequencyWeekly:
+		return nil, fmt.Errorf("podcast: rrule %q has BYMONTHDAY, which isn't allowed for weekly rules", rrule)
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != FrequencyMonthly && r.Freq != FrequencyYearly {
+			return nil, fmt.Errorf("podcast: rrule %q numbers BYDAY, which is only allowed for monthly and yearly rules", rrule)
+		}
+	}
+	return r, nil
+}
+
+// parseRuleTime parses an ISO 8601 or RFC 5545 date or date-time. Times
+// without a zone are in location.
+func parseRuleTime(value string, location *time.Location) (t time.Time, dateOnly bool, err error) {
+	for _, layout := range []string{time.RFC3339Nano, "20060102T150405Z07:00"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, false, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "20060102T150405"} {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, false, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02", "20060102"} {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, true, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+}
+
+func parseRuleWeekday(value string) (RuleWeekday, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return RuleWeekday{}, fmt.Errorf("invalid weekday %q", value)
+	}
+	weekday, ok := ruleWeekdays[value[len(value)-2:]]
+	if !ok {
+		return RuleWeekday{}, fmt.Errorf("invalid weekday %q", value)
+	}
+	var n int
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return RuleWeekday{}, fmt.Errorf("invalid weekday %q", value)
+		}
+	}
+	return RuleWeekday{n, weekday}, nil
+}
+
+// String formats the rule as an RRULE value, without dtstart
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.WeekStart != time.Monday && r.Freq == FrequencyWeekly && r.Interval > 1 {
+		parts = append(parts, "WKST="+weekdayCode(r.WeekStart))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = weekdayCode(day.Weekday)
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func weekdayCode(weekday time.Weekday) string {
+	return strings.ToUpper(weekday.String()[:2])
+}
+
+// UpdateFrequency is a podcast:updateFrequency element for the rule, with a
+// description of it as the text
+func (r *RecurrenceRule) UpdateFrequency() *PodcastUpdateFrequency {
+	frequency := &PodcastUpdateFrequency{
+		UpdateFrequencyText: r.Text(),
+		Rrule:               r.String(),
+	}
+	if !r.Start.IsZero() {
+		frequency.Dtstart = r.Start.Format("2006-01-02T15:04:05.000Z07:00")
+	}
+	return frequency
+}
+
+// Text describes the rule in English, such as "Weekly on Tuesday" or "Monthly
+// on the first Monday". It's "" for rules without a frequency, such as the
+// zero RecurrenceRule.
+func (r *RecurrenceRule) Text() string {
+	if r.Freq == "" {
+		return ""
+	}
+	units := map[Frequency]string{
+		FrequencyDaily:   "day",
+		FrequencyWeekly:  "week",
+		FrequencyMonthly: "month",
+		FrequencyYearly:  "year",
+	}
+	text := strings.ToUpper(string(r.Freq[:1])) + strings.ToLower(string(r.Freq[1:]))
+	if r.Interval > 1 {
+		text = fmt.Sprintf("Every %d %ss", r.Interval, units[r.Freq])
+	}
+
+	var on []string
+	ordinals := map[int]string{1: "first", 2: "second", 3: "third", 4: "fourth", 5: "fifth", -1: "last", -2: "second to last"}
+	for _, day := range r.ByDay {
+		switch ordinal, ok := ordinals[day.N]; {
+		case day.N == 0:
+			on = append(on, day.Weekday.String())
+		case ok:
+			on = append(on, "the "+ordinal+" "+day.Weekday.String())
+		default:
+			on = append(on, fmt.Sprintf("%s number %d", day.Weekday, day.N))
+		}
+	}
+	for _, day := range r.ByMonthDay {
+		if day == -1 {
+			on = append(on, "the last day")
+		} else {
+			on = append(on, "day "+strconv.Itoa(day))
+		}
+	}
+	if len(on) > 0 {
+		text += " on " + strings.Join(on, ", ")
+	}
+	return text
+}
+
+// maxRulePeriods limits how far NextOccurrences looks for occurrences of a
+// rule that might never occur, like the 31st of every other February
+const maxRulePeriods = 10000
+
+// NextOccurrences returns the next n times the rule occurs after after. It
+// returns fewer if the rule ends first.
+func (r *RecurrenceRule) NextOccurrences(after time.Time, n int) []time.Time {
+	start := r.Start
+	if start.IsZero() {
+		start = after.Truncate(time.Second)
+	}
+	interval := max(r.Interval, 1)
+
+	var occurrences []time.Time
+	count := 0
+	period := 0
+	if r.Count == 0 && after.After(start) {
+		// Skip the periods before after, which can't have occurrences. Rules
+		// with a count have to be counted from the start.
+		period = r.periodsBetween(start, after) / interval * interval
+		period = max(period-interval, 0)
+	}
+	for i := 0; i < maxRulePeriods && len(occurrences) < n; i, period = i+1, period+interval {
+		for _, t := range r.occurrencesIn(start, period) {
+			if t.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) || r.Count > 0 && count >= r.Count {
+				return occurrences
+			}
+			count++
+			if t.After(after) {
+				occurrences = append(occurrences, t)
+				if len(occurrences) == n {
+					break
+				}
+			}
+		}
+	}
+	return occurrences
+}
+
+// periodsBetween is how many whole periods of the rule's frequency start
+// is before t
+func (r *RecurrenceRule) periodsBetween(start, t time.Time) int {
+	t = t.In(start.Location())
+	days := func(a, b time.Time) int {
+		ay, am, ad := a.Date()
+		by, bm, bd := b.Date()
+		return int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+	}
+	switch r.Freq {
+	case FrequencyDaily:
+		return days(start, t)
+	case FrequencyWeekly:
+		return days(r.weekOf(start), r.weekOf(t)) / 7
+	case FrequencyMonthly:
+		return (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+	default:
+		return t.Year() - start.Year()
+	}
+}
+
+// weekOf is the first day of the week t is in
+func (r *RecurrenceRule) weekOf(t time.Time) time.Time {
+	back := (int(t.Weekday()) - int(r.WeekStart) + 7) % 7
+	return t.AddDate(0, 0, -back)
+}
+
+// occurrencesIn returns the times the rule occurs in the period that is
+// period periods of its frequency after the one start is in, in order
+func (r *RecurrenceRule) occurrencesIn(start time.Time, period int) []time.Time {
+	year, month, day := start.Date()
+	hour, minute, second := start.Clock()
+	location := start.Location()
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, location)
+	}
+
+	// The days in the period
+	var first time.Time
+	var length int
+	switch r.Freq {
+	case FrequencyDaily:
+		first, length = date(year, month, day+period), 1
+	case FrequencyWeekly:
+		week := r.weekOf(date(year, month, day))
+		first, length = week.AddDate(0, 0, 7*period), 7
+	case FrequencyMonthly:
+		first = date(year, month+time.Month(period), 1)
+		length = daysIn(first.Year(), first.Month())
+	default:
+		first = date(year+period, time.January, 1)
+		length = daysInYear(year + period)
+	}
+
+	var occurrences []time.Time
+	for i := 0; i < length; i++ {
+		t := first.AddDate(0, 0, i)
+		if r.matches(start, t) {
+			occurrences = append(occurrences, t)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	return occurrences
+}
+
+// matches reports if the rule occurs on the day of t
+func (r *RecurrenceRule) matches(start, t time.Time) bool {
+	if len(r.ByMonthDay) > 0 {
+		found := false
+		days := daysIn(t.Year(), t.Month())
+		for _, day := range r.ByMonthDay {
+			if day == t.Day() || day < 0 && days+day+1 == t.Day() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.ByDay) > 0 {
+		found := false
+		for _, day := range r.ByDay {
+			if day.Weekday == t.Weekday() && (day.N == 0 || day.N == r.weekdayNumber(t, day.N < 0)) {
+				found = true
+				break
+			}
+		}
+		return found
+	}
+	if len(r.ByMonthDay) > 0 {
+		return true
+	}
+
+	// Without BY parts, rules occur on the start's day of the period
+	switch r.Freq {
+	case FrequencyWeekly:
+		return t.Weekday() == start.Weekday()
+	case FrequencyMonthly:
+		return t.Day() == start.Day()
+	case FrequencyYearly:
+		return t.Month() == start.Month() && t.Day() == start.Day()
+	}
+	return true
+}
+
+// weekdayNumber is which of its weekday t is in its month, for monthly rules,
+// or year, counting from the end if fromEnd, as a negative number
+func (r *RecurrenceRule) weekdayNumber(t time.Time, fromEnd bool) int {
+	day, days := t.Day(), daysIn(t.Year(), t.Month())
+	if r.Freq == FrequencyYearly {
+		day = t.YearDay()
+		days = daysInYear(t.Year())
+	}
+	if fromEnd {
+		return -((days-day)/7 + 1)
+	}
+	return (day-1)/7 + 1
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysInYear(year int) int {
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+// ruleHistory is how many of the latest episodes InferRecurrenceRule looks at
+const ruleHistory = 12
+
+// InferRecurrenceRule guesses the show's release schedule from the pubDates
+// of its latest episodes: weekly on the days it publishes, daily, every other
+// week, or monthly on a day or weekday of the month. It returns false if the
+// show doesn't publish regularly enough for a rule to fit.
+func (p *Podcast) InferRecurrenceRule() (*RecurrenceRule, bool) {
+	var times []time.Time
+	for i := range p.Items {
+		if t, err := p.Items[i].PubDate.Time(); err == nil {
+			times = append(times, t)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	if len(times) > ruleHistory {
+		times = times[len(times)-ruleHistory:]
+	}
+	if len(times) < 3 {
+		return nil, false
+	}
+
+	// Episodes come out at the latest episode's time of day, in its zone
+	latest := times[len(times)-1]
+	for i := range times {
+		times[i] = times[i].In(latest.Location())
+	}
+	var gaps []int
+	for i := 1; i < len(times); i++ {
+		gaps = append(gaps, int(times[i].Sub(times[i-1]).Round(24*time.Hour)/(24*time.Hour)))
+	}
+	sort.Ints(gaps)
+	gap := gaps[len(gaps)/2]
+
+	first := times[0]
+	hour, minute, second := latest.Clock()
+	start := time.Date(first.Year(), first.Month(), first.Day(), hour, minute, second, 0, latest.Location())
+	rule := &RecurrenceRule{Interval: 1, WeekStart: time.Monday, Start: start}
+	switch {
+	case gap <= 5:
+		weekdays := map[time.Weekday]int{}
+		for _, t := range times {
+			weekdays[t.Weekday()]++
+		}
+		if len(weekdays) == 7 {
+			rule.Freq = FrequencyDaily
+			break
+		}
+		rule.Freq = FrequencyWeekly
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if weekdays[weekday] > 0 {
+				rule.ByDay = append(rule.ByDay, RuleWeekday{Weekday: weekday})
+			}
+		}
+	case gap <= 8, gap >= 12 && gap <= 16:
+		rule.Freq = FrequencyWeekly
+		rule.Interval = (gap + 3) / 7
+		rule.ByDay = []RuleWeekday{{Weekday: mostCommon(times, func(t time.Time) time.Weekday { return t.Weekday() })}}
+	case gap >= 26 && gap <= 33:
+		rule.Freq = FrequencyMonthly
+		day := mostCommon(times, func(t time.Time) int { return t.Day() })
+		weekday := mostCommon(times, func(t time.Time) RuleWeekday {
+			return RuleWeekday{rule.weekdayNumber(t, false), t.Weekday()}
+		})
+		rule.ByMonthDay = []int{day}
+		if rule.fit(times) < 0.75 {
+			rule.ByMonthDay = nil
+			rule.ByDay = []RuleWeekday{weekday}
+		}
+	default:
+		return nil, false
+	}
+
+	if rule.fit(times) < 0.75 {
+		return nil, false
+	}
+	return rule, true
+}
+
+// fit is the share of times that are on days the rule occurs
+func (r *RecurrenceRule) fit(times []time.Time) float64 {
+	days := map[string]bool{}
+	from := times[0].Add(-time.Second)
+	for _, t := range r.NextOccurrences(from, 3*len(times)*7) {
+		if t.After(times[len(times)-1].AddDate(0, 0, 1)) {
+			break
+		}
+		days[t.Format(time.DateOnly)] = true
+	}
+	matched := 0
+	for _, t := range times {
+		if days[t.Format(time.DateOnly)] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(times))
+}
+
+// mostCommon returns the most common key of times, preferring the latest
+func mostCommon[K comparable](times []time.Time, key func(time.Time) K) K {
+	counts := map[K]int{}
+	var best K
+	for i := len(times) - 1; i >= 0; i-- {
+		k := key(times[i])
+		counts[k]++
+		if counts[k] > counts[best] {
+			best = k
+		}
+	}
+	return best
+}
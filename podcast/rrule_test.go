@@ -0,0 +1,179 @@
+package podcast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestNextOccurrences(t *testing.T) {
+	after := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		rrule    string
+		dtstart  string
+		after    time.Time
+		n        int
+		expected []string
+	}{
+		{"weekly", "FREQ=WEEKLY;BYDAY=TU", "2023-01-03T10:00:00.000Z", after, 3, []string{"2024-03-05T10:00:00Z", "2024-03-12T10:00:00Z", "2024-03-19T10:00:00Z"}},
+		{"every other day", "FREQ=DAILY;INTERVAL=2", "2024-03-01T08:00:00Z", after, 3, []string{"2024-03-05T08:00:00Z", "2024-03-07T08:00:00Z", "2024-03-09T08:00:00Z"}},
+		{"count", "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4", "2024-03-04T09:00:00Z", after.AddDate(0, 0, -7), 10, []string{"2024-03-04T09:00:00Z", "2024-03-06T09:00:00Z", "2024-03-08T09:00:00Z", "2024-03-11T09:00:00Z"}},
+		{"count from start", "FREQ=DAILY;COUNT=3", "2024-03-03T09:00:00Z", after, 10, []string{"2024-03-05T09:00:00Z"}},
+		{"first tuesday", "FREQ=MONTHLY;BYDAY=1TU", "2024-01-01T12:00:00Z", after, 3, []string{"2024-03-05T12:00:00Z", "2024-04-02T12:00:00Z", "2024-05-07T12:00:00Z"}},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", "2024-01-01T12:00:00Z", after, 2, []string{"2024-03-29T12:00:00Z", "2024-04-26T12:00:00Z"}},
+		{"last day", "FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-31T00:00:00Z", after, 3, []string{"2024-03-31T00:00:00Z", "2024-04-30T00:00:00Z", "2024-05-31T00:00:00Z"}},
+		{"skips short months", "FREQ=MONTHLY;BYMONTHDAY=31", "2024-01-31T00:00:00Z", after, 3, []string{"2024-03-31T00:00:00Z", "2024-05-31T00:00:00Z", "2024-07-31T00:00:00Z"}},
+		{"until", "FREQ=WEEKLY;BYDAY=TU;UNTIL=20240319", "2024-03-05T10:00:00Z", after.AddDate(0, 0, -7), 10, []string{"2024-03-05T10:00:00Z", "2024-03-12T10:00:00Z", "2024-03-19T10:00:00Z"}},
+		{"every other week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TH", "2024-01-04T00:00:00Z", after, 2, []string{"2024-03-14T00:00:00Z", "2024-03-28T00:00:00Z"}},
+		{"leap days", "FREQ=YEARLY", "2020-02-29T06:00:00Z", after.AddDate(-3, 0, 0), 2, []string{"2024-02-29T06:00:00Z", "2028-02-29T06:00:00Z"}},
+		{"with offset", "RRULE:FREQ=WEEKLY;BYDAY=MO", "2024-01-01T09:00:00-05:00", after, 1, []string{"2024-03-04T09:00:00-05:00"}},
+		{"no dtstart", "FREQ=WEEKLY;BYDAY=FR", "", after, 2, []string{"2024-03-08T12:00:00Z", "2024-03-15T12:00:00Z"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tc.rrule, tc.dtstart)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, occurrence := range rule.NextOccurrences(tc.after, tc.n) {
+				actual = append(actual, occurrence.Format(time.RFC3339))
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected occurrences (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRuleErrors(t *testing.T) {
+	testCases := []struct{ rrule, dtstart string }{
+		{"", ""},
+		{"FREQ=HOURLY", ""},
+		{"INTERVAL=2", ""},
+		{"FREQ=WEEKLY;BYDAY=1MO", ""},
+		{"FREQ=WEEKLY;BYDAY=XX", ""},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", ""},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", ""},
+		{"FREQ=DAILY;COUNT=3", ""},
+		{"FREQ=DAILY;COUNT=3;UNTIL=20240101", "2023-01-01"},
+		{"FREQ=WEEKLY;BYSETPOS=1", ""},
+		{"FREQ=WEEKLY", "next tuesday"},
+	}
+	for _, tc := range testCases {
+		if _, err := ParseRecurrenceRule(tc.rrule, tc.dtstart); err == nil {
+			t.Errorf("expected %q with dtstart %q to be invalid", tc.rrule, tc.dtstart)
+		}
+	}
+}
+
+func TestRecurrenceRuleText(t *testing.T) {
+	testCases := []struct{ rrule, text string }{
+		{"FREQ=DAILY", "Daily"},
+		{"FREQ=WEEKLY;BYDAY=TU", "Weekly on Tuesday"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "Every 2 weeks on Monday, Thursday"},
+		{"FREQ=MONTHLY;BYDAY=1TU", "Monthly on the first Tuesday"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "Monthly on the last day"},
+	}
+	for _, tc := range testCases {
+		rule, err := ParseRecurrenceRule(tc.rrule, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := rule.Text(); text != tc.text {
+			t.Errorf("%s: expected %q, got %q", tc.rrule, tc.text, text)
+		}
+		if rrule := rule.String(); rrule != tc.rrule {
+			t.Errorf("expected %q to format the same, got %q", tc.rrule, rrule)
+		}
+	}
+
+	if text := (&RecurrenceRule{}).Text(); text != "" {
+		t.Errorf("expected no text for the zero rule, got %q", text)
+	}
+}
+
+// publishedOn is a show with episodes published at the times
+func publishedOn(times ...time.Time) *Podcast {
+	p := &Podcast{}
+	for _, t := range times {
+		p.Items = append(p.Items, Episode{Item: rss.Item{PubDate: rss.NewRFC2822Date(t)}})
+	}
+	return p
+}
+
+// every returns count times, step apart, from the first
+func every(first time.Time, count int, step func(time.Time) time.Time) []time.Time {
+	times := []time.Time{first}
+	for len(times) < count {
+		times = append(times, step(times[len(times)-1]))
+	}
+	return times
+}
+
+func TestInferRecurrenceRule(t *testing.T) {
+	// A Tuesday
+	tuesday := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	var weekdays []time.Time
+	for day := 0; day < 21; day++ {
+		if t := tuesday.AddDate(0, 0, day); t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			weekdays = append(weekdays, t)
+		}
+	}
+	var firstMondays []time.Time
+	for month := time.January; month <= time.June; month++ {
+		t := time.Date(2024, month, 1, 8, 0, 0, 0, time.UTC)
+		for t.Weekday() != time.Monday {
+			t = t.AddDate(0, 0, 1)
+		}
+		firstMondays = append(firstMondays, t)
+	}
+
+	testCases := []struct {
+		name    string
+		times   []time.Time
+		rrule   string
+		dtstart string
+	}{
+		{"weekly", every(tuesday, 8, func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }), "FREQ=WEEKLY;BYDAY=TU", "2024-01-02T10:00:00.000Z"},
+		{"weekdays", weekdays, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", "2024-01-05T10:00:00.000Z"},
+		{"daily", every(tuesday, 10, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }), "FREQ=DAILY", "2024-01-02T10:00:00.000Z"},
+		{"every other week", every(tuesday, 6, func(t time.Time) time.Time { return t.AddDate(0, 0, 14) }), "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", "2024-01-02T10:00:00.000Z"},
+		{"monthly", every(tuesday.AddDate(0, 0, 13), 6, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }), "FREQ=MONTHLY;BYMONTHDAY=15", "2024-01-15T10:00:00.000Z"},
+		{"first monday", firstMondays, "FREQ=MONTHLY;BYDAY=1MO", "2024-01-01T08:00:00.000Z"},
+		{"irregular", []time.Time{tuesday, tuesday.AddDate(0, 0, 3), tuesday.AddDate(0, 0, 20), tuesday.AddDate(0, 2, 0)}, "", ""},
+		{"too few", []time.Time{tuesday, tuesday.AddDate(0, 0, 7)}, "", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, ok := publishedOn(tc.times...).InferRecurrenceRule()
+			if !ok {
+				if tc.rrule != "" {
+					t.Fatal("expected a rule")
+				}
+				return
+			}
+			if tc.rrule == "" {
+				t.Fatalf("expected no rule, got %s", rule)
+			}
+			frequency := rule.UpdateFrequency()
+			if frequency.Rrule != tc.rrule || frequency.Dtstart != tc.dtstart {
+				t.Errorf("expected %s from %s, got %s from %s", tc.rrule, tc.dtstart, frequency.Rrule, frequency.Dtstart)
+			}
+
+			// The rule should parse back the same
+			parsed, err := frequency.RecurrenceRule()
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := tc.times[len(tc.times)-1]
+			if diff := cmp.Diff(rule.NextOccurrences(last, 3), parsed.NextOccurrences(last, 3)); diff != "" {
+				t.Errorf("unexpected occurrences after parsing (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
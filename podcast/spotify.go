@@ -0,0 +1,24 @@
+package podcast
+
+import "encoding/xml"
+
+// SpotifyNamespaceURL is the namespace of the tags described in Spotify's
+// [Podcast Delivery Specification].
+//
+// Types beginning with "Spotify" are from the Spotify spec.
+//
+// [Podcast Delivery Specification]: https://podcasters.spotify.com/terms/Spotify_Podcast_Delivery_Specification_v1.6.pdf
+const SpotifyNamespaceURL = "http://www.spotify.com/ns/rss"
+
+// SpotifyLimit limits the number of episodes that appear in the Spotify
+// client.
+//
+// Example:
+//
+//	<spotify:limit recentCount="1"/>
+type SpotifyLimit struct {
+	XMLName xml.Name `xml:"http://www.spotify.com/ns/rss limit"`
+
+	// RecentCount is the number of most recent episodes Spotify will show.
+	RecentCount int `xml:"recentCount,attr"`
+}
@@ -0,0 +1,375 @@
+package podcast
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jaydenmilne/podcast/internal/uuid"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Severity is how serious a [Problem] is
+type Severity string
+
+const (
+	// SeverityError problems break a requirement, and may get the feed
+	// rejected by directories or apps.
+	SeverityError Severity = "error"
+	// SeverityWarning problems break a recommendation.
+	SeverityWarning Severity = "warning"
+)
+
+// The specs a [Problem] can come from
+const (
+	SpecRSS          = "rss"
+	SpecApple        = "apple"
+	SpecPodcastIndex = "podcastindex"
+)
+
+// Problem is an issue with a feed found by [Validate]
+type Problem struct {
+	Severity Severity `json:"severity"`
+
+	// Spec is where the rule comes from: [SpecRSS], [SpecApple] or
+	// [SpecPodcastIndex]
+	Spec string `json:"spec"`
+
+	// Path locates the element, such as channel.item[2].enclosure, where
+	// items are counted from zero in document order.
+	Path string `json:"path"`
+
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", p.Severity, p.Path, p.Message, p.Spec)
+}
+
+// AppleEnclosureTypes are the enclosure types Apple Podcasts accepts
+var AppleEnclosureTypes = []string{"audio/x-m4a", "audio/mpeg", "video/quicktime", "video/mp4", "video/x-m4v", "application/pdf"}
+
+// Validate checks the feed against the requirements and recommendations of
+// RSS 2.0, [Apple Podcasts] and the [podcast namespace]. Problems are listed
+// in document order, followed by those from [Podcast.CheckNumbering], and an
+// empty list means the feed is valid.
+//
+// Only what can be checked from the feed itself is checked: URLs aren't
+// fetched, so missing artwork or media isn't reported.
+//
+// [Apple Podcasts]: https://podcasters.apple.com/support/823-podcast-requirements
+// [podcast namespace]: https://podcastindex.org/namespace/1.0
+func Validate(feed *RSSPodcast) []Problem {
+	v := &validator{}
+	v.channel(&feed.Channel)
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) report(severity Severity, spec, path, message string, args ...any) {
+	v.problems = append(v.problems, Problem{severity, spec, path, fmt.Sprintf(message, args...)})
+}
+
+// required reports an error from spec if value is blank
+func (v *validator) required(spec, path, value, name string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.report(SeverityError, spec, path, "missing %s", name)
+		return false
+	}
+	return true
+}
+
+// maxLength warns if value is longer than limit characters, the truncation
+// limit of many podcast namespace tags
+func (v *validator) maxLength(path, value string, limit int) {
+	if n := utf8.RuneCountInString(value); n > limit {
+		v.report(SeverityWarning, SpecPodcastIndex, path, "is %d characters, longer than %d may be truncated", n, limit)
+	}
+}
+
+func (v *validator) url(spec, path, value, name string) {
+	if !v.required(spec, path, value, name) {
+		return
+	}
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.report(SeverityError, spec, path, "%s %q is not an http or https URL", name, value)
+	}
+}
+
+func (v *validator) channel(c *Podcast) {
+	const p = "channel"
+
+	v.required(SpecApple, p+".title", c.Title, "title")
+	v.required(SpecApple, p+".description", c.Description.Value, "description")
+	if strings.TrimSpace(c.Link) == "" {
+		v.report(SeverityWarning, SpecRSS, p+".link", "missing link to the show's website")
+	}
+	v.required(SpecApple, p+".language", c.Language, "language")
+
+	if v.required(SpecApple, p+".itunes:image", c.ItunesImage.Href, "artwork") {
+		v.url(SpecApple, p+".itunes:image", c.ItunesImage.Href, "artwork")
+		v.imageExtension(p+".itunes:image", c.ItunesImage.Href)
+	}
+	if len(c.ItunesCategory) == 0 {
+		v.report(SeverityError, SpecApple, p+".itunes:category", "missing category")
+	}
+	if c.ItunesExplicit == nil {
+		v.report(SeverityError, SpecApple, p+".itunes:explicit", "missing explicit flag")
+	}
+	if c.ItunesAuthor == "" {
+		v.report(SeverityWarning, SpecApple, p+".itunes:author", "missing author")
+	}
+	switch c.ItunesType {
+	case "", ItunesShowTypeEpisodic, ItunesShowTypeSerial:
+	default:
+		v.report(SeverityError, SpecApple, p+".itunes:type", "type %q is not episodic or serial", c.ItunesType)
+	}
+	v.date(SpecRSS, p+".pubDate", c.PubDate)
+	v.date(SpecRSS, p+".lastBuildDate", c.LastBuildDate)
+
+	v.podcastGUID(p+".podcast:guid", c.PodcastGUID)
+	if c.PodcastLocked != nil {
+		v.yesOrNo(p+".podcast:locked", c.PodcastLocked.Value)
+	}
+	for i, funding := range c.PodcastFunding {
+		fp := fmt.Sprintf("%s.podcast:funding[%d]", p, i)
+		v.url(SpecPodcastIndex, fp, funding.URL, "url")
+		v.maxLength(fp, string(funding.Value), 128)
+	}
+	switch medium := strings.TrimSuffix(string(c.PodcastMedium), "L"); PodcastMedium(medium) {
+	case "", MediumPodcast, MediumMusic, MediumVideo, MediumFilm, MediumAudiobook, MediumNewsletter, MediumBlog:
+	default:
+		v.report(SeverityWarning, SpecPodcastIndex, p+".podcast:medium", "unknown medium %q", c.PodcastMedium)
+	}
+	if c.PodcastLicense != nil {
+		v.license(p+".podcast:license", c.PodcastLicense)
+	}
+	if c.PodcastTxt != nil {
+		v.txt(p+".podcast:txt", c.PodcastTxt)
+	}
+	for i, person := range c.PodcastPeople {
+		v.person(fmt.Sprintf("%s.podcast:person[%d]", p, i), &person)
+	}
+	for i, value := range c.PodcastValue {
+		v.value(fmt.Sprintf("%s.podcast:value[%d]", p, i), &value)
+	}
+	for i, item := range c.PodcastRemoteItems {
+		v.required(SpecPodcastIndex, fmt.Sprintf("%s.podcast:remoteItem[%d]", p, i), item.FeedGUID, "feedGuid")
+	}
+	if c.PodcastImages != nil {
+		v.required(SpecPodcastIndex, p+".podcast:images", c.PodcastImages.Srcset, "srcset")
+	}
+	if frequency := c.PodcastUpdateFrequency; frequency != nil {
+		fp := p + ".podcast:updateFrequency"
+		v.maxLength(fp, frequency.UpdateFrequencyText, 128)
+		if frequency.Rrule != "" {
+			if _, err := frequency.RecurrenceRule(); err != nil {
+				v.report(SeverityError, SpecPodcastIndex, fp, "%s", strings.TrimPrefix(err.Error(), "podcast: "))
+			}
+		}
+	}
+
+	guids := map[string]int{}
+	enclosures := map[string]int{}
+	for i := range c.Items {
+		item := &c.Items[i]
+		ip := fmt.Sprintf("%s.item[%d]", p, i)
+		v.episode(ip, item)
+
+		if c.ItunesType == ItunesShowTypeSerial && item.EpisodeNumber() == 0 && item.ItunesEpisodeType != TrailerEpisode {
+			v.report(SeverityWarning, SpecApple, ip+".itunes:episode", "missing episode number, which serial shows should have")
+		}
+		if item.GUID != nil && item.GUID.Value != "" {
+			if first, ok := guids[item.GUID.Value]; ok {
+				v.report(SeverityError, SpecRSS, ip+".guid", "GUID %q is also used by item[%d]", item.GUID.Value, first)
+			} else {
+				guids[item.GUID.Value] = i
+			}
+		}
+		if item.Enclosure != nil && item.Enclosure.URL != "" {
+			if first, ok := enclosures[item.Enclosure.URL]; ok {
+				v.report(SeverityWarning, SpecApple, ip+".enclosure", "enclosure URL is also used by item[%d]", first)
+			} else {
+				enclosures[item.Enclosure.URL] = i
+			}
+		}
+	}
+
+	for i := range c.PodcastLiveItem {
+		live := &c.PodcastLiveItem[i]
+		lp := fmt.Sprintf("%s.podcast:liveItem[%d]", p, i)
+		switch live.Status {
+		case StatusPending, StatusLive, StatusEnded:
+		default:
+			v.report(SeverityError, SpecPodcastIndex, lp, "status %q is not pending, live or ended", live.Status)
+		}
+		if v.required(SpecPodcastIndex, lp, string(live.Start), "start") {
+			if _, _, err := live.Times(); err != nil {
+				v.report(SeverityError, SpecPodcastIndex, lp, "%s", strings.TrimPrefix(err.Error(), "podcast: "))
+			}
+		}
+		if live.Enclosure == nil {
+			v.report(SeverityError, SpecPodcastIndex, lp+".enclosure", "missing enclosure")
+		}
+	}
+
+	v.problems = append(v.problems, c.CheckNumbering()...)
+}
+
+func (v *validator) episode(p string, e *Episode) {
+	v.required(SpecApple, p+".title", e.Title, "title")
+
+	if e.Enclosure == nil {
+		v.report(SeverityError, SpecApple, p+".enclosure", "missing enclosure")
+	} else {
+		v.url(SpecApple, p+".enclosure", e.Enclosure.URL, "url")
+		if e.Enclosure.Length <= 0 {
+			v.report(SeverityError, SpecApple, p+".enclosure", "missing length in bytes")
+		}
+		if v.required(SpecApple, p+".enclosure", e.Enclosure.Type, "type") && !slices.Contains(AppleEnclosureTypes, e.Enclosure.Type) {
+			v.report(SeverityWarning, SpecApple, p+".enclosure", "type %q is not supported by Apple Podcasts", e.Enclosure.Type)
+		}
+	}
+
+	if e.GUID == nil || strings.TrimSpace(e.GUID.Value) == "" {
+		v.report(SeverityWarning, SpecApple, p+".guid", "missing GUID, so apps can't tell when an episode is new")
+	}
+	if e.PubDate == "" {
+		v.report(SeverityWarning, SpecApple, p+".pubDate", "missing publication date")
+	}
+	v.date(SpecRSS, p+".pubDate", e.PubDate)
+	if e.Description == nil || strings.TrimSpace(e.Description.Value) == "" {
+		v.report(SeverityWarning, SpecApple, p+".description", "missing description")
+	}
+
+	if e.ItunesDuration == "" {
+		v.report(SeverityWarning, SpecApple, p+".itunes:duration", "missing duration")
+	} else if _, err := e.Duration(); err != nil {
+		v.report(SeverityWarning, SpecApple, p+".itunes:duration", "duration %q should be in seconds, or HH:MM:SS", e.ItunesDuration)
+	}
+	if e.ItunesImage != nil {
+		v.url(SpecApple, p+".itunes:image", e.ItunesImage.Href, "artwork")
+		v.imageExtension(p+".itunes:image", e.ItunesImage.Href)
+	}
+	switch e.ItunesEpisodeType {
+	case "", FullEpisode, TrailerEpisode, BonusEpisode:
+	default:
+		v.report(SeverityError, SpecApple, p+".itunes:episodeType", "type %q is not full, trailer or bonus", e.ItunesEpisodeType)
+	}
+
+	for i, transcript := range e.PodcastTranscript {
+		tp := fmt.Sprintf("%s.podcast:transcript[%d]", p, i)
+		v.url(SpecPodcastIndex, tp, transcript.URL, "url")
+		v.required(SpecPodcastIndex, tp, transcript.Type, "type")
+	}
+	if e.PodcastChapters != nil {
+		v.url(SpecPodcastIndex, p+".podcast:chapters", e.PodcastChapters.URL, "url")
+		v.required(SpecPodcastIndex, p+".podcast:chapters", e.PodcastChapters.Type, "type")
+	}
+	for i, soundbite := range e.PodcastSoundbite {
+		if soundbite.Duration <= 0 {
+			v.report(SeverityError, SpecPodcastIndex, fmt.Sprintf("%s.podcast:soundbite[%d]", p, i), "duration must be positive")
+		}
+	}
+	for i, alternate := range e.PodcastAlternateEnclosures {
+		ap := fmt.Sprintf("%s.podcast:alternateEnclosure[%d]", p, i)
+		v.required(SpecPodcastIndex, ap, alternate.Type, "type")
+		if len(alternate.Source) == 0 {
+			v.report(SeverityError, SpecPodcastIndex, ap, "missing source")
+		}
+		for j, source := range alternate.Source {
+			v.required(SpecPodcastIndex, fmt.Sprintf("%s.podcast:source[%d]", ap, j), source.URI, "uri")
+		}
+	}
+	if e.PodcastLicense != nil {
+		v.license(p+".podcast:license", e.PodcastLicense)
+	}
+	for i, txt := range e.PodcastTxt {
+		v.txt(fmt.Sprintf("%s.podcast:txt[%d]", p, i), &txt)
+	}
+	for i, person := range e.PodcastPeople {
+		v.person(fmt.Sprintf("%s.podcast:person[%d]", p, i), &person)
+	}
+	for i, value := range e.PodcastValue {
+		v.value(fmt.Sprintf("%s.podcast:value[%d]", p, i), &value)
+	}
+	if e.PodcastImages != nil {
+		v.required(SpecPodcastIndex, p+".podcast:images", e.PodcastImages.Srcset, "srcset")
+	}
+}
+
+func (v *validator) date(spec, path string, date rss.RFC2822Date) {
+	if date == "" {
+		return
+	}
+	if _, err := date.Time(); err != nil {
+		v.report(SeverityError, spec, path, "date %q is not in RFC 2822 format", date)
+	}
+}
+
+func (v *validator) imageExtension(p, href string) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".jpg", ".jpeg", ".png", "":
+	default:
+		v.report(SeverityWarning, SpecApple, p, "artwork should be a JPEG or PNG file")
+	}
+}
+
+func (v *validator) podcastGUID(path, guid string) {
+	if guid == "" {
+		v.report(SeverityWarning, SpecPodcastIndex, path, "missing podcast GUID, see GUIDFromFeedURL")
+		return
+	}
+	if _, err := uuid.Parse(guid); err != nil || guid[14] != '5' {
+		v.report(SeverityWarning, SpecPodcastIndex, path, "GUID %q should be a version 5 UUID", guid)
+	}
+}
+
+func (v *validator) yesOrNo(path string, value YesOrNo) {
+	if value != Yes && value != No {
+		v.report(SeverityError, SpecPodcastIndex, path, "value %q is not yes or no", value)
+	}
+}
+
+func (v *validator) license(path string, license *PodcastLicense) {
+	if v.required(SpecPodcastIndex, path, license.LicenseID, "license identifier") {
+		v.maxLength(path, license.LicenseID, 128)
+	}
+}
+
+func (v *validator) txt(path string, txt *PodcastTxt) {
+	if n := utf8.RuneCountInString(txt.Value); n > 4000 {
+		v.report(SeverityError, SpecPodcastIndex, path, "is %d characters, more than the limit of 4000", n)
+	}
+}
+
+func (v *validator) person(path string, person *PodcastPerson) {
+	if v.required(SpecPodcastIndex, path, person.PersonName, "name") {
+		v.maxLength(path, person.PersonName, 128)
+	}
+}
+
+func (v *validator) value(path string, value *PodcastValue) {
+	v.required(SpecPodcastIndex, path, value.Type, "type")
+	v.required(SpecPodcastIndex, path, value.Method, "method")
+	if len(value.Recipients) == 0 {
+		v.report(SeverityError, SpecPodcastIndex, path, "missing valueRecipient")
+	}
+	for i, recipient := range value.Recipients {
+		rp := fmt.Sprintf("%s.podcast:valueRecipient[%d]", path, i)
+		v.required(SpecPodcastIndex, rp, recipient.Type, "type")
+		v.required(SpecPodcastIndex, rp, recipient.Address, "address")
+		v.required(SpecPodcastIndex, rp, recipient.Split, "split")
+	}
+}
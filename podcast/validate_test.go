@@ -0,0 +1,188 @@
+package podcast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func validFeed() *RSSPodcast {
+	explicit := false
+	isPermaLink := false
+	return &RSSPodcast{
+		Version: rss.RSSVersion,
+		Channel: Podcast{
+			Channel: rss.Channel{
+				Title:       "The Go Podcast",
+				Link:        "https://example.com",
+				Description: rss.Description{Value: "A show about Go."},
+				Language:    "en",
+				PubDate:     "Mon, 01 Jan 2024 00:00:00 +0000",
+			},
+			ItunesImage:    ItunesImageTag{Href: "https://example.com/cover.jpg"},
+			ItunesCategory: []ItunesCategory{{Text: "Technology"}},
+			ItunesExplicit: &explicit,
+			ItunesAuthor:   "Jayden",
+			PodcastGUID:    "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+			PodcastLocked:  &PodcastLocked{Value: Yes},
+			PodcastFunding: []PodcastFunding{{Value: "Donate", URL: "https://example.com/donate"}},
+			Items: []Episode{
+				{
+					Item: rss.Item{
+						Title:       "Episode 1",
+						Description: &rss.Description{Value: "The first one."},
+						Enclosure:   &rss.Enclosure{URL: "https://example.com/1.mp3", Length: 1000, Type: "audio/mpeg"},
+						GUID:        &rss.GUID{Value: "episode-1", IsPermaLink: &isPermaLink},
+						PubDate:     "Mon, 01 Jan 2024 00:00:00 +0000",
+					},
+					ItunesDuration:    "01:02:03",
+					ItunesEpisodeType: FullEpisode,
+					PodcastTranscript: []PodcastTranscript{{URL: "https://example.com/1.vtt", Type: "text/vtt"}},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	if problems := Validate(validFeed()); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		modify   func(feed *RSSPodcast)
+		expected []Problem
+	}{
+		{
+			"missing required channel tags",
+			func(feed *RSSPodcast) {
+				feed.Channel.Title = " "
+				feed.Channel.Language = ""
+				feed.Channel.ItunesCategory = nil
+				feed.Channel.ItunesExplicit = nil
+			},
+			[]Problem{
+				{SeverityError, SpecApple, "channel.title", "missing title"},
+				{SeverityError, SpecApple, "channel.language", "missing language"},
+				{SeverityError, SpecApple, "channel.itunes:category", "missing category"},
+				{SeverityError, SpecApple, "channel.itunes:explicit", "missing explicit flag"},
+			},
+		},
+		{
+			"bad artwork",
+			func(feed *RSSPodcast) {
+				feed.Channel.ItunesImage.Href = "ftp://example.com/cover.gif"
+			},
+			[]Problem{
+				{SeverityError, SpecApple, "channel.itunes:image", `artwork "ftp://example.com/cover.gif" is not an http or https URL`},
+				{SeverityWarning, SpecApple, "channel.itunes:image", "artwork should be a JPEG or PNG file"},
+			},
+		},
+		{
+			"podcast namespace",
+			func(feed *RSSPodcast) {
+				feed.Channel.PodcastGUID = "https://example.com/feed.xml"
+				feed.Channel.PodcastLocked.Value = "true"
+				feed.Channel.PodcastFunding[0].URL = ""
+				feed.Channel.PodcastMedium = "radio"
+				feed.Channel.PodcastValue = []PodcastValue{{Type: "lightning"}}
+				feed.Channel.PodcastUpdateFrequency = &PodcastUpdateFrequency{UpdateFrequencyText: "Daily", Rrule: "FREQ=DAILY;COUNT=10"}
+			},
+			[]Problem{
+				{SeverityWarning, SpecPodcastIndex, "channel.podcast:guid", `GUID "https://example.com/feed.xml" should be a version 5 UUID`},
+				{SeverityError, SpecPodcastIndex, "channel.podcast:locked", `value "true" is not yes or no`},
+				{SeverityError, SpecPodcastIndex, "channel.podcast:funding[0]", "missing url"},
+				{SeverityWarning, SpecPodcastIndex, "channel.podcast:medium", `unknown medium "radio"`},
+				{SeverityError, SpecPodcastIndex, "channel.podcast:value[0]", "missing method"},
+				{SeverityError, SpecPodcastIndex, "channel.podcast:value[0]", "missing valueRecipient"},
+				{SeverityError, SpecPodcastIndex, "channel.podcast:updateFrequency", `rrule "FREQ=DAILY;COUNT=10" has a COUNT but there is no dtstart`},
+			},
+		},
+		{
+			"bad episode",
+			func(feed *RSSPodcast) {
+				item := &feed.Channel.Items[0]
+				item.Enclosure = &rss.Enclosure{URL: "/1.ogg", Type: "audio/ogg"}
+				item.GUID = nil
+				item.PubDate = "2024-01-01"
+				item.ItunesDuration = "1h"
+				item.ItunesEpisodeType = "extra"
+				item.PodcastTranscript[0].Type = ""
+			},
+			[]Problem{
+				{SeverityError, SpecApple, "channel.item[0].enclosure", `url "/1.ogg" is not an http or https URL`},
+				{SeverityError, SpecApple, "channel.item[0].enclosure", "missing length in bytes"},
+				{SeverityWarning, SpecApple, "channel.item[0].enclosure", `type "audio/ogg" is not supported by Apple Podcasts`},
+				{SeverityWarning, SpecApple, "channel.item[0].guid", "missing GUID, so apps can't tell when an episode is new"},
+				{SeverityError, SpecRSS, "channel.item[0].pubDate", `date "2024-01-01" is not in RFC 2822 format`},
+				{SeverityWarning, SpecApple, "channel.item[0].itunes:duration", `duration "1h" should be in seconds, or HH:MM:SS`},
+				{SeverityError, SpecApple, "channel.item[0].itunes:episodeType", `type "extra" is not full, trailer or bonus`},
+				{SeverityError, SpecPodcastIndex, "channel.item[0].podcast:transcript[0]", "missing type"},
+			},
+		},
+		{
+			"duplicates",
+			func(feed *RSSPodcast) {
+				feed.Channel.ItunesType = ItunesShowTypeSerial
+				feed.Channel.Items[0].ItunesEpisode = 1
+				feed.Channel.Items = append(feed.Channel.Items, feed.Channel.Items[0])
+				feed.Channel.Items[1].ItunesEpisode = 0
+			},
+			[]Problem{
+				{SeverityWarning, SpecApple, "channel.item[1].itunes:episode", "missing episode number, which serial shows should have"},
+				{SeverityError, SpecRSS, "channel.item[1].guid", `GUID "episode-1" is also used by item[0]`},
+				{SeverityWarning, SpecApple, "channel.item[1].enclosure", "enclosure URL is also used by item[0]"},
+			},
+		},
+		{
+			"live items",
+			func(feed *RSSPodcast) {
+				feed.Channel.PodcastLiveItem = []PodcastLiveItem{
+					{Status: "started", Start: "2024-03-04T18:00:00Z"},
+					{Status: StatusEnded, Start: "2024-03-04T18:00:00Z", End: "2024-03-04T17:00:00Z"},
+				}
+				for i := range feed.Channel.PodcastLiveItem {
+					feed.Channel.PodcastLiveItem[i].Enclosure = &rss.Enclosure{URL: "https://example.com/stream"}
+				}
+			},
+			[]Problem{
+				{SeverityError, SpecPodcastIndex, "channel.podcast:liveItem[0]", `status "started" is not pending, live or ended`},
+				{SeverityError, SpecPodcastIndex, "channel.podcast:liveItem[1]", "live item ends at 2024-03-04T17:00:00Z, before it starts at 2024-03-04T18:00:00Z"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			feed := validFeed()
+			tc.modify(feed)
+			if diff := cmp.Diff(tc.expected, Validate(feed)); diff != "" {
+				t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseItunesDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"":         0,
+		"3723":     time.Hour + 2*time.Minute + 3*time.Second,
+		"62:03":    time.Hour + 2*time.Minute + 3*time.Second,
+		"01:02:03": time.Hour + 2*time.Minute + 3*time.Second,
+		"1.5":      1500 * time.Millisecond,
+	} {
+		if actual, err := ParseItunesDuration(value); err != nil || actual != expected {
+			t.Errorf("ParseItunesDuration(%q) = %v, %v, expected %v", value, actual, err, expected)
+		}
+	}
+	for _, value := range []string{"1h", "1:60", "1:2:3:4", "-5", "1::2", "NaN", "Inf", "1e9", "0x10", "1.5:00", ".5"} {
+		if _, err := ParseItunesDuration(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
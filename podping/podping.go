@@ -0,0 +1,260 @@
+// Package podping tells podcast apps and directories that feeds changed, by
+// sending podpings through a podping.cloud compatible HTTP endpoint.
+//
+// See [podping.cloud]
+//
+// [podping.cloud]: https://github.com/Podcastindex-org/podping.cloud
+package podping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// Defaults for the zero [Client]
+const (
+	DefaultEndpoint   = "https://podping.cloud/"
+	DefaultUserAgent  = "podcast-podping/1.0 (+https://github.com/jaydenmilne/podcast)"
+	DefaultBatchDelay = 3 * time.Second
+	DefaultRetries    = 5
+	DefaultRetryDelay = time.Second
+)
+
+// Notification is a podping for a feed
+type Notification struct {
+	URL    string
+	Reason podcast.PodpingReason
+	Medium podcast.PodcastMedium
+}
+
+// NotificationFor is the notification for the feed at feedURL, with the
+// medium from the channel's podcast:medium, defaulting to podcast
+func NotificationFor(feedURL string, channel *podcast.Podcast, reason podcast.PodpingReason) Notification {
+	n := Notification{URL: feedURL, Reason: reason, Medium: podcast.MediumPodcast}
+	if channel != nil && channel.PodcastMedium != "" {
+		n.Medium = channel.PodcastMedium
+	}
+	if n.Reason == "" {
+		n.Reason = podcast.PodpingReasonUpdate
+	}
+	return n
+}
+
+// StatusError is returned when the endpoint rejects a podping
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "podping: " + e.Status
+}
+
+// temporary reports if sending again might work
+func (e *StatusError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Client sends podpings. [Client.Send] sends one right away, while
+// [Client.Notify] queues them for [Client.Run], which waits for updates to
+// settle and sends each queued podping once. The zero value sends to
+// [DefaultEndpoint], which needs a Token.
+type Client struct {
+	// Endpoint is the URL of the podping.cloud compatible server. Defaults
+	// to [DefaultEndpoint].
+	Endpoint string
+
+	// Token authorizes the podpings, and is sent as the Authorization header
+	Token string
+
+	// HTTPClient defaults to [http.DefaultClient]
+	HTTPClient *http.Client
+
+	// UserAgent defaults to [DefaultUserAgent]
+	UserAgent string
+
+	// BatchDelay is how long Run waits after a notification for more,
+	// so a burst of updates to a feed sends one podping. Defaults to
+	// [DefaultBatchDelay].
+	BatchDelay time.Duration
+
+	// Retries is how many times a podping is sent again after a network
+	// error or a 429 or 5xx response. Defaults to [DefaultRetries]; use a
+	// negative number for none.
+	Retries int
+
+	// RetryDelay is how long to wait before the first retry, which doubles
+	// for each one after. Defaults to [DefaultRetryDelay].
+	RetryDelay time.Duration
+
+	// OnError, if set, is called by Run for podpings that couldn't be sent
+	OnError func(Notification, error)
+
+	mu      sync.Mutex
+	pending []Notification
+	queued  map[Notification]bool
+	wake    chan struct{}
+}
+
+// Notify queues a podping for [Client.Run] to send. Notifications that are
+// already queued are dropped.
+func (c *Client) Notify(n Notification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queued == nil {
+		c.queued = map[Notification]bool{}
+	}
+	if c.queued[n] {
+		return
+	}
+	c.queued[n] = true
+	c.pending = append(c.pending, n)
+	select {
+	case c.wakeChannel() <- struct{}{}:
+	default:
+	}
+}
+
+// wakeChannel must be called with c.mu held
+func (c *Client) wakeChannel() chan struct{} {
+	if c.wake == nil {
+		c.wake = make(chan struct{}, 1)
+	}
+	return c.wake
+}
+
+// take removes the queued notifications
+func (c *Client) take() []Notification {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	batch := c.pending
+	c.pending, c.queued = nil, nil
+	return batch
+}
+
+// Run sends queued podpings, a batch delay after the first of each burst,
+// until ctx is done. Run must only be called once at a time.
+func (c *Client) Run(ctx context.Context) error {
+	delay := c.BatchDelay
+	if delay <= 0 {
+		delay = DefaultBatchDelay
+	}
+	c.mu.Lock()
+	wake := c.wakeChannel()
+	c.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		for _, n := range c.take() {
+			if err := c.Send(ctx, n); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if c.OnError != nil {
+					c.OnError(n, err)
+				}
+			}
+		}
+	}
+}
+
+// Flush sends the queued podpings now, such as before shutting down
+func (c *Client) Flush(ctx context.Context) error {
+	var errs []error
+	for _, n := range c.take() {
+		if err := c.Send(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Send sends a podping now, retrying temporary failures
+func (c *Client) Send(ctx context.Context, n Notification) error {
+	retries := c.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	delay := c.RetryDelay
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, n)
+		var status *StatusError
+		if err == nil || errors.As(err, &status) && !status.temporary() || attempt >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay << attempt):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, n Notification) error {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("podping: %w", err)
+	}
+	query := u.Query()
+	query.Set("url", n.URL)
+	if n.Reason != "" {
+		query.Set("reason", string(n.Reason))
+	}
+	if n.Medium != "" {
+		query.Set("medium", string(n.Medium))
+	}
+	u.RawQuery = query.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("podping: %w", err)
+	}
+	if c.Token != "" {
+		request.Header.Set("Authorization", c.Token)
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	request.Header.Set("User-Agent", userAgent)
+
+	client := http.DefaultClient
+	if c.HTTPClient != nil {
+		client = c.HTTPClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("podping: %w", err)
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 1<<16))
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: response.StatusCode, Status: response.Status}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package podping_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/podping"
+	"github.com/jaydenmilne/podcast/podping/podpingtest"
+)
+
+func TestSend(t *testing.T) {
+	server := podpingtest.NewServer("secret")
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	channel := &podcast.Podcast{PodcastMedium: podcast.MediumMusic}
+	if err := client.Send(ctx, podping.NotificationFor("https://example.com/feed.xml", channel, podcast.PodpingReasonLive)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Temporary failures are retried
+	server.FailNext(2)
+	if err := client.Send(ctx, podping.NotificationFor("https://example.com/other.xml", nil, "")); err != nil {
+		t.Fatal(err)
+	}
+	server.FailNext(2)
+	client.Retries = 1
+	var status *podping.StatusError
+	if err := client.Send(ctx, podping.NotificationFor("https://example.com/failed.xml", nil, "")); !errors.As(err, &status) || status.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected to give up after a retry, got %v", err)
+	}
+	server.FailNext(0)
+
+	client.Token = "wrong"
+	if err := client.Send(ctx, podping.NotificationFor("https://example.com/feed.xml", nil, "")); !errors.As(err, &status) || status.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+
+	expected := []podping.Notification{
+		{"https://example.com/feed.xml", podcast.PodpingReasonLive, podcast.MediumMusic},
+		{"https://example.com/other.xml", podcast.PodpingReasonUpdate, podcast.MediumPodcast},
+	}
+	if diff := cmp.Diff(expected, server.Pings()); diff != "" {
+		t.Errorf("unexpected podpings (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	server := podpingtest.NewServer("")
+	defer server.Close()
+	client := server.Client()
+	client.BatchDelay = 20 * time.Millisecond
+	errs := make(chan error, 1)
+	client.OnError = func(n podping.Notification, err error) { errs <- err }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- client.Run(ctx) }()
+
+	update := podping.NotificationFor("https://example.com/feed.xml", nil, "")
+	live := podping.NotificationFor("https://example.com/feed.xml", nil, podcast.PodpingReasonLive)
+	for i := 0; i < 5; i++ {
+		client.Notify(update)
+	}
+	client.Notify(live)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Pings()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected Run to stop with the context, got %v", err)
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("unexpected error %v", err)
+	default:
+	}
+
+	if diff := cmp.Diff([]podping.Notification{update, live}, server.Pings()); diff != "" {
+		t.Errorf("expected the burst to be sent once (-want +got):\n%s", diff)
+	}
+
+	client.Notify(update)
+	if err := client.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Pings()); n != 3 {
+		t.Errorf("expected Flush to send the queued podping, got %d podpings", n)
+	}
+}
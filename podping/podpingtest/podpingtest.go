@@ -0,0 +1,84 @@
+// Package podpingtest provides an in-process podping.cloud stand-in for tests
+// of code that sends podpings.
+package podpingtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/podping"
+)
+
+// Server records the podpings sent to it. Point [podping.Client.Endpoint] at
+// its URL.
+type Server struct {
+	*httptest.Server
+
+	// Token is the Authorization header podpings must have, if set
+	Token string
+
+	mu    sync.Mutex
+	pings []podping.Notification
+	fail  int
+}
+
+// NewServer starts a server that accepts podpings with the token, or any
+// podpings if it's empty. Close it when done.
+func NewServer(token string) *Server {
+	s := &Server{Token: token}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client is a podping client for the server, which retries without delay
+func (s *Server) Client() *podping.Client {
+	return &podping.Client{
+		Endpoint:   s.URL,
+		Token:      s.Token,
+		HTTPClient: s.Server.Client(),
+		RetryDelay: 1,
+	}
+}
+
+// Pings are the podpings received, in order
+func (s *Server) Pings() []podping.Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]podping.Notification(nil), s.pings...)
+}
+
+// FailNext makes the next n podpings fail with 503 Service Unavailable
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = n
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Token != "" && r.Header.Get("Authorization") != s.Token {
+		http.Error(w, "Invalid Authorization header", http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("url") == "" {
+		http.Error(w, "Invalid url", http.StatusBadRequest)
+		return
+	}
+	if s.fail > 0 {
+		s.fail--
+		http.Error(w, "Try again", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.pings = append(s.pings, podping.Notification{
+		URL:    query.Get("url"),
+		Reason: podcast.PodpingReason(query.Get("reason")),
+		Medium: podcast.PodcastMedium(query.Get("medium")),
+	})
+	w.Write([]byte("Success!"))
+}
@@ -0,0 +1,428 @@
+// Package privatefeed gives each subscriber of a private or premium podcast
+// their own feed URL. Tokens signed with HMAC-SHA256 identify the subscriber
+// in the URL of the feed and its media, so they can be checked without a
+// database, expire, and be revoked.
+package privatefeed
+
+import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// Errors returned by [Signer.Verify]
+var (
+	ErrInvalidToken = errors.New("privatefeed: invalid token")
+	ErrExpiredToken = errors.New("privatefeed: token expired")
+	ErrRevokedToken = errors.New("privatefeed: token revoked")
+)
+
+// Token identifies a subscriber's personal feed
+type Token struct {
+	Subscriber string
+	Issued     time.Time
+
+	// Expires is when the token stops working, if it's not zero
+	Expires time.Time
+}
+
+// Signer signs and verifies tokens
+type Signer struct {
+	// Key (required) is the secret tokens are signed with
+	Key []byte
+
+	// Param is the query parameter tokens are added to URLs as. Defaults to
+	// "token".
+	Param string
+
+	// Revoked reports whether a token was revoked, such as with
+	// [Revocations.Revoked]
+	Revoked func(Token) bool
+
+	// Now returns the current time. Defaults to [time.Now].
+	Now func() time.Time
+}
+
+func (s *Signer) param() string {
+	if s.Param == "" {
+		return "token"
+	}
+	return s.Param
+}
+
+func (s *Signer) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Issue signs a token for the subscriber, issued now. The token expires
+// after lifetime, or never if it's zero.
+func (s *Signer) Issue(subscriber string, lifetime time.Duration) string {
+	token := Token{Subscriber: subscriber, Issued: s.now()}
+	if lifetime > 0 {
+		token.Expires = token.Issued.Add(lifetime)
+	}
+	return s.Sign(token)
+}
+
+// Sign encodes the token and its signature for use in URLs. Times are kept
+// to the second.
+func (s *Signer) Sign(token Token) string {
+	var expires int64
+	if !token.Expires.IsZero() {
+		expires = token.Expires.Unix()
+	}
+	payload := strconv.FormatInt(token.Issued.Unix(), 10) + "." + strconv.FormatInt(expires, 10) + "." + token.Subscriber
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded))
+}
+
+func (s *Signer) mac(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// Verify checks the signature of the token, and that it hasn't expired or
+// been revoked
+func (s *Signer) Verify(signed string) (Token, error) {
+	if len(s.Key) == 0 {
+		return Token{}, errors.New("privatefeed: signer has no key")
+	}
+	encoded, signature, ok := strings.Cut(signed, ".")
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if !ok || err != nil || !hmac.Equal(mac, s.mac(encoded)) {
+		return Token{}, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Token{}, ErrInvalidToken
+	}
+	fields := strings.SplitN(string(payload), ".", 3)
+	if len(fields) != 3 {
+		return Token{}, ErrInvalidToken
+	}
+	issued, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Token{}, ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return Token{}, ErrInvalidToken
+	}
+
+	token := Token{Subscriber: fields[2], Issued: time.Unix(issued, 0)}
+	if expires != 0 {
+		token.Expires = time.Unix(expires, 0)
+		if !s.now().Before(token.Expires) {
+			return token, ErrExpiredToken
+		}
+	}
+	if s.Revoked != nil && s.Revoked(token) {
+		return token, ErrRevokedToken
+	}
+	return token, nil
+}
+
+// Revocations is a revocation list of subscribers' tokens. It's safe for
+// concurrent use.
+type Revocations struct {
+	mu sync.Mutex
+	// revoked is when each subscriber's tokens were last revoked
+	revoked map[string]time.Time
+}
+
+// Revoke revokes the subscriber's tokens issued before at. Tokens issued
+// later, such as a replacement for a leaked feed URL, still work. Since
+// tokens keep their issue time to the second, at is too, so a replacement
+// issued in the same second as the revocation works.
+func (r *Revocations) Revoke(subscriber string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.revoked == nil {
+		r.revoked = map[string]time.Time{}
+	}
+	r.revoked[subscriber] = at.Truncate(time.Second)
+}
+
+// Restore undoes revoking the subscriber's tokens
+func (r *Revocations) Restore(subscriber string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.revoked, subscriber)
+}
+
+// Revoked reports whether the token was revoked, for [Signer.Revoked]
+func (r *Revocations) Revoked(token Token) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.revoked[token.Subscriber]
+	return ok && token.Issued.Before(at)
+}
+
+// Personalize returns a copy of the feed for the holder of the signed token.
+// The token is added to the URLs of the feed's self link and of its media
+// served over HTTP, including live streams, so each subscriber's feed is only
+// theirs. The copy asks apps and directories not to list it, and locks it
+// against being imported elsewhere. Its WebSub hubs and rssCloud are removed,
+// since they'd share the feed's URL with others. The original feed isn't
+// changed.
+func (s *Signer) Personalize(feed *podcast.RSSPodcast, signed string) *podcast.RSSPodcast {
+	withToken := func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return raw
+		}
+		query := u.Query()
+		query.Set(s.param(), signed)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	personal := *feed
+	channel := &personal.Channel
+	channel.Cloud = nil
+	channel.AtomLinks = slices.DeleteFunc(slices.Clone(channel.AtomLinks), func(link podcast.AtomLink) bool {
+		return link.Rel == podcast.RelHub
+	})
+	for i := range channel.AtomLinks {
+		if channel.AtomLinks[i].Rel == podcast.RelSelf {
+			channel.AtomLinks[i].Href = withToken(channel.AtomLinks[i].Href)
+		}
+	}
+	channel.ItunesBlock = podcast.ItunesYesValue
+	channel.PodcastBlock = []podcast.PodcastBlock{{Value: podcast.Yes}}
+	locked := &podcast.PodcastLocked{Value: podcast.Yes}
+	if channel.PodcastLocked != nil {
+		locked.Owner = channel.PodcastLocked.Owner
+	}
+	channel.PodcastLocked = locked
+
+	channel.PodcastTrailers = slices.Clone(channel.PodcastTrailers)
+	for i := range channel.PodcastTrailers {
+		channel.PodcastTrailers[i].URL = withToken(channel.PodcastTrailers[i].URL)
+	}
+	channel.Items = slices.Clone(channel.Items)
+	for i := range channel.Items {
+		personalizeEpisode(&channel.Items[i], withToken)
+	}
+	channel.PodcastLiveItem = slices.Clone(channel.PodcastLiveItem)
+	for i := range channel.PodcastLiveItem {
+		personalizeEpisode(&channel.PodcastLiveItem[i].Episode, withToken)
+	}
+	return &personal
+}
+
+// personalizeEpisode adds the token to the URLs of a copy of the episode's
+// enclosures, leaving the original's alone
+func personalizeEpisode(episode *podcast.Episode, withToken func(string) string) {
+	if episode.Enclosure != nil {
+		enclosure := *episode.Enclosure
+		enclosure.URL = withToken(enclosure.URL)
+		episode.Enclosure = &enclosure
+	}
+	episode.PodcastAlternateEnclosures = slices.Clone(episode.PodcastAlternateEnclosures)
+	for j := range episode.PodcastAlternateEnclosures {
+		alternate := &episode.PodcastAlternateEnclosures[j]
+		alternate.Source = slices.Clone(alternate.Source)
+		for k := range alternate.Source {
+			alternate.Source[k].URI = withToken(alternate.Source[k].URI)
+		}
+	}
+}
+
+type contextKey struct{}
+
+// FromContext returns the token of a request let through by
+// [Signer.Middleware] or served by a [Handler]
+func FromContext(ctx context.Context) (Token, bool) {
+	token, ok := ctx.Value(contextKey{}).(Token)
+	return token, ok
+}
+
+// verifyRequest checks the token in the request's query. It answers the
+// request itself if the token isn't valid.
+func (s *Signer) verifyRequest(w http.ResponseWriter, r *http.Request) (string, *http.Request, bool) {
+	signed := r.URL.Query().Get(s.param())
+	token, err := s.Verify(signed)
+	switch {
+	case signed == "":
+		http.Error(w, "missing token", http.StatusUnauthorized)
+		return "", nil, false
+	case errors.Is(err, ErrExpiredToken), errors.Is(err, ErrRevokedToken):
+		http.Error(w, strings.TrimPrefix(err.Error(), "privatefeed: "), http.StatusForbidden)
+		return "", nil, false
+	case err != nil:
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return "", nil, false
+	}
+	return signed, r.WithContext(context.WithValue(r.Context(), contextKey{}, token)), true
+}
+
+// Middleware only lets requests with a valid token through to next, such as
+// requests for a private feed's media. Use [FromContext] to find out whose
+// token it is.
+func (s *Signer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, r, ok := s.verifyRequest(w, r); ok {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// DefaultMaxFeeds is how many personalized feeds a [Handler] keeps, unless
+// set
+const DefaultMaxFeeds = 1000
+
+// Handler is an [http.Handler] serving personalized copies of the feed from
+// Source, as [podcast.Handler] does, to requests with a valid token. The
+// encoded feeds of the most recently served tokens are kept until Source
+// reports a change, so polling doesn't personalize and encode the feed again.
+type Handler struct {
+	Signer *Signer
+	Source podcast.FeedSource
+
+	// CacheControl sets a Cache-Control max-age from the feed's TTL, as for
+	// [podcast.Handler]
+	CacheControl bool
+
+	// MaxFeeds is how many tokens' encoded feeds are kept. The least
+	// recently served are dropped first. Defaults to [DefaultMaxFeeds].
+	MaxFeeds int
+
+	mu   sync.Mutex
+	base *podcast.RSSPodcast
+	// generation counts the changes to base
+	generation int
+	// feeds are the personalized feeds of base, by token, and recent orders
+	// them from the most recently served
+	feeds  map[string]*list.Element
+	recent *list.List
+}
+
+// personalFeed is a token's copy of the feed
+type personalFeed struct {
+	signed     string
+	expires    time.Time
+	handler    *podcast.Handler
+	feed       *podcast.RSSPodcast
+	generation int
+}
+
+func (h *Handler) maxFeeds() int {
+	if h.MaxFeeds <= 0 {
+		return DefaultMaxFeeds
+	}
+	return h.MaxFeeds
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	signed, r, ok := h.Signer.verifyRequest(w, r)
+	if !ok {
+		return
+	}
+	token, _ := FromContext(r.Context())
+	h.personalFeed(signed, token).handler.ServeHTTP(w, r)
+}
+
+// personalFeed returns the token's feed, personalized when it's first served
+// and when Source reports a change
+func (h *Handler) personalFeed(signed string, token Token) *personalFeed {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if element, ok := h.feeds[signed]; ok {
+		h.recent.MoveToFront(element)
+		return element.Value.(*personalFeed)
+	}
+	personal := &personalFeed{signed: signed, expires: token.Expires}
+	personal.handler = &podcast.Handler{
+		CacheControl: h.CacheControl,
+		Source: func(ctx context.Context) (*podcast.RSSPodcast, bool, error) {
+			base, generation, err := h.baseFeed(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			changed := generation != personal.generation
+			if changed {
+				personal.feed = h.Signer.Personalize(base, signed)
+				personal.generation = generation
+				// Keep it, in case a change dropped it
+				h.keep(personal)
+			}
+			return personal.feed, changed, nil
+		},
+	}
+	h.keep(personal)
+	return personal
+}
+
+// keep adds the feed to the kept feeds if it isn't there, making room by
+// dropping the feeds of expired tokens, then the least recently served. h.mu
+// must be held.
+func (h *Handler) keep(personal *personalFeed) {
+	if h.feeds == nil {
+		h.feeds = map[string]*list.Element{}
+		h.recent = list.New()
+	}
+	if _, ok := h.feeds[personal.signed]; ok {
+		return
+	}
+	now := h.Signer.now()
+	for element := h.recent.Front(); element != nil; {
+		next := element.Next()
+		if kept := element.Value.(*personalFeed); !kept.expires.IsZero() && !now.Before(kept.expires) {
+			h.drop(element)
+		}
+		element = next
+	}
+	for h.recent.Len() >= h.maxFeeds() {
+		h.drop(h.recent.Back())
+	}
+	h.feeds[personal.signed] = h.recent.PushFront(personal)
+}
+
+func (h *Handler) drop(element *list.Element) {
+	delete(h.feeds, element.Value.(*personalFeed).signed)
+	h.recent.Remove(element)
+}
+
+// baseFeed returns the latest feed from Source, and how many times it
+// changed. The personalized feeds of earlier versions are dropped when it
+// changes.
+func (h *Handler) baseFeed(ctx context.Context) (*podcast.RSSPodcast, int, error) {
+	if h.Source == nil {
+		return nil, 0, fmt.Errorf("privatefeed: handler has no source")
+	}
+	feed, changed, err := h.Source(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if changed || h.base == nil {
+		if feed == nil {
+			return nil, 0, fmt.Errorf("privatefeed: source returned no feed")
+		}
+		h.base = feed
+		h.generation++
+		h.feeds, h.recent = nil, nil
+	}
+	return h.base, h.generation, nil
+}
@@ -0,0 +1,249 @@
+package privatefeed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	revocations := &Revocations{}
+	signer := &Signer{
+		Key:     []byte("secret"),
+		Revoked: revocations.Revoked,
+		Now:     func() time.Time { return now },
+	}
+	forever := signer.Issue("alice", 0)
+	month := signer.Issue("bob", 30*24*time.Hour)
+	other := &Signer{Key: []byte("other")}
+	// mallory's token with bob's signature
+	payload, _, _ := strings.Cut(signer.Issue("mallory", 0), ".")
+	_, signature, _ := strings.Cut(month, ".")
+	tampered := payload + "." + signature
+
+	token, err := signer.Verify(month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Token{Subscriber: "bob", Issued: now, Expires: now.Add(30 * 24 * time.Hour)}
+	if diff := cmp.Diff(expected, token, cmp.Comparer(time.Time.Equal)); diff != "" {
+		t.Errorf("unexpected token (-want +got):\n%s", diff)
+	}
+
+	testCases := []struct {
+		name     string
+		signed   string
+		expected error
+	}{
+		{"forever", forever, nil},
+		{"empty", "", ErrInvalidToken},
+		{"garbage", "not.a-token", ErrInvalidToken},
+		{"other key", other.Issue("alice", 0), ErrInvalidToken},
+		{"tampered", tampered, ErrInvalidToken},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := signer.Verify(tc.signed); !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	now = now.Add(31 * 24 * time.Hour)
+	if _, err := signer.Verify(month); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expected the token to have expired, got %v", err)
+	}
+
+	// A replacement issued in the same second as the revocation works
+	now = now.Add(500 * time.Millisecond)
+	revocations.Revoke("alice", now)
+	if _, err := signer.Verify(forever); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("expected the token to be revoked, got %v", err)
+	}
+	now = now.Add(100 * time.Millisecond)
+	if _, err := signer.Verify(signer.Issue("alice", 0)); err != nil {
+		t.Errorf("expected a new token to work, got %v", err)
+	}
+	revocations.Restore("alice")
+	if _, err := signer.Verify(forever); err != nil {
+		t.Errorf("expected the token to be restored, got %v", err)
+	}
+}
+
+func testFeed() *podcast.RSSPodcast {
+	return &podcast.RSSPodcast{Version: "2.0", Channel: podcast.Podcast{
+		Channel: rss.Channel{Title: "Premium", Cloud: &rss.Cloud{Domain: "rpc.example.com"}},
+		AtomLinks: []podcast.AtomLink{
+			{Rel: podcast.RelSelf, Href: "https://example.com/feed.xml?format=rss"},
+			{Rel: podcast.RelHub, Href: "https://hub.example.com/"},
+		},
+		PodcastLocked:   &podcast.PodcastLocked{Value: podcast.No, Owner: "owner@example.com"},
+		PodcastTrailers: []podcast.PodcastTrailer{{URL: "https://example.com/trailer.mp3"}},
+		PodcastLiveItem: []podcast.PodcastLiveItem{{
+			Episode: podcast.Episode{Item: rss.Item{Title: "Live", Enclosure: &rss.Enclosure{URL: "https://example.com/live.mp3"}}},
+			Status:  podcast.StatusPending,
+		}},
+		Items: []podcast.Episode{{
+			Item: rss.Item{Title: "One", Enclosure: &rss.Enclosure{URL: "https://example.com/1.mp3"}},
+			PodcastAlternateEnclosures: []podcast.PodcastAlternateEnclosure{{
+				Type: "audio/mpeg",
+				Source: []podcast.PodcastSource{
+					{URI: "https://cdn.example.com/1.mp3"},
+					{URI: "ipfs://QmExample"},
+				},
+			}},
+		}},
+	}}
+}
+
+func TestPersonalize(t *testing.T) {
+	signer := &Signer{Key: []byte("secret")}
+	feed := testFeed()
+	personal := signer.Personalize(feed, "abc")
+
+	expected := testFeed()
+	expected.Channel.Cloud = nil
+	expected.Channel.AtomLinks = []podcast.AtomLink{{Rel: podcast.RelSelf, Href: "https://example.com/feed.xml?format=rss&token=abc"}}
+	expected.Channel.ItunesBlock = podcast.ItunesYesValue
+	expected.Channel.PodcastBlock = []podcast.PodcastBlock{{Value: podcast.Yes}}
+	expected.Channel.PodcastLocked = &podcast.PodcastLocked{Value: podcast.Yes, Owner: "owner@example.com"}
+	expected.Channel.PodcastTrailers[0].URL = "https://example.com/trailer.mp3?token=abc"
+	expected.Channel.PodcastLiveItem[0].Enclosure.URL = "https://example.com/live.mp3?token=abc"
+	expected.Channel.Items[0].Enclosure.URL = "https://example.com/1.mp3?token=abc"
+	expected.Channel.Items[0].PodcastAlternateEnclosures[0].Source[0].URI = "https://cdn.example.com/1.mp3?token=abc"
+	if diff := cmp.Diff(expected, personal); diff != "" {
+		t.Errorf("unexpected personalized feed (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(testFeed(), feed); diff != "" {
+		t.Errorf("the original feed changed (-want +got):\n%s", diff)
+	}
+}
+
+// cachedFeed returns the feed the handler keeps for the token, or nil
+func cachedFeed(handler *Handler, signed string) *podcast.RSSPodcast {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	element, ok := handler.feeds[signed]
+	if !ok {
+		return nil
+	}
+	return element.Value.(*personalFeed).feed
+}
+
+func TestHandler(t *testing.T) {
+	revocations := &Revocations{}
+	signer := &Signer{Key: []byte("secret"), Revoked: revocations.Revoked}
+	changed := false
+	handler := &Handler{
+		Signer: signer,
+		Source: func(ctx context.Context) (*podcast.RSSPodcast, bool, error) {
+			return testFeed(), changed, nil
+		},
+	}
+	media := signer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := FromContext(r.Context())
+		io.WriteString(w, "audio for "+token.Subscriber)
+	}))
+	serve := func(handler http.Handler, target string) *http.Response {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder.Result()
+	}
+
+	alice := signer.Issue("alice", 0)
+	response := serve(handler, "/feed.xml?token="+url.QueryEscape(alice))
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.StatusCode, body)
+	}
+	feed, err := podcast.Decode(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enclosure, err := url.Parse(feed.Channel.Items[0].Enclosure.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = serve(media, enclosure.RequestURI())
+	body, _ = io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || string(body) != "audio for alice" {
+		t.Errorf("unexpected media response %d %q", response.StatusCode, body)
+	}
+
+	// The personalized feed is kept until the source changes
+	cached := cachedFeed(handler, alice)
+	if response := serve(handler, "/feed.xml?token="+url.QueryEscape(alice)); response.StatusCode != http.StatusOK || cachedFeed(handler, alice) != cached {
+		t.Errorf("expected the cached feed, got %d", response.StatusCode)
+	}
+	changed = true
+	if response := serve(handler, "/feed.xml?token="+url.QueryEscape(alice)); response.StatusCode != http.StatusOK || cachedFeed(handler, alice) == cached {
+		t.Errorf("expected the feed to be personalized again after a change, got %d", response.StatusCode)
+	}
+	changed = false
+
+	if response := serve(handler, "/feed.xml"); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", response.StatusCode)
+	}
+	if response := serve(media, "/1.mp3?token=forged"); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a forged token, got %d", response.StatusCode)
+	}
+	revocations.Revoke("alice", time.Now().Add(time.Second))
+	if response := serve(handler, "/feed.xml?token="+url.QueryEscape(alice)); response.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for a revoked token, got %d", response.StatusCode)
+	}
+	if response := serve(media, enclosure.RequestURI()); response.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for media with a revoked token, got %d", response.StatusCode)
+	}
+}
+
+func TestHandlerEviction(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	signer := &Signer{Key: []byte("secret"), Now: func() time.Time { return now }}
+	handler := &Handler{
+		Signer:   signer,
+		MaxFeeds: 2,
+		Source: func(ctx context.Context) (*podcast.RSSPodcast, bool, error) {
+			return testFeed(), false, nil
+		},
+	}
+	serve := func(signed string) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/feed.xml?token="+url.QueryEscape(signed), nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d", recorder.Code)
+		}
+	}
+
+	alice, bob, carol := signer.Issue("alice", 0), signer.Issue("bob", time.Hour), signer.Issue("carol", 0)
+	serve(alice)
+	serve(bob)
+	serve(alice)
+	serve(carol)
+	if cachedFeed(handler, bob) != nil || cachedFeed(handler, alice) == nil || cachedFeed(handler, carol) == nil {
+		t.Errorf("expected the least recently served feed to be dropped, got %d feeds", len(handler.feeds))
+	}
+
+	// Feeds of expired tokens are dropped first
+	handler.MaxFeeds = 3
+	serve(bob)
+	now = now.Add(2 * time.Hour)
+	dave := signer.Issue("dave", 0)
+	serve(dave)
+	if cachedFeed(handler, bob) != nil || len(handler.feeds) != 3 {
+		t.Errorf("expected the expired token's feed to be dropped, got %d feeds", len(handler.feeds))
+	}
+}
@@ -0,0 +1,426 @@
+package probe
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ID3v2 text encodings
+const (
+	id3Latin1  = 0
+	id3UTF16   = 1
+	id3UTF16BE = 2
+	id3UTF8    = 3
+)
+
+// id3Frame is a frame of an ID3v2 tag, with ID3v2.2 IDs translated to their
+// ID3v2.3 equivalent. PIC keeps its ID, as its layout differs from APIC.
+type id3Frame struct {
+	id   string
+	data []byte
+}
+
+// id3v22IDs maps the three letter frame IDs of ID3v2.2 that are read
+var id3v22IDs = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TAL": "TALB",
+	"TRK": "TRCK",
+	"TPA": "TPOS",
+	"TYE": "TYER",
+	"COM": "COMM",
+	"PIC": "PIC",
+	"WXX": "WXXX",
+}
+
+// readID3v2 reads the ID3v2 tag at the start of r. See the [ID3v2.3] and
+// [ID3v2.4] specs, and the [chapters addendum].
+//
+// [ID3v2.3]: https://id3.org/id3v2.3.0
+// [ID3v2.4]: https://id3.org/id3v2.4.0-structure
+// [chapters addendum]: https://id3.org/id3v2-chapters-1.0
+func readID3v2(r io.Reader, tags *Tags) error {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return formatError(MP3, "truncated ID3 header")
+	}
+	version, flags := header[3], header[5]
+	if version < 2 || version > 4 {
+		return formatError(MP3, "unsupported ID3v2."+strconv.Itoa(int(version))+" tag")
+	}
+
+	data := make([]byte, syncsafe(header[6:10]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return formatError(MP3, "truncated ID3 tag")
+	}
+
+	// Before ID3v2.4 unsynchronisation applies to the whole tag, after to
+	// individual frames.
+	if flags&0x80 != 0 && version < 4 {
+		data = unsynchronise(data)
+	}
+
+	if flags&0x40 != 0 && version > 2 {
+		if len(data) < 4 {
+			return formatError(MP3, "truncated ID3 extended header")
+		}
+		size := int(binary.BigEndian.Uint32(data))
+		if version == 4 {
+			size = syncsafe(data[:4])
+		} else {
+			// The ID3v2.3 size excludes itself
+			size += 4
+		}
+		if size > len(data) {
+			return formatError(MP3, "truncated ID3 extended header")
+		}
+		data = data[size:]
+	}
+
+	frames := parseID3Frames(data, version)
+	applyID3Frames(frames, tags)
+
+	var chapterIDs []string
+	var toc []id3Frame
+	for _, frame := range frames {
+		switch frame.id {
+		case "CHAP":
+			if id, chapter, ok := parseID3Chapter(frame.data, version); ok {
+				chapterIDs = append(chapterIDs, id)
+				tags.Chapters = append(tags.Chapters, chapter)
+			}
+		case "CTOC":
+			toc = append(toc, frame)
+		}
+	}
+	markHiddenChapters(toc, chapterIDs, tags.Chapters)
+
+	return nil
+}
+
+// parseID3Frames splits the frames of a tag, skipping padding, encrypted
+// frames and frames that can't be decoded.
+func parseID3Frames(data []byte, version byte) []id3Frame {
+	idLength, headerLength := 4, 10
+	if version == 2 {
+		idLength, headerLength = 3, 6
+	}
+
+	var frames []id3Frame
+	for len(data) >= headerLength && data[0] != 0 {
+		id := string(data[:idLength])
+		var size int
+		var flags uint16
+		switch version {
+		case 2:
+			size = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(data[4:]))
+			flags = binary.BigEndian.Uint16(data[8:])
+		case 4:
+			size = syncsafe(data[4:8])
+			flags = binary.BigEndian.Uint16(data[8:])
+		}
+		if size < 0 || headerLength+size > len(data) {
+			break
+		}
+		content := data[headerLength : headerLength+size]
+		data = data[headerLength+size:]
+
+		if version == 2 {
+			id = id3v22IDs[id]
+		}
+		content, ok := decodeID3Frame(content, flags, version)
+		if id == "" || !ok {
+			continue
+		}
+		frames = append(frames, id3Frame{id: id, data: content})
+	}
+	return frames
+}
+
+// decodeID3Frame undoes the compression and unsynchronisation of a frame
+func decodeID3Frame(content []byte, flags uint16, version byte) ([]byte, bool) {
+	var compressed, encrypted bool
+
+	switch version {
+	case 3:
+		compressed, encrypted = flags&0x0080 != 0, flags&0x0040 != 0
+		if compressed {
+			// Decompressed size
+			content = skip(content, 4)
+		}
+		if flags&0x0020 != 0 {
+			// Group identifier
+			content = skip(content, 1)
+		}
+	case 4:
+		compressed, encrypted = flags&0x0008 != 0, flags&0x0004 != 0
+		if flags&0x0040 != 0 {
+			content = skip(content, 1)
+		}
+		if flags&0x0001 != 0 {
+			// Data length indicator
+			content = skip(content, 4)
+		}
+		if flags&0x0002 != 0 {
+			content = unsynchronise(content)
+		}
+	}
+
+	if encrypted {
+		return nil, false
+	}
+	if compressed {
+		z, err := zlib.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, false
+		}
+		defer z.Close()
+		if content, err = io.ReadAll(z); err != nil {
+			return nil, false
+		}
+	}
+	return content, true
+}
+
+func applyID3Frames(frames []id3Frame, tags *Tags) {
+	for _, frame := range frames {
+		switch frame.id {
+		case "TIT2":
+			tags.Title = id3Text(frame.data)
+		case "TPE1":
+			tags.Artist = id3Text(frame.data)
+		case "TALB":
+			tags.Album = id3Text(frame.data)
+		case "TDRC", "TYER":
+			if tags.Date == "" {
+				tags.Date = id3Text(frame.data)
+			}
+		case "TRCK":
+			tags.Episode = id3Number(id3Text(frame.data))
+		case "TPOS":
+			tags.Season = id3Number(id3Text(frame.data))
+		case "TDES":
+			// The iTunes podcast description wins over comments
+			tags.Description = id3Text(frame.data)
+		case "COMM":
+			if tags.Description == "" {
+				tags.Description = id3Comment(frame.data)
+			}
+		case "APIC", "PIC":
+			picture, pictureType, ok := id3Picture(frame.data, frame.id)
+			// Prefer the front cover
+			if ok && (tags.Artwork == nil || pictureType == 3) {
+				tags.Artwork = picture
+			}
+		}
+	}
+}
+
+// parseID3Chapter decodes a CHAP frame, returning its element ID
+func parseID3Chapter(data []byte, version byte) (string, Chapter, bool) {
+	id, data, ok := cutID3String(data, id3Latin1)
+	if !ok || len(data) < 16 {
+		return "", Chapter{}, false
+	}
+
+	chapter := Chapter{
+		Start: time.Duration(binary.BigEndian.Uint32(data[0:])) * time.Millisecond,
+		End:   time.Duration(binary.BigEndian.Uint32(data[4:])) * time.Millisecond,
+	}
+
+	for _, frame := range parseID3Frames(data[16:], version) {
+		switch frame.id {
+		case "TIT2":
+			chapter.Title = id3Text(frame.data)
+		case "WXXX":
+			chapter.URL = id3UserURL(frame.data)
+		case "APIC", "PIC":
+			if picture, _, ok := id3Picture(frame.data, frame.id); ok {
+				chapter.Image = picture
+			}
+		}
+	}
+
+	return string(id), chapter, true
+}
+
+// markHiddenChapters marks chapters that aren't in any table of contents as
+// hidden. Without a table of contents every chapter is visible.
+func markHiddenChapters(toc []id3Frame, ids []string, chapters []Chapter) {
+	if len(toc) == 0 {
+		return
+	}
+
+	listed := map[string]bool{}
+	for _, frame := range toc {
+		_, data, ok := cutID3String(frame.data, id3Latin1)
+		if !ok || len(data) < 2 {
+			continue
+		}
+		count := int(data[1])
+		data = data[2:]
+		for i := 0; i < count; i++ {
+			var child []byte
+			if child, data, ok = cutID3String(data, id3Latin1); !ok {
+				break
+			}
+			listed[string(child)] = true
+		}
+	}
+
+	for i, id := range ids {
+		chapters[i].Hidden = !listed[id]
+	}
+}
+
+// id3Text decodes a text information frame. Multiple values, which ID3v2.4
+// separates with null characters, are joined with slashes.
+func id3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	text := decodeID3String(data[1:], data[0])
+	text = strings.TrimRight(text, "\x00")
+	return strings.ReplaceAll(text, "\x00", "/")
+}
+
+// id3Number parses numbers such as "3" or "3/12"
+func id3Number(text string) int {
+	text, _, _ = strings.Cut(text, "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(text))
+	return n
+}
+
+func id3Comment(data []byte) string {
+	// Encoding, language, short description, text
+	if len(data) < 4 {
+		return ""
+	}
+	_, text, ok := cutID3String(data[4:], data[0])
+	if !ok {
+		return ""
+	}
+	return strings.TrimRight(decodeID3String(text, data[0]), "\x00")
+}
+
+func id3UserURL(data []byte) string {
+	if len(data) < 1 {
+		return ""
+	}
+	_, url, ok := cutID3String(data[1:], data[0])
+	if !ok {
+		return ""
+	}
+	return strings.TrimRight(decodeID3String(url, id3Latin1), "\x00")
+}
+
+// id3Picture decodes an APIC frame, or a PIC frame from ID3v2.2 which has a
+// three letter image format instead of a mime type.
+func id3Picture(data []byte, id string) (*Picture, byte, bool) {
+	if len(data) < 2 {
+		return nil, 0, false
+	}
+	encoding := data[0]
+	data = data[1:]
+
+	picture := &Picture{}
+	if id == "PIC" {
+		if len(data) < 3 {
+			return nil, 0, false
+		}
+		picture.MIMEType = "image/" + strings.ToLower(string(data[:3]))
+		if picture.MIMEType == "image/jpg" {
+			picture.MIMEType = "image/jpeg"
+		}
+		data = data[3:]
+	} else {
+		mimeType, rest, ok := cutID3String(data, id3Latin1)
+		if !ok {
+			return nil, 0, false
+		}
+		picture.MIMEType, data = decodeID3String(mimeType, id3Latin1), rest
+	}
+
+	if len(data) < 1 {
+		return nil, 0, false
+	}
+	pictureType := data[0]
+	description, rest, ok := cutID3String(data[1:], encoding)
+	if !ok {
+		return nil, 0, false
+	}
+	picture.Description = decodeID3String(description, encoding)
+	picture.Data = rest
+	return picture, pictureType, true
+}
+
+// cutID3String splits a null terminated string in the given encoding from the
+// data following it.
+func cutID3String(data []byte, encoding byte) (value []byte, rest []byte, ok bool) {
+	if encoding == id3UTF16 || encoding == id3UTF16BE {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:], true
+			}
+		}
+		return nil, nil, false
+	}
+
+	i := bytes.IndexByte(data, 0)
+	if i < 0 {
+		return nil, nil, false
+	}
+	return data[:i], data[i+1:], true
+}
+
+func decodeID3String(data []byte, encoding byte) string {
+	switch encoding {
+	case id3Latin1:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	case id3UTF16, id3UTF16BE:
+		bigEndian := encoding == id3UTF16BE
+		if len(data) >= 2 && encoding == id3UTF16 {
+			switch {
+			case data[0] == 0xFE && data[1] == 0xFF:
+				bigEndian, data = true, data[2:]
+			case data[0] == 0xFF && data[1] == 0xFE:
+				data = data[2:]
+			}
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = binary.BigEndian.Uint16(data[2*i:])
+			} else {
+				units[i] = binary.LittleEndian.Uint16(data[2*i:])
+			}
+		}
+		// Each value of a multi value frame has its own byte order mark
+		return strings.ReplaceAll(string(utf16.Decode(units)), "\uFEFF", "")
+	}
+	return string(data)
+}
+
+// unsynchronise removes the zero bytes inserted after 0xFF bytes
+func unsynchronise(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+}
+
+func skip(data []byte, n int) []byte {
+	if len(data) < n {
+		return nil
+	}
+	return data[n:]
+}
@@ -0,0 +1,246 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// mp3SearchLimit is how far past the ID3 tag the first frame is searched for
+const mp3SearchLimit = 64 * 1024
+
+var mp3Bitrates = [2][3][16]int{
+	// MPEG 1, layers I, II and III
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	// MPEG 2 and 2.5, layers I, II and III
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+var mp3SampleRates = map[int][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// mp3Frame is a parsed MPEG audio frame header
+type mp3Frame struct {
+	version    int
+	layer      int // 1, 2 or 3
+	bitrate    int // bits per second
+	sampleRate int
+	padding    int
+	mono       bool
+}
+
+func parseMP3Frame(header []byte) (mp3Frame, bool) {
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+
+	frame := mp3Frame{
+		version: int(header[1]>>3) & 0x3,
+		layer:   4 - int(header[1]>>1)&0x3,
+		padding: int(header[2]>>1) & 0x1,
+		mono:    header[3]>>6 == 0x3,
+	}
+	bitrateIndex := int(header[2] >> 4)
+	sampleRateIndex := int(header[2]>>2) & 0x3
+
+	// Reserved values, and free format which can't be sized from the header
+	if frame.version == 1 || frame.layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	table := 0
+	if frame.version != mpeg1 {
+		table = 1
+	}
+	frame.bitrate = mp3Bitrates[table][frame.layer-1][bitrateIndex] * 1000
+	frame.sampleRate = mp3SampleRates[frame.version][sampleRateIndex]
+
+	return frame, true
+}
+
+func (f mp3Frame) samples() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && f.version != mpeg1:
+		return 576
+	}
+	return 1152
+}
+
+// length is the size of the frame in bytes, header included
+func (f mp3Frame) length() int {
+	if f.layer == 1 {
+		return (12*f.bitrate/f.sampleRate + f.padding) * 4
+	}
+	return f.samples()/8*f.bitrate/f.sampleRate + f.padding
+}
+
+// sideInfoLength is the size of the layer III side information that comes
+// between the header and a Xing tag.
+func (f mp3Frame) sideInfoLength() int {
+	switch {
+	case f.version == mpeg1 && f.mono:
+		return 17
+	case f.version == mpeg1:
+		return 32
+	case f.mono:
+		return 9
+	}
+	return 17
+}
+
+// skipID3v2 returns the offset just past the ID3v2 tags at the start of r
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	var offset int64
+	for {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		var header [10]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil || string(header[:3]) != "ID3" {
+			return offset, nil
+		}
+
+		offset += 10 + int64(syncsafe(header[6:10]))
+		if header[5]&0x10 != 0 {
+			// Footer
+			offset += 10
+		}
+	}
+}
+
+// syncsafe decodes an integer with the top bit of each byte unset
+func syncsafe(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n<<7 | int(c&0x7F)
+	}
+	return n
+}
+
+func probeMP3(r io.ReadSeeker, info *Info) error {
+	start, err := skipID3v2(r)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, mp3SearchLimit)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return ErrUnknownFormat
+		}
+		return err
+	}
+	buf = buf[:n]
+
+	// A frame is only trusted if the next one follows right after it, as
+	// frame sync patterns easily appear at random.
+	offset := -1
+	var frame mp3Frame
+	for i := 0; i+4 <= len(buf); i++ {
+		candidate, ok := parseMP3Frame(buf[i:])
+		if !ok {
+			continue
+		}
+		next := i + candidate.length()
+		if next+4 <= len(buf) {
+			if following, ok := parseMP3Frame(buf[next:]); !ok || following.version != candidate.version || following.layer != candidate.layer {
+				continue
+			}
+		} else if next > len(buf) {
+			continue
+		}
+		offset, frame = i, candidate
+		break
+	}
+	if offset < 0 {
+		return ErrUnknownFormat
+	}
+
+	info.Format = MP3
+	info.MIMEType = "audio/mpeg"
+	info.Codec = [...]string{"mp1", "mp2", "mp3"}[frame.layer-1]
+	info.SampleRate = frame.sampleRate
+	info.Channels = 2
+	if frame.mono {
+		info.Channels = 1
+	}
+
+	end := info.Size
+	var tag [3]byte
+	if info.Size >= 128 {
+		if _, err := r.Seek(-128, io.SeekEnd); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, tag[:]); err == nil && string(tag[:]) == "TAG" {
+			end -= 128
+		}
+	}
+	audioBytes := end - start - int64(offset)
+
+	frames, vbrBytes := readVBRHeader(buf[offset:], frame)
+	if frames > 0 {
+		info.Duration = durationOf(frames*int64(frame.samples()), int64(frame.sampleRate))
+		if vbrBytes > 0 {
+			audioBytes = vbrBytes
+		}
+		if info.Duration > 0 {
+			info.Bitrate = int(float64(audioBytes*8) / info.Duration.Seconds())
+		}
+		return nil
+	}
+
+	// Constant bitrate
+	info.Bitrate = frame.bitrate
+	info.Duration = time.Duration(float64(audioBytes*8) / float64(frame.bitrate) * float64(time.Second))
+	return nil
+}
+
+// readVBRHeader reads the frame and byte counts of the Xing, Info or VBRI
+// header in the first frame, if there is one.
+func readVBRHeader(data []byte, frame mp3Frame) (frames int64, bytes int64) {
+	xing := 4 + frame.sideInfoLength()
+	if len(data) >= xing+8 && (string(data[xing:xing+4]) == "Xing" || string(data[xing:xing+4]) == "Info") {
+		flags := binary.BigEndian.Uint32(data[xing+4:])
+		field := xing + 8
+		if flags&0x1 != 0 && len(data) >= field+4 {
+			frames = int64(binary.BigEndian.Uint32(data[field:]))
+			field += 4
+		}
+		if flags&0x2 != 0 && len(data) >= field+4 {
+			bytes = int64(binary.BigEndian.Uint32(data[field:]))
+		}
+		return frames, bytes
+	}
+
+	// VBRI always follows 32 bytes after the header
+	const vbri = 4 + 32
+	if len(data) >= vbri+18 && string(data[vbri:vbri+4]) == "VBRI" {
+		bytes = int64(binary.BigEndian.Uint32(data[vbri+10:]))
+		frames = int64(binary.BigEndian.Uint32(data[vbri+14:]))
+	}
+	return frames, bytes
+}
@@ -0,0 +1,200 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// mp4Box is the header of an ISO base media file format box
+type mp4Box struct {
+	typ string
+	// offset of the box's content, just past its header
+	offset int64
+	// size of the box's content
+	size int64
+}
+
+func (b mp4Box) end() int64 {
+	return b.offset + b.size
+}
+
+// readMP4Boxes reads the headers of the boxes between start and end, calling
+// fn for each. fn may read from r, as it is seeked to the next box afterwards.
+func readMP4Boxes(r io.ReadSeeker, start, end int64, fn func(mp4Box) error) error {
+	for offset := start; offset+8 <= end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return formatError(MP4, "truncated box")
+		}
+
+		box := mp4Box{typ: string(header[4:8]), offset: offset + 8}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		switch size {
+		case 0:
+			// The box extends to the end of the file
+			size = end - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return formatError(MP4, "truncated box")
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			box.offset += 8
+		}
+		box.size = size - (box.offset - offset)
+		if box.size < 0 || offset+size > end {
+			return formatError(MP4, "box "+box.typ+" overflows its parent")
+		}
+
+		if err := fn(box); err != nil {
+			return err
+		}
+		offset += size
+	}
+	return nil
+}
+
+// readMP4Content reads up to n bytes of the content of box
+func readMP4Content(r io.ReadSeeker, box mp4Box, n int64) ([]byte, error) {
+	if n > box.size {
+		n = box.size
+	}
+	if _, err := r.Seek(box.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, formatError(MP4, "truncated "+box.typ+" box")
+	}
+	return data, nil
+}
+
+func probeMP4(r io.ReadSeeker, info *Info) error {
+	info.Format = MP4
+
+	var brand string
+	var mdatSize int64
+	var timescale, duration uint64
+
+	err := readMP4Boxes(r, 0, info.Size, func(box mp4Box) error {
+		switch box.typ {
+		case "ftyp":
+			data, err := readMP4Content(r, box, 4)
+			if err != nil {
+				return err
+			}
+			brand = string(data)
+		case "mdat":
+			mdatSize += box.size
+		case "moov":
+			return readMP4Boxes(r, box.offset, box.end(), func(box mp4Box) error {
+				switch box.typ {
+				case "mvhd":
+					data, err := readMP4Content(r, box, 32)
+					if err != nil {
+						return err
+					}
+					if len(data) >= 32 && data[0] == 1 {
+						timescale = uint64(binary.BigEndian.Uint32(data[20:]))
+						duration = binary.BigEndian.Uint64(data[24:])
+					} else if len(data) >= 20 && data[0] == 0 {
+						timescale = uint64(binary.BigEndian.Uint32(data[12:]))
+						duration = uint64(binary.BigEndian.Uint32(data[16:]))
+					}
+				case "trak":
+					return probeMP4Track(r, box, info)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if timescale > 0 {
+		info.Duration = durationOf(int64(duration), int64(timescale))
+	}
+	if mdatSize > 0 && info.Duration > 0 {
+		info.Bitrate = int(float64(mdatSize*8) / info.Duration.Seconds())
+	}
+
+	switch {
+	case brand == "qt  ":
+		info.MIMEType = "video/quicktime"
+	case brand == "M4V " || brand == "M4VH" || brand == "M4VP":
+		info.MIMEType = "video/x-m4v"
+	case info.HasVideo:
+		info.MIMEType = "video/mp4"
+	default:
+		info.MIMEType = "audio/x-m4a"
+	}
+
+	return nil
+}
+
+// probeMP4Track reads the codec of a trak box, and for the first audio track
+// its channels and sample rate.
+func probeMP4Track(r io.ReadSeeker, trak mp4Box, info *Info) error {
+	var handler string
+	var sampleEntry mp4Box
+
+	// trak > mdia > hdlr and trak > mdia > minf > stbl > stsd
+	var walk func(box mp4Box) error
+	walk = func(box mp4Box) error {
+		switch box.typ {
+		case "mdia", "minf", "stbl":
+			return readMP4Boxes(r, box.offset, box.end(), walk)
+		case "hdlr":
+			data, err := readMP4Content(r, box, 12)
+			if err != nil {
+				return err
+			}
+			if len(data) == 12 {
+				handler = string(data[8:12])
+			}
+		case "stsd":
+			// Version, flags and entry count come before the first entry
+			if box.size < 8 {
+				return nil
+			}
+			return readMP4Boxes(r, box.offset+8, box.end(), func(entry mp4Box) error {
+				if sampleEntry.typ == "" {
+					sampleEntry = entry
+				}
+				return nil
+			})
+		}
+		return nil
+	}
+	if err := readMP4Boxes(r, trak.offset, trak.end(), walk); err != nil {
+		return err
+	}
+
+	switch handler {
+	case "vide":
+		info.HasVideo = true
+	case "soun":
+		if info.Codec != "" {
+			return nil
+		}
+		info.Codec = sampleEntry.typ
+
+		// Audio sample entries have 8 reserved bytes after the data reference
+		// index, then the channel count, sample size, 4 more bytes and the
+		// sample rate as a 16.16 fixed point number.
+		data, err := readMP4Content(r, sampleEntry, 28)
+		if err != nil {
+			return err
+		}
+		if len(data) == 28 {
+			info.Channels = int(binary.BigEndian.Uint16(data[16:]))
+			info.SampleRate = int(binary.BigEndian.Uint32(data[24:]) >> 16)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+	"unicode/utf16"
+)
+
+// mp4MaxChapters bounds the samples read from a chapter track, whose sample
+// count comes from the file.
+const mp4MaxChapters = 1 << 16
+
+// mp4Track is what's needed of a trak box to read a chapter track
+type mp4Track struct {
+	id uint32
+	// chapters are the IDs of the chapter tracks referenced with tref > chap
+	chapters  []uint32
+	timescale uint32
+
+	durations    []uint32
+	sizes        []uint32
+	chunkOffsets []int64
+	chunks       []mp4ChunkRun
+}
+
+// mp4ChunkRun is an entry of an stsc box, chunks from first on have the same
+// number of samples.
+type mp4ChunkRun struct {
+	first   uint32
+	samples uint32
+}
+
+// readMP4Tags reads the iTunes metadata (moov > udta > meta > ilst) and the
+// chapters, either from a QuickTime chapter track or a Nero chpl box.
+func readMP4Tags(r io.ReadSeeker, size int64, tags *Tags) error {
+	var tracks []*mp4Track
+	var neroChapters []Chapter
+	var episode, track int
+
+	err := readMP4Boxes(r, 0, size, func(box mp4Box) error {
+		if box.typ != "moov" {
+			return nil
+		}
+		return readMP4Boxes(r, box.offset, box.end(), func(box mp4Box) error {
+			switch box.typ {
+			case "trak":
+				t, err := readMP4Track(r, box)
+				if err != nil {
+					return err
+				}
+				tracks = append(tracks, t)
+			case "udta":
+				return readMP4Boxes(r, box.offset, box.end(), func(box mp4Box) error {
+					switch box.typ {
+					case "meta":
+						return readMP4Meta(r, box, tags, &episode, &track)
+					case "chpl":
+						chapters, err := readNeroChapters(r, box)
+						neroChapters = chapters
+						return err
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	tags.Episode = episode
+	if tags.Episode == 0 {
+		tags.Episode = track
+	}
+
+	tags.Chapters, err = readMP4ChapterTrack(r, tracks)
+	if err != nil {
+		return err
+	}
+	if len(tags.Chapters) == 0 {
+		tags.Chapters = neroChapters
+	}
+	return nil
+}
+
+// readMP4Meta reads the items of a meta box
+func readMP4Meta(r io.ReadSeeker, meta mp4Box, tags *Tags, episode, track *int) error {
+	// meta is a full box in MP4 files, but not in QuickTime files
+	start := meta.offset
+	head, err := readMP4Content(r, meta, 8)
+	if err != nil {
+		return err
+	}
+	if len(head) == 8 && string(head[4:8]) != "hdlr" {
+		start += 4
+	}
+
+	return readMP4Boxes(r, start, meta.end(), func(box mp4Box) error {
+		if box.typ != "ilst" {
+			return nil
+		}
+		return readMP4Boxes(r, box.offset, box.end(), func(item mp4Box) error {
+			dataType, value, err := readMP4ItemData(r, item)
+			if err != nil || value == nil {
+				return err
+			}
+
+			switch item.typ {
+			case "\xa9nam":
+				tags.Title = string(value)
+			case "\xa9ART":
+				tags.Artist = string(value)
+			case "\xa9alb":
+				tags.Album = string(value)
+			case "\xa9day":
+				tags.Date = string(value)
+			case "ldes":
+				tags.Description = string(value)
+			case "desc", "\xa9cmt":
+				if tags.Description == "" {
+					tags.Description = string(value)
+				}
+			case "tves":
+				*episode = mp4Integer(value)
+			case "tvsn":
+				tags.Season = mp4Integer(value)
+			case "trkn":
+				// Reserved, track number, total tracks
+				if len(value) >= 4 {
+					*track = int(binary.BigEndian.Uint16(value[2:]))
+				}
+			case "covr":
+				if tags.Artwork == nil {
+					tags.Artwork = &Picture{MIMEType: mp4ImageTypes[dataType], Data: value}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// mp4ImageTypes maps the well-known data types of images to mime types
+var mp4ImageTypes = map[uint32]string{
+	13: "image/jpeg",
+	14: "image/png",
+	27: "image/bmp",
+}
+
+// readMP4ItemData reads the first data box of a metadata item, returning its
+// well-known type and value.
+func readMP4ItemData(r io.ReadSeeker, item mp4Box) (dataType uint32, value []byte, err error) {
+	err = readMP4Boxes(r, item.offset, item.end(), func(box mp4Box) error {
+		if box.typ != "data" || value != nil {
+			return nil
+		}
+		// Type indicator and locale come before the value
+		data, err := readMP4Content(r, box, box.size)
+		if err != nil || len(data) < 8 {
+			return err
+		}
+		dataType = binary.BigEndian.Uint32(data) & 0xFFFFFF
+		value = data[8:]
+		return nil
+	})
+	return dataType, value, err
+}
+
+// mp4Integer decodes a big endian integer of any size up to 8 bytes
+func mp4Integer(value []byte) int {
+	if len(value) > 8 {
+		return 0
+	}
+	n := 0
+	for _, b := range value {
+		n = n<<8 | int(b)
+	}
+	return n
+}
+
+// readNeroChapters reads a chpl box, as written by Nero and ffmpeg
+func readNeroChapters(r io.ReadSeeker, box mp4Box) ([]Chapter, error) {
+	data, err := readMP4Content(r, box, box.size)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 5 {
+		return nil, nil
+	}
+
+	// Version and flags, then a reserved field in version 1
+	position := 4
+	if data[0] == 1 {
+		position += 4
+	}
+	if position >= len(data) {
+		return nil, nil
+	}
+	count := int(data[position])
+	position++
+
+	var chapters []Chapter
+	for i := 0; i < count && position+9 <= len(data); i++ {
+		// Start in units of 100 nanoseconds
+		start := time.Duration(binary.BigEndian.Uint64(data[position:])) * 100
+		length := int(data[position+8])
+		position += 9
+		if position+length > len(data) {
+			break
+		}
+		chapters = append(chapters, Chapter{Start: start, Title: string(data[position : position+length])})
+		position += length
+	}
+	return chapters, nil
+}
+
+// readMP4Track reads the parts of a trak box needed to find and read a
+// chapter track.
+func readMP4Track(r io.ReadSeeker, trak mp4Box) (*mp4Track, error) {
+	t := &mp4Track{}
+
+	var walk func(box mp4Box) error
+	walk = func(box mp4Box) error {
+		switch box.typ {
+		case "mdia", "minf", "stbl", "tref":
+			return readMP4Boxes(r, box.offset, box.end(), walk)
+		case "tkhd", "chap", "mdhd", "stts", "stsz", "stsc", "stco", "co64":
+		default:
+			return nil
+		}
+
+		data, err := readMP4Content(r, box, box.size)
+		if err != nil {
+			return err
+		}
+
+		switch box.typ {
+		case "tkhd":
+			// Version 1 has 64 bit creation and modification times
+			if len(data) >= 24 && data[0] == 1 {
+				t.id = binary.BigEndian.Uint32(data[20:])
+			} else if len(data) >= 16 {
+				t.id = binary.BigEndian.Uint32(data[12:])
+			}
+		case "chap":
+			for i := 0; i+4 <= len(data); i += 4 {
+				t.chapters = append(t.chapters, binary.BigEndian.Uint32(data[i:]))
+			}
+		case "mdhd":
+			if len(data) >= 24 && data[0] == 1 {
+				t.timescale = binary.BigEndian.Uint32(data[20:])
+			} else if len(data) >= 16 {
+				t.timescale = binary.BigEndian.Uint32(data[12:])
+			}
+		case "stts":
+			for _, entry := range mp4Table(data, 8) {
+				count, delta := binary.BigEndian.Uint32(entry), binary.BigEndian.Uint32(entry[4:])
+				for i := uint32(0); i < count && len(t.durations) < mp4MaxChapters; i++ {
+					t.durations = append(t.durations, delta)
+				}
+			}
+		case "stsz":
+			if len(data) < 12 {
+				return nil
+			}
+			uniform, count := binary.BigEndian.Uint32(data[4:]), binary.BigEndian.Uint32(data[8:])
+			if uniform == 0 {
+				for _, entry := range mp4Table(data[4:], 4) {
+					t.sizes = append(t.sizes, binary.BigEndian.Uint32(entry))
+				}
+			} else {
+				for i := uint32(0); i < count && i < mp4MaxChapters; i++ {
+					t.sizes = append(t.sizes, uniform)
+				}
+			}
+		case "stsc":
+			for _, entry := range mp4Table(data, 12) {
+				t.chunks = append(t.chunks, mp4ChunkRun{
+					first:   binary.BigEndian.Uint32(entry),
+					samples: binary.BigEndian.Uint32(entry[4:]),
+				})
+			}
+		case "stco":
+			for _, entry := range mp4Table(data, 4) {
+				t.chunkOffsets = append(t.chunkOffsets, int64(binary.BigEndian.Uint32(entry)))
+			}
+		case "co64":
+			for _, entry := range mp4Table(data, 8) {
+				t.chunkOffsets = append(t.chunkOffsets, int64(binary.BigEndian.Uint64(entry)))
+			}
+		}
+		return nil
+	}
+
+	return t, readMP4Boxes(r, trak.offset, trak.end(), walk)
+}
+
+// mp4Table splits the entries of a full box that holds an entry count followed
+// by entries of a fixed size.
+func mp4Table(data []byte, entrySize int) [][]byte {
+	if len(data) < 8 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint32(data[4:]))
+	data = data[8:]
+
+	var entries [][]byte
+	for i := 0; i < count && len(data) >= entrySize && i < mp4MaxChapters; i++ {
+		entries = append(entries, data[:entrySize])
+		data = data[entrySize:]
+	}
+	return entries
+}
+
+// readMP4ChapterTrack reads the chapters from the text track referenced as
+// chapters by another track, if there is one.
+func readMP4ChapterTrack(r io.ReadSeeker, tracks []*mp4Track) ([]Chapter, error) {
+	var chapterTrack *mp4Track
+	for _, t := range tracks {
+		for _, id := range t.chapters {
+			for _, candidate := range tracks {
+				if candidate.id == id && candidate != t {
+					chapterTrack = candidate
+				}
+			}
+		}
+	}
+	if chapterTrack == nil || chapterTrack.timescale == 0 {
+		return nil, nil
+	}
+	t := chapterTrack
+
+	var chapters []Chapter
+	var elapsed int64
+	sample := 0
+	for chunk, offset := range t.chunkOffsets {
+		samples := t.samplesInChunk(uint32(chunk + 1))
+		for i := uint32(0); i < samples && sample < len(t.sizes) && sample < len(t.durations); i++ {
+			title, err := readMP4TextSample(r, offset, t.sizes[sample])
+			if err != nil {
+				return nil, err
+			}
+
+			duration := int64(t.durations[sample])
+			chapters = append(chapters, Chapter{
+				Start: durationOf(elapsed, int64(t.timescale)),
+				End:   durationOf(elapsed+duration, int64(t.timescale)),
+				Title: title,
+			})
+
+			elapsed += duration
+			offset += int64(t.sizes[sample])
+			sample++
+		}
+	}
+	return chapters, nil
+}
+
+// samplesInChunk is the number of samples in the chunk with the given one
+// based index.
+func (t *mp4Track) samplesInChunk(chunk uint32) uint32 {
+	var samples uint32
+	for _, run := range t.chunks {
+		if run.first > chunk {
+			break
+		}
+		samples = run.samples
+	}
+	return samples
+}
+
+// readMP4TextSample reads a sample of a text track: a 16 bit length followed
+// by UTF-8 or UTF-16 text, possibly followed by other boxes.
+func readMP4TextSample(r io.ReadSeeker, offset int64, size uint32) (string, error) {
+	if size < 2 {
+		return "", nil
+	}
+	// The length of the text is only 16 bits, anything further is ignored
+	if size > 2+0xFFFF {
+		size = 2 + 0xFFFF
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", formatError(MP4, "truncated chapter sample")
+	}
+
+	length := int(binary.BigEndian.Uint16(data))
+	text := data[2:]
+	if length < len(text) {
+		text = text[:length]
+	}
+
+	if len(text) >= 2 && text[0] == 0xFE && text[1] == 0xFF {
+		units := make([]uint16, (len(text)-2)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(text[2+2*i:])
+		}
+		return string(utf16.Decode(units)), nil
+	}
+	return string(text), nil
+}
@@ -0,0 +1,103 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// oggTailLimit is how far from the end of the file the last page is searched
+// for. Pages are at most 64 KiB.
+const oggTailLimit = 64 * 1024
+
+// oggPage is the part of an Ogg page header probing needs
+type oggPage struct {
+	granule int64
+	serial  uint32
+}
+
+func parseOggPage(data []byte) (page oggPage, headerLength int, ok bool) {
+	if len(data) < 27 || string(data[:4]) != "OggS" {
+		return oggPage{}, 0, false
+	}
+	page.granule = int64(binary.LittleEndian.Uint64(data[6:]))
+	page.serial = binary.LittleEndian.Uint32(data[14:])
+	return page, 27 + int(data[26]), true
+}
+
+func probeOgg(r io.ReadSeeker, info *Info) error {
+	info.Format = Ogg
+	info.MIMEType = "audio/ogg"
+
+	// The first page holds only the identification header of the first
+	// stream, which is small enough to read whole.
+	first := make([]byte, 27+255+64)
+	n, err := io.ReadFull(r, first)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	first = first[:n]
+
+	page, headerLength, ok := parseOggPage(first)
+	if !ok || headerLength > len(first) {
+		return formatError(Ogg, "truncated first page")
+	}
+	packet := first[headerLength:]
+
+	// Granule positions count samples, which for Opus are always at 48 kHz
+	// and include the pre-skip.
+	var granuleRate, preSkip int64
+	switch {
+	case hasPrefix(packet, "OpusHead") && len(packet) >= 16:
+		info.Codec = "opus"
+		info.Channels = int(packet[9])
+		info.SampleRate = 48000
+		preSkip = int64(binary.LittleEndian.Uint16(packet[10:]))
+		granuleRate = 48000
+	case hasPrefix(packet, "\x01vorbis") && len(packet) >= 28:
+		info.Codec = "vorbis"
+		info.Channels = int(packet[11])
+		info.SampleRate = int(binary.LittleEndian.Uint32(packet[12:]))
+		granuleRate = int64(info.SampleRate)
+	default:
+		return formatError(Ogg, "only Opus and Vorbis streams are supported")
+	}
+
+	last, err := lastOggGranule(r, info.Size, page.serial)
+	if err != nil {
+		return err
+	}
+	if last > preSkip {
+		info.Duration = durationOf(last-preSkip, granuleRate)
+	}
+	return nil
+}
+
+// lastOggGranule finds the granule position of the last page of the stream
+// with the given serial number.
+func lastOggGranule(r io.ReadSeeker, size int64, serial uint32) (int64, error) {
+	start := size - oggTailLimit
+	if start < 0 {
+		start = 0
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+	tail := make([]byte, size-start)
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return 0, err
+	}
+
+	for i := len(tail); i > 0; {
+		i = bytes.LastIndex(tail[:i], []byte("OggS"))
+		if i < 0 {
+			break
+		}
+		page, _, ok := parseOggPage(tail[i:])
+		// A granule of -1 means no packet ends on the page
+		if ok && page.serial == serial && page.granule != -1 {
+			return page.granule, nil
+		}
+	}
+	return 0, formatError(Ogg, "no last page found")
+}
@@ -0,0 +1,156 @@
+// Package probe reads the technical metadata of podcast media files, such as
+// their duration and bitrate, without shelling out to ffprobe.
+//
+// MP3, MP4 (M4A, M4V, MOV), Ogg (Opus and Vorbis) and WAV files are
+// supported. Only the container and frame headers are read, nothing is
+// decoded, so probing a large file is cheap as long as it can be seeked.
+//
+// You are probably most interested in [File] and [Info.PopulateEpisode].
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Format is the container format of a media file
+type Format string
+
+const (
+	MP3 Format = "mp3"
+	MP4 Format = "mp4"
+	Ogg Format = "ogg"
+	WAV Format = "wav"
+)
+
+// ErrUnknownFormat is returned when a file isn't in any supported format
+var ErrUnknownFormat = errors.New("probe: unknown media format")
+
+// Info is the technical metadata of a media file. Fields that couldn't be
+// determined are left as zero.
+type Info struct {
+	Format Format
+
+	// MIMEType is suitable for [rss.Enclosure.Type], such as audio/mpeg. The
+	// types Apple Podcasts documents are preferred, so M4A files are
+	// audio/x-m4a.
+	MIMEType string
+
+	// Codec of the first audio track, such as mp3, mp4a, opus or pcm
+	Codec string
+
+	// Size of the file in bytes
+	Size int64
+
+	Duration time.Duration
+
+	// Bitrate is the average bitrate in bits per second
+	Bitrate int
+
+	Channels   int
+	SampleRate int
+
+	// HasVideo is true if the file has a video track
+	HasVideo bool
+}
+
+// prober reads the metadata of one format. r is positioned at the start of the
+// file, and info.Size is already set.
+type prober func(r io.ReadSeeker, info *Info) error
+
+// File probes the media file with the given name.
+func File(name string) (*Info, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Probe(f)
+}
+
+// Probe detects the format of the media in r and reads its metadata. r is
+// read from the start, whatever its current offset.
+func Probe(r io.ReadSeeker) (*Info, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var magic [12]byte
+	n, err := io.ReadFull(r, magic[:])
+	if err == io.EOF {
+		return nil, ErrUnknownFormat
+	} else if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var probe prober
+	switch m := magic[:n]; {
+	case hasPrefix(m, "OggS"):
+		probe = probeOgg
+	case hasPrefix(m, "RIFF") && len(m) >= 12 && string(m[8:12]) == "WAVE":
+		probe = probeWAV
+	case len(m) >= 8 && string(m[4:8]) == "ftyp":
+		probe = probeMP4
+	default:
+		// MP3 files have no magic number, frames have to be searched for
+		probe = probeMP3
+	}
+
+	info := &Info{Size: size}
+	if err := probe(r, info); err != nil {
+		return nil, err
+	}
+
+	if info.Bitrate == 0 && info.Duration > 0 {
+		info.Bitrate = int(float64(size*8) / info.Duration.Seconds())
+	}
+
+	return info, nil
+}
+
+// PopulateEpisode fills in the enclosure length and type and the duration of
+// an episode from the media file. The enclosure is created if the episode
+// doesn't have one yet, its URL is left for the caller to set.
+func (i *Info) PopulateEpisode(e *podcast.Episode) {
+	if e.Enclosure == nil {
+		e.Enclosure = &rss.Enclosure{}
+	}
+	e.Enclosure.Length = int(i.Size)
+	e.Enclosure.Type = i.MIMEType
+
+	if i.Duration > 0 {
+		e.ItunesDuration = strconv.Itoa(int(i.Duration.Round(time.Second).Seconds()))
+	}
+}
+
+func hasPrefix(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == prefix
+}
+
+// durationOf converts a number of samples at a sample rate to a duration
+func durationOf(samples int64, rate int64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	seconds := samples / rate
+	remainder := samples % rate
+	return time.Duration(seconds)*time.Second + time.Duration(remainder)*time.Second/time.Duration(rate)
+}
+
+func formatError(format Format, reason string) error {
+	return fmt.Errorf("probe: invalid %s file: %s", format, reason)
+}
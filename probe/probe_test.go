@@ -0,0 +1,228 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// mp3Frame128k is the header of an MPEG 1 layer III frame at 128 kbps and
+// 44.1 kHz, which is 417 bytes long.
+var mp3Frame128k = []byte{0xFF, 0xFB, 0x90, 0x00}
+
+func mp3Frames(count int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, mp3Frame128k)
+	return bytes.Repeat(frame, count)
+}
+
+// id3v2 is an empty ID3v2.4 tag with some padding
+func id3v2(padding int) []byte {
+	tag := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, byte(padding >> 7), byte(padding & 0x7F)}
+	return append(tag, make([]byte, padding)...)
+}
+
+func box(typ string, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	header := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	return append(append(header, typ...), data...)
+}
+
+func mp4File(brand string, handler string) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)  // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 61500) // duration
+
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:], handler)
+
+	entry := make([]byte, 28)
+	binary.BigEndian.PutUint16(entry[16:], 2)
+	binary.BigEndian.PutUint32(entry[24:], 44100<<16)
+	stsd := append([]byte{0, 0, 0, 0, 0, 0, 0, 1}, box("mp4a", entry)...)
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte(brand), []byte{0, 0, 0, 0}, []byte("isom")),
+		box("mdat", make([]byte, 61500)),
+		box("moov",
+			box("mvhd", mvhd),
+			box("trak",
+				box("tkhd", make([]byte, 84)),
+				box("mdia",
+					box("mdhd", make([]byte, 24)),
+					box("hdlr", hdlr),
+					box("minf", box("stbl", box("stsd", stsd))),
+				),
+			),
+		),
+	}, nil)
+}
+
+func makeOggPage(headerType byte, granule int64, serial uint32, packet []byte) []byte {
+	page := []byte("OggS")
+	page = append(page, 0, headerType)
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = append(page, 0, 0, 0, 0, 0, 0, 0, 0) // sequence and checksum
+
+	var segments []byte
+	for remaining := len(packet); ; remaining -= 255 {
+		if remaining < 255 {
+			segments = append(segments, byte(remaining))
+			break
+		}
+		segments = append(segments, 255)
+	}
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, packet...)
+}
+
+func opusFile() []byte {
+	head := []byte("OpusHead")
+	head = append(head, 1, 2)
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = binary.LittleEndian.AppendUint32(head, 44100)
+	head = append(head, 0, 0, 0)
+
+	return bytes.Join([][]byte{
+		makeOggPage(2, 0, 42, head),
+		makeOggPage(0, 0, 42, []byte("OpusTags")),
+		makeOggPage(0, 48000*3, 42, make([]byte, 2000)),
+		makeOggPage(4, 48000*5+312, 42, make([]byte, 2000)),
+	}, nil)
+}
+
+func wavFile() []byte {
+	format := binary.LittleEndian.AppendUint16(nil, 1) // PCM
+	format = binary.LittleEndian.AppendUint16(format, 1)
+	format = binary.LittleEndian.AppendUint32(format, 8000)
+	format = binary.LittleEndian.AppendUint32(format, 8000)
+	format = binary.LittleEndian.AppendUint16(format, 1)
+	format = binary.LittleEndian.AppendUint16(format, 8)
+
+	chunk := func(id string, data []byte) []byte {
+		c := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+
+	body := bytes.Join([][]byte{
+		[]byte("WAVE"),
+		chunk("fmt ", format),
+		chunk("LIST", []byte("odd")),
+		chunk("data", make([]byte, 16000)),
+	}, nil)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func TestProbe(t *testing.T) {
+	cbr := append(id3v2(100), mp3Frames(100)...)
+	cbr = append(cbr, append([]byte("TAG"), make([]byte, 125)...)...)
+
+	xing := mp3Frames(10)
+	copy(xing[36:], "Xing\x00\x00\x00\x03")
+	binary.BigEndian.PutUint32(xing[44:], 1000)
+	binary.BigEndian.PutUint32(xing[48:], 1000*300)
+
+	vbri := mp3Frames(10)
+	copy(vbri[36:], "VBRI")
+	binary.BigEndian.PutUint32(vbri[46:], 500*300)
+	binary.BigEndian.PutUint32(vbri[50:], 500)
+
+	// Garbage before the first frame, including a false frame sync
+	garbage := append([]byte{0, 0xFF, 0xFB, 0x90, 0x00, 1, 2, 3}, mp3Frames(20)...)
+
+	testCases := []struct {
+		name     string
+		file     []byte
+		expected Info
+	}{
+		{"mp3 cbr", cbr, Info{
+			Format: MP3, MIMEType: "audio/mpeg", Codec: "mp3", Size: int64(len(cbr)),
+			Duration: 2606250 * time.Microsecond, Bitrate: 128000, Channels: 2, SampleRate: 44100,
+		}},
+		{"mp3 xing", xing, Info{
+			Format: MP3, MIMEType: "audio/mpeg", Codec: "mp3", Size: int64(len(xing)),
+			Duration: durationOf(1000*1152, 44100), Bitrate: 91875, Channels: 2, SampleRate: 44100,
+		}},
+		{"mp3 vbri", vbri, Info{
+			Format: MP3, MIMEType: "audio/mpeg", Codec: "mp3", Size: int64(len(vbri)),
+			Duration: durationOf(500*1152, 44100), Bitrate: 91875, Channels: 2, SampleRate: 44100,
+		}},
+		{"mp3 garbage", garbage, Info{
+			Format: MP3, MIMEType: "audio/mpeg", Codec: "mp3", Size: int64(len(garbage)),
+			Duration: 521250 * time.Microsecond, Bitrate: 128000, Channels: 2, SampleRate: 44100,
+		}},
+		{"m4a", mp4File("M4A ", "soun"), Info{
+			Format: MP4, MIMEType: "audio/x-m4a", Codec: "mp4a", Size: int64(len(mp4File("M4A ", "soun"))),
+			Duration: 61500 * time.Millisecond, Bitrate: 8000, Channels: 2, SampleRate: 44100,
+		}},
+		{"mp4 video", mp4File("isom", "vide"), Info{
+			Format: MP4, MIMEType: "video/mp4", Size: int64(len(mp4File("isom", "vide"))),
+			Duration: 61500 * time.Millisecond, Bitrate: 8000, HasVideo: true,
+		}},
+		{"opus", opusFile(), Info{
+			Format: Ogg, MIMEType: "audio/ogg", Codec: "opus", Size: int64(len(opusFile())),
+			Duration: 5 * time.Second, Bitrate: len(opusFile()) * 8 / 5, Channels: 2, SampleRate: 48000,
+		}},
+		{"wav", wavFile(), Info{
+			Format: WAV, MIMEType: "audio/wav", Codec: "pcm", Size: int64(len(wavFile())),
+			Duration: 2 * time.Second, Bitrate: 64000, Channels: 1, SampleRate: 8000,
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := Probe(bytes.NewReader(tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, *info); diff != "" {
+				t.Errorf("Probe() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProbeUnknownFormat(t *testing.T) {
+	for _, file := range []string{"", "not media at all", "ID3"} {
+		if _, err := Probe(strings.NewReader(file)); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("expected ErrUnknownFormat for %q, got %v", file, err)
+		}
+	}
+
+	if _, err := Probe(bytes.NewReader(mp4File("M4A ", "soun")[:30])); err == nil {
+		t.Errorf("expected an error for a truncated mp4")
+	}
+}
+
+func TestPopulateEpisode(t *testing.T) {
+	info := Info{MIMEType: "audio/mpeg", Size: 1234, Duration: 61500 * time.Millisecond}
+
+	episode := podcast.Episode{}
+	info.PopulateEpisode(&episode)
+	expected := podcast.Episode{
+		Item:           rss.Item{Enclosure: &rss.Enclosure{Length: 1234, Type: "audio/mpeg"}},
+		ItunesDuration: "62",
+	}
+	if diff := cmp.Diff(expected, episode); diff != "" {
+		t.Errorf("PopulateEpisode() mismatch (-want +got):\n%s", diff)
+	}
+
+	episode = podcast.Episode{Item: rss.Item{Enclosure: &rss.Enclosure{URL: "https://example.com/1.mp3"}}}
+	info.PopulateEpisode(&episode)
+	if episode.Enclosure.URL != "https://example.com/1.mp3" || episode.Enclosure.Length != 1234 {
+		t.Errorf("expected the enclosure URL to be kept, got %+v", episode.Enclosure)
+	}
+}
@@ -0,0 +1,174 @@
+package probe
+
+import (
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Tags is the descriptive metadata embedded in a media file, read from ID3v2
+// tags in MP3 files and from the iTunes metadata and chapter tracks of MP4
+// files. Missing fields are left as zero.
+type Tags struct {
+	Title  string
+	Artist string
+	Album  string
+
+	// Description is the show notes, from the ID3 TDES or COMM frames, or the
+	// MP4 ldes or desc items.
+	Description string
+
+	// Date is the recording or release date as written in the file, usually
+	// a year or an ISO 8601 date.
+	Date string
+
+	// Episode is the ID3 track number, or the MP4 tves item falling back to
+	// the track number.
+	Episode int
+
+	// Season is the ID3 disc number (TPOS), or the MP4 tvsn item.
+	Season int
+
+	// Artwork is the cover picture, if there is one.
+	Artwork *Picture
+
+	// Chapters from ID3 CHAP frames, or from the chapter track or Nero chpl
+	// box of MP4 files, sorted by start time.
+	Chapters []Chapter
+}
+
+// Picture is an image embedded in a media file
+type Picture struct {
+	MIMEType    string
+	Description string
+	Data        []byte
+}
+
+// Chapter is a chapter mark embedded in a media file
+type Chapter struct {
+	Start time.Duration
+
+	// End is zero if the file doesn't say when the chapter ends
+	End time.Duration
+
+	Title string
+
+	// URL is a web page related to the chapter, from an ID3 WXXX frame
+	URL string
+
+	// Image is a picture for the chapter, from an ID3 APIC frame
+	Image *Picture
+
+	// Hidden chapters aren't listed in any ID3 table of contents (CTOC), so
+	// should not be shown to listeners, but may still change the artwork.
+	Hidden bool
+}
+
+// FileTags reads the tags of the media file with the given name.
+func FileTags(name string) (*Tags, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTags(f)
+}
+
+// ReadTags reads the tags of an MP3 or MP4 file. An MP3 file without an ID3v2
+// tag has empty Tags. Other formats return [ErrUnknownFormat].
+func ReadTags(r io.ReadSeeker) (*Tags, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var magic [8]byte
+	n, err := io.ReadFull(r, magic[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	tags := &Tags{}
+	switch m := magic[:n]; {
+	case hasPrefix(m, "ID3"):
+		err = readID3v2(r, tags)
+	case len(m) >= 8 && string(m[4:8]) == "ftyp":
+		err = readMP4Tags(r, size, tags)
+	default:
+		// An MP3 file may have no tag at all, anything else is unsupported
+		if info, probeErr := Probe(r); probeErr != nil || info.Format != MP3 {
+			return nil, ErrUnknownFormat
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tags.Chapters, func(i, j int) bool {
+		return tags.Chapters[i].Start < tags.Chapters[j].Start
+	})
+	return tags, nil
+}
+
+// PopulateEpisode fills in the title, description, episode and season numbers
+// of an episode from the tags, leaving fields the tags don't have alone.
+//
+// Embedded artwork has to be hosted somewhere before a feed can point to it,
+// so pass the URL where Artwork is published as artworkURL to set
+// ItunesImage, or "" to leave it alone.
+func (t *Tags) PopulateEpisode(e *podcast.Episode, artworkURL string) {
+	if t.Title != "" {
+		e.Title = t.Title
+	}
+	if t.Description != "" {
+		e.Description = &rss.Description{Value: t.Description}
+	}
+	if t.Episode > 0 {
+		e.ItunesEpisode = t.Episode
+	}
+	if t.Season > 0 {
+		e.ItunesSeason = t.Season
+	}
+	if artworkURL != "" {
+		e.ItunesImage = &podcast.ItunesImageTag{Href: artworkURL}
+	}
+}
+
+// JSONChapters converts the embedded chapters into a [podcast.JSONChapters]
+// file, which can be published and linked with [podcast.PodcastChapters].
+// Chapter images are embedded, so aren't included.
+func (t *Tags) JSONChapters() podcast.JSONChapters {
+	chapters := podcast.JSONChapters{
+		Version:     podcast.JSONChaptersVersion,
+		Title:       t.Title,
+		Author:      t.Artist,
+		PodcastName: t.Album,
+		Chapters:    []podcast.JSONChapter{},
+	}
+
+	for _, chapter := range t.Chapters {
+		jsonChapter := podcast.JSONChapter{
+			StartTime: chapter.Start.Seconds(),
+			EndTime:   chapter.End.Seconds(),
+			Title:     chapter.Title,
+			URL:       chapter.URL,
+		}
+		if chapter.Hidden {
+			toc := false
+			jsonChapter.Toc = &toc
+		}
+		chapters.Chapters = append(chapters.Chapters, jsonChapter)
+	}
+
+	return chapters
+}
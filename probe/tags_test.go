@@ -0,0 +1,295 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/podcast"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// id3v23Frame builds an ID3v2.3 frame, whose size isn't syncsafe
+func id3v23Frame(id string, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	frame := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+	return append(append(frame, 0, 0), data...)
+}
+
+// id3v24Frame builds an ID3v2.4 frame, whose size is syncsafe
+func id3v24Frame(id string, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	size := len(data)
+	frame := append([]byte(id), byte(size>>21&0x7F), byte(size>>14&0x7F), byte(size>>7&0x7F), byte(size&0x7F))
+	return append(append(frame, 0, 0), data...)
+}
+
+func id3Tag(version byte, frames ...[]byte) []byte {
+	data := bytes.Join(frames, nil)
+	data = append(data, make([]byte, 32)...) // padding
+	size := len(data)
+	header := []byte{'I', 'D', '3', version, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, data...)
+}
+
+func latin1(s string) []byte {
+	return append([]byte{id3Latin1}, s...)
+}
+
+func utf16LE(s string) []byte {
+	data := []byte{id3UTF16, 0xFF, 0xFE}
+	for _, r := range s {
+		data = append(data, byte(r), 0)
+	}
+	return data
+}
+
+func ms(n uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, n)
+}
+
+func TestReadID3v23Tags(t *testing.T) {
+	noOffset := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0}
+
+	tag := id3Tag(3,
+		id3v23Frame("TIT2", utf16LE("Episode 12: Chapters")),
+		id3v23Frame("TPE1", latin1("Jayden Milne")),
+		id3v23Frame("TALB", latin1("The Go Podcast")),
+		id3v23Frame("TYER", latin1("2024")),
+		id3v23Frame("TRCK", latin1("12/20")),
+		id3v23Frame("TPOS", latin1("2")),
+		id3v23Frame("COMM", []byte{id3Latin1}, []byte("eng"), []byte("\x00"), []byte("Show notes, caf\xe9 edition")),
+		id3v23Frame("APIC", []byte{id3Latin1}, []byte("image/png\x00"), []byte{4}, []byte("back\x00"), []byte{0x89, 'P', 'N', 'G'}),
+		id3v23Frame("APIC", []byte{id3Latin1}, []byte("image/jpeg\x00"), []byte{3}, []byte("cover\x00"), jpeg),
+		id3v23Frame("CTOC", []byte("toc\x00"), []byte{0x03, 2}, []byte("ch1\x00ch0\x00"), id3v23Frame("TIT2", latin1("Contents"))),
+		id3v23Frame("CHAP", []byte("ch1\x00"), ms(61500), ms(125000), noOffset,
+			id3v23Frame("TIT2", latin1("Interview")),
+			id3v23Frame("WXXX", []byte{id3Latin1}, []byte("\x00"), []byte("https://example.com/guest")),
+		),
+		id3v23Frame("CHAP", []byte("ch0\x00"), ms(0), ms(61500), noOffset,
+			id3v23Frame("TIT2", latin1("Intro")),
+			id3v23Frame("APIC", []byte{id3Latin1}, []byte("image/jpeg\x00"), []byte{0}, []byte("\x00"), jpeg),
+		),
+		id3v23Frame("CHAP", []byte("ad\x00"), ms(30000), ms(45000), noOffset,
+			id3v23Frame("TIT2", latin1("Sponsor")),
+		),
+	)
+
+	tags, err := ReadTags(bytes.NewReader(append(tag, mp3Frames(10)...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Tags{
+		Title:       "Episode 12: Chapters",
+		Artist:      "Jayden Milne",
+		Album:       "The Go Podcast",
+		Description: "Show notes, café edition",
+		Date:        "2024",
+		Episode:     12,
+		Season:      2,
+		Artwork:     &Picture{MIMEType: "image/jpeg", Description: "cover", Data: jpeg},
+		Chapters: []Chapter{
+			{Start: 0, End: 61500 * time.Millisecond, Title: "Intro", Image: &Picture{MIMEType: "image/jpeg", Data: jpeg}},
+			{Start: 30 * time.Second, End: 45 * time.Second, Title: "Sponsor", Hidden: true},
+			{Start: 61500 * time.Millisecond, End: 125 * time.Second, Title: "Interview", URL: "https://example.com/guest"},
+		},
+	}
+	if diff := cmp.Diff(expected, tags); diff != "" {
+		t.Errorf("ReadTags() mismatch (-want +got):\n%s", diff)
+	}
+
+	toc := false
+	expectedChapters := podcast.JSONChapters{
+		Version:     podcast.JSONChaptersVersion,
+		Title:       "Episode 12: Chapters",
+		Author:      "Jayden Milne",
+		PodcastName: "The Go Podcast",
+		Chapters: []podcast.JSONChapter{
+			{StartTime: 0, EndTime: 61.5, Title: "Intro"},
+			{StartTime: 30, EndTime: 45, Title: "Sponsor", Toc: &toc},
+			{StartTime: 61.5, EndTime: 125, Title: "Interview", URL: "https://example.com/guest"},
+		},
+	}
+	if diff := cmp.Diff(expectedChapters, tags.JSONChapters()); diff != "" {
+		t.Errorf("JSONChapters() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadID3v24Tags(t *testing.T) {
+	// A frame over 127 bytes, to tell syncsafe sizes apart
+	notes := strings.Repeat("Long notes. ", 20)
+
+	tag := id3Tag(4,
+		id3v24Frame("TIT2", []byte{id3UTF8}, []byte("Épisode\x00Deux")),
+		id3v24Frame("TDES", []byte{id3UTF8}, []byte(notes)),
+		id3v24Frame("COMM", []byte{id3Latin1}, []byte("eng"), []byte("\x00"), []byte("Ignored")),
+		id3v24Frame("TDRC", []byte{id3UTF8}, []byte("2024-03-01")),
+	)
+
+	tags, err := ReadTags(bytes.NewReader(tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Tags{Title: "Épisode/Deux", Description: notes, Date: "2024-03-01"}
+	if diff := cmp.Diff(expected, tags); diff != "" {
+		t.Errorf("ReadTags() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func mp4Item(typ string, dataType uint32, value []byte) []byte {
+	return box(typ, box("data", binary.BigEndian.AppendUint32(nil, dataType), []byte{0, 0, 0, 0}, value))
+}
+
+func mp4FullBox(typ string, content ...[]byte) []byte {
+	return box(typ, append([]byte{0, 0, 0, 0}, bytes.Join(content, nil)...))
+}
+
+// mp4ChapterFile has an audio track whose chapters are in a text track. The
+// chapter titles are in the mdat box at the start of the file.
+func mp4ChapterFile() []byte {
+	ftyp := box("ftyp", []byte("M4A "), []byte{0, 0, 0, 0})
+	samples := [][]byte{
+		append([]byte{0, 5}, "Intro"...),
+		append([]byte{0, 9}, "Interview"...),
+		// UTF-16 with a byte order mark, and a trailing encd box
+		append([]byte{0, 6, 0xFE, 0xFF, 0, 'A', 0, 'd'}, box("encd", []byte{0, 0, 1, 0})...),
+	}
+	mdat := box("mdat", samples...)
+	offset := uint32(len(ftyp) + 8)
+
+	tkhd := func(id uint32) []byte {
+		content := make([]byte, 80)
+		binary.BigEndian.PutUint32(content[8:], id)
+		return mp4FullBox("tkhd", content)
+	}
+	mdhd := make([]byte, 20)
+	binary.BigEndian.PutUint32(mdhd[8:], 1000)
+
+	ilst := box("ilst",
+		mp4Item("\xa9nam", 1, []byte("Episode 3")),
+		mp4Item("\xa9ART", 1, []byte("Jayden Milne")),
+		mp4Item("desc", 1, []byte("Short notes")),
+		mp4Item("ldes", 1, []byte("Long notes")),
+		mp4Item("trkn", 0, []byte{0, 0, 0, 7, 0, 10, 0, 0}),
+		mp4Item("tves", 21, []byte{0, 0, 0, 3}),
+		mp4Item("tvsn", 21, []byte{0, 0, 0, 1}),
+		mp4Item("covr", 14, []byte{0x89, 'P', 'N', 'G'}),
+	)
+
+	moov := box("moov",
+		mp4FullBox("mvhd", make([]byte, 96)),
+		box("trak",
+			tkhd(1),
+			box("tref", box("chap", binary.BigEndian.AppendUint32(nil, 2))),
+			box("mdia", mp4FullBox("hdlr", make([]byte, 4), []byte("soun"), make([]byte, 12))),
+		),
+		box("trak",
+			tkhd(2),
+			box("mdia",
+				mp4FullBox("mdhd", mdhd),
+				mp4FullBox("hdlr", make([]byte, 4), []byte("text"), make([]byte, 12)),
+				box("minf", box("stbl",
+					mp4FullBox("stts", ms(2), ms(2), ms(60000), ms(1), ms(30000)),
+					mp4FullBox("stsz", ms(0), ms(3), ms(uint32(len(samples[0]))), ms(uint32(len(samples[1]))), ms(uint32(len(samples[2])))),
+					// Two chunks, the first with two samples
+					mp4FullBox("stsc", ms(2), ms(1), ms(2), ms(1), ms(2), ms(1), ms(1)),
+					mp4FullBox("stco", ms(2), ms(offset), ms(offset+uint32(len(samples[0])+len(samples[1])))),
+				)),
+			),
+		),
+		box("udta",
+			box("meta", []byte{0, 0, 0, 0}, mp4FullBox("hdlr", make([]byte, 4), []byte("mdir"), make([]byte, 12)), ilst),
+			// Ignored in favour of the chapter track
+			mp4FullBox("chpl", []byte{1}, binary.BigEndian.AppendUint64(nil, 0), []byte{4}, []byte("Nero")),
+		),
+	)
+
+	return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+}
+
+func TestReadMP4Tags(t *testing.T) {
+	tags, err := ReadTags(bytes.NewReader(mp4ChapterFile()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Tags{
+		Title:       "Episode 3",
+		Artist:      "Jayden Milne",
+		Description: "Long notes",
+		Episode:     3,
+		Season:      1,
+		Artwork:     &Picture{MIMEType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}},
+		Chapters: []Chapter{
+			{Start: 0, End: 60 * time.Second, Title: "Intro"},
+			{Start: 60 * time.Second, End: 120 * time.Second, Title: "Interview"},
+			{Start: 120 * time.Second, End: 150 * time.Second, Title: "Ad"},
+		},
+	}
+	if diff := cmp.Diff(expected, tags); diff != "" {
+		t.Errorf("ReadTags() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadMP4NeroChapters(t *testing.T) {
+	chpl := mp4FullBox("chpl", []byte{2},
+		binary.BigEndian.AppendUint64(nil, 0), []byte{5}, []byte("Intro"),
+		binary.BigEndian.AppendUint64(nil, 615_000_000), []byte{5}, []byte("Outro"),
+	)
+	file := append(box("ftyp", []byte("M4A "), []byte{0, 0, 0, 0}), box("moov", box("udta", chpl))...)
+
+	tags, err := ReadTags(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Chapter{
+		{Start: 0, Title: "Intro"},
+		{Start: 61500 * time.Millisecond, Title: "Outro"},
+	}
+	if diff := cmp.Diff(expected, tags.Chapters); diff != "" {
+		t.Errorf("ReadTags() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadTagsWithoutTags(t *testing.T) {
+	tags, err := ReadTags(bytes.NewReader(mp3Frames(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&Tags{}, tags); diff != "" {
+		t.Errorf("expected no tags (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadTags(bytes.NewReader(wavFile())); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat for a wav file, got %v", err)
+	}
+}
+
+func TestTagsPopulateEpisode(t *testing.T) {
+	tags := Tags{Title: "Episode 3", Description: "Notes", Episode: 3, Season: 1}
+
+	episode := podcast.Episode{Item: rss.Item{Link: "https://example.com/3"}}
+	tags.PopulateEpisode(&episode, "https://example.com/3.jpg")
+
+	expected := podcast.Episode{
+		Item: rss.Item{
+			Title:       "Episode 3",
+			Link:        "https://example.com/3",
+			Description: &rss.Description{Value: "Notes"},
+		},
+		ItunesImage:   &podcast.ItunesImageTag{Href: "https://example.com/3.jpg"},
+		ItunesEpisode: 3,
+		ItunesSeason:  1,
+	}
+	if diff := cmp.Diff(expected, episode); diff != "" {
+		t.Errorf("PopulateEpisode() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,70 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// wavFormats names the codecs of the common WAVE format tags
+var wavFormats = map[uint16]string{
+	0x0001: "pcm",
+	0x0003: "float",
+	0x0006: "alaw",
+	0x0007: "ulaw",
+	0x0055: "mp3",
+	0xFFFE: "pcm", // WAVE_FORMAT_EXTENSIBLE, almost always PCM
+}
+
+func probeWAV(r io.ReadSeeker, info *Info) error {
+	info.Format = WAV
+	info.MIMEType = "audio/wav"
+
+	var byteRate, dataSize int64
+	foundFormat := false
+
+	// Chunks follow the 12 byte RIFF header, padded to an even size
+	for offset := int64(12); offset+8 <= info.Size; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return formatError(WAV, "truncated chunk")
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:]))
+
+		switch string(header[:4]) {
+		case "fmt ":
+			var format [16]byte
+			if size < 16 {
+				return formatError(WAV, "fmt chunk too short")
+			}
+			if _, err := io.ReadFull(r, format[:]); err != nil {
+				return formatError(WAV, "truncated fmt chunk")
+			}
+			info.Codec = wavFormats[binary.LittleEndian.Uint16(format[0:])]
+			info.Channels = int(binary.LittleEndian.Uint16(format[2:]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(format[4:]))
+			byteRate = int64(binary.LittleEndian.Uint32(format[8:]))
+			foundFormat = true
+		case "data":
+			dataSize = size
+			// Streamed files may not know their size when writing the header
+			if remaining := info.Size - offset - 8; dataSize > remaining || dataSize == 0 {
+				dataSize = remaining
+			}
+		}
+
+		offset += 8 + size + size%2
+	}
+
+	if !foundFormat {
+		return formatError(WAV, "no fmt chunk")
+	}
+
+	info.Bitrate = int(byteRate * 8)
+	if byteRate > 0 {
+		info.Duration = durationOf(dataSize, byteRate)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package rss
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// rfc2822Layouts are the layouts tried, in order, by [RFC2822Date.Time]. Feeds
+// in the wild drop the weekday, the seconds, or use single digit days, so
+// we accept all of those along with [RFC2822DateFormatSpecifier].
+var rfc2822Layouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"Mon, 02 Jan 2006 15:04 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"02 Jan 2006 15:04:05 -0700",
+	"02 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	RFC2822DateFormatSpecifier,
+}
+
+// NewRFC2822Date formats t as an RFC 2822 date, such as
+//
+//	Sat, 01 Apr 2023 19:00:00 +0000
+func NewRFC2822Date(t time.Time) RFC2822Date {
+	return RFC2822Date(t.Format(time.RFC1123Z))
+}
+
+// Time parses the date. It is lenient about the formatting mistakes commonly
+// found in real feeds, such as a missing weekday or seconds.
+func (d RFC2822Date) Time() (time.Time, error) {
+	value := strings.TrimSpace(string(d))
+	if value == "" {
+		return time.Time{}, fmt.Errorf("rss: empty date")
+	}
+
+	for _, layout := range rfc2822Layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("rss: unrecognized date %q", value)
+}
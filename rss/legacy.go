@@ -0,0 +1,348 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const RSS091Version = "0.91"
+const RSS092Version = "0.92"
+
+// RSS10Version is reported by [Decode] for RDF based RSS 1.0 documents, which
+// carry no version attribute of their own.
+const RSS10Version = "1.0"
+
+const RDFNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+const RSS10Namespace = "http://purl.org/rss/1.0/"
+const DublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+
+// RSS10EnclosureNamespace is the namespace of the [mod_enclosure] module, the
+// usual way of attaching media to an RSS 1.0 item.
+//
+// [mod_enclosure]: http://www.xs4all.nl/~foz/mod_enclosure.html
+const RSS10EnclosureNamespace = "http://purl.oclc.org/net/rss_2.0/enc#"
+
+// Decode reads an RSS 0.91, 0.92, 1.0 (RDF) or 2.0 document and normalizes it
+// into an [RSS].
+//
+// RSS 0.91 and 0.92 are close enough to RSS 2.0 that they decode directly,
+// the only difference being that the lowercase <textinput> element is mapped
+// onto [Channel.TextInput]. RSS 1.0 documents are decoded into an [RDF] and
+// converted with [RDF.RSS].
+//
+// [RSS.Version] is left as the version of the source document ([RSS10Version]
+// for RSS 1.0), so set it to [RSSVersion] before re-encoding if you want a
+// valid RSS 2.0 feed.
+//
+// The HTML entities declared by the RSS 0.91 DTD (&eacute; and friends) are
+// accepted for every version.
+func Decode(r io.Reader) (*RSS, error) {
+	decoder := GetDecoder(r)
+	decoder.Entity = xml.HTMLEntity
+
+	start, err := RootElement(decoder)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeElement(decoder, start)
+}
+
+// DecodeElement is [Decode] for a document whose root element start was
+// already read, such as with [RootElement].
+func DecodeElement(decoder *xml.Decoder, start xml.StartElement) (*RSS, error) {
+	if IsRDF(start) {
+		var rdf RDF
+		if err := decoder.DecodeElement(&rdf, &start); err != nil {
+			return nil, err
+		}
+		return rdf.RSS(), nil
+	}
+
+	if start.Name.Local != "rss" {
+		return nil, fmt.Errorf("rss: unsupported root element <%s>", start.Name.Local)
+	}
+
+	var legacy legacyRSS
+	if err := decoder.DecodeElement(&legacy, &start); err != nil {
+		return nil, err
+	}
+
+	feed := RSS{
+		XMLName: legacy.XMLName,
+		Channel: legacy.Channel.Channel,
+		Version: legacy.Version,
+	}
+	if feed.Channel.TextInput == nil && legacy.Channel.LegacyTextInput != nil {
+		feed.Channel.TextInput = &TextInput{
+			Title:       legacy.Channel.LegacyTextInput.Title,
+			Description: legacy.Channel.LegacyTextInput.Description,
+			Name:        legacy.Channel.LegacyTextInput.Name,
+			Link:        legacy.Channel.LegacyTextInput.Link,
+		}
+	}
+
+	return &feed, nil
+}
+
+// RootElement advances decoder to the document's root element and returns it,
+// skipping the XML declaration, comments and any DOCTYPE. Pass the result to
+// [xml.Decoder.DecodeElement].
+func RootElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// IsLegacy reports if start is the <rss> root of an RSS 0.91 or 0.92
+// document.
+func IsLegacy(start xml.StartElement) bool {
+	if start.Name.Local != "rss" {
+		return false
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "version" {
+			version := strings.TrimSpace(attr.Value)
+			return version == RSS091Version || version == RSS092Version
+		}
+	}
+	return false
+}
+
+// IsRDF reports if start is the <rdf:RDF> root of an RSS 1.0 document.
+func IsRDF(start xml.StartElement) bool {
+	return start.Name.Space == RDFNamespace && start.Name.Local == "RDF"
+}
+
+// legacyRSS accepts the RSS 0.91 spelling of <textinput> alongside everything
+// RSS 2.0 has.
+type legacyRSS struct {
+	XMLName xml.Name      `xml:"https://www.rssboard.org/rss-specification rss"`
+	Channel legacyChannel `xml:"https://www.rssboard.org/rss-specification channel"`
+	Version string        `xml:"version,attr,omitempty"`
+}
+
+type legacyChannel struct {
+	Channel
+
+	LegacyTextInput *struct {
+		Title       string `xml:"https://www.rssboard.org/rss-specification title"`
+		Description string `xml:"https://www.rssboard.org/rss-specification description"`
+		Name        string `xml:"https://www.rssboard.org/rss-specification name"`
+		Link        string `xml:"https://www.rssboard.org/rss-specification link"`
+	} `xml:"https://www.rssboard.org/rss-specification textinput"`
+}
+
+// RDF is the root of an [RSS 1.0] document. Unlike RSS 2.0 the image, items
+// and text input are siblings of the channel rather than children of it.
+//
+// Use [RDF.RSS] to convert it to an [RSS].
+//
+// [RSS 1.0]: https://web.resource.org/rss/1.0/spec
+type RDF struct {
+	XMLName xml.Name `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+
+	Channel   RDFChannel    `xml:"http://purl.org/rss/1.0/ channel"`
+	Image     *RDFImage     `xml:"http://purl.org/rss/1.0/ image,omitempty"`
+	Items     []RDFItem     `xml:"http://purl.org/rss/1.0/ item"`
+	TextInput *RDFTextInput `xml:"http://purl.org/rss/1.0/ textinput,omitempty"`
+}
+
+// RDFChannel contains metadata describing the channel itself, along with the
+// commonly used [Dublin Core] elements.
+//
+// [Dublin Core]: https://web.resource.org/rss/1.0/modules/dc/
+type RDFChannel struct {
+	XMLName xml.Name `xml:"http://purl.org/rss/1.0/ channel"`
+
+	// About is the URI of the channel, usually the URL of the feed itself.
+	About string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+
+	Title       string `xml:"http://purl.org/rss/1.0/ title"`
+	Link        string `xml:"http://purl.org/rss/1.0/ link"`
+	Description string `xml:"http://purl.org/rss/1.0/ description"`
+
+	// Date is a [W3CDTF] date, such as 2002-09-01T12:00:00+00:00
+	//
+	// [W3CDTF]: https://www.w3.org/TR/NOTE-datetime
+	Date      string   `xml:"http://purl.org/dc/elements/1.1/ date,omitempty"`
+	Creator   string   `xml:"http://purl.org/dc/elements/1.1/ creator,omitempty"`
+	Publisher string   `xml:"http://purl.org/dc/elements/1.1/ publisher,omitempty"`
+	Rights    string   `xml:"http://purl.org/dc/elements/1.1/ rights,omitempty"`
+	Language  string   `xml:"http://purl.org/dc/elements/1.1/ language,omitempty"`
+	Subjects  []string `xml:"http://purl.org/dc/elements/1.1/ subject,omitempty"`
+}
+
+// RDFImage is an image to be associated with an HTML rendering of the channel.
+type RDFImage struct {
+	XMLName xml.Name `xml:"http://purl.org/rss/1.0/ image"`
+
+	About string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title string `xml:"http://purl.org/rss/1.0/ title"`
+	URL   string `xml:"http://purl.org/rss/1.0/ url"`
+	Link  string `xml:"http://purl.org/rss/1.0/ link"`
+}
+
+// RDFItem is an RSS 1.0 item. An item may carry media via the mod_enclosure
+// module, see [RSS10EnclosureNamespace].
+type RDFItem struct {
+	XMLName xml.Name `xml:"http://purl.org/rss/1.0/ item"`
+
+	// About is the URI of the item, usually the same as Link.
+	About string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+
+	Title       string `xml:"http://purl.org/rss/1.0/ title"`
+	Link        string `xml:"http://purl.org/rss/1.0/ link"`
+	Description string `xml:"http://purl.org/rss/1.0/ description,omitempty"`
+
+	Date     string   `xml:"http://purl.org/dc/elements/1.1/ date,omitempty"`
+	Creator  string   `xml:"http://purl.org/dc/elements/1.1/ creator,omitempty"`
+	Subjects []string `xml:"http://purl.org/dc/elements/1.1/ subject,omitempty"`
+
+	Enclosures []RDFEnclosure `xml:"http://purl.oclc.org/net/rss_2.0/enc# enclosure,omitempty"`
+}
+
+// RDFEnclosure is a mod_enclosure <enc:enclosure>
+//
+// Example:
+//
+//	<enc:enclosure rdf:resource="http://example.com/ep1.mp3" enc:length="5650889" enc:type="audio/mpeg" />
+type RDFEnclosure struct {
+	XMLName xml.Name `xml:"http://purl.oclc.org/net/rss_2.0/enc# enclosure"`
+
+	Resource string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# resource,attr"`
+	Length   int    `xml:"http://purl.oclc.org/net/rss_2.0/enc# length,attr,omitempty"`
+	Type     string `xml:"http://purl.oclc.org/net/rss_2.0/enc# type,attr,omitempty"`
+}
+
+// RDFTextInput is the RSS 1.0 equivalent of [TextInput].
+type RDFTextInput struct {
+	XMLName xml.Name `xml:"http://purl.org/rss/1.0/ textinput"`
+
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"http://purl.org/rss/1.0/ title"`
+	Description string `xml:"http://purl.org/rss/1.0/ description"`
+	Name        string `xml:"http://purl.org/rss/1.0/ name"`
+	Link        string `xml:"http://purl.org/rss/1.0/ link"`
+}
+
+// RSS converts an RSS 1.0 document into an [RSS]. The fields are mapped as
+// follows:
+//
+//	channel/title, link, description  -> Channel.Title, Link, Description
+//	channel/dc:language               -> Channel.Language
+//	channel/dc:rights                 -> Channel.Copyright
+//	channel/dc:creator                -> Channel.ManagingEditor
+//	channel/dc:date                   -> Channel.PubDate
+//	channel/dc:subject                -> Channel.Categories
+//	image                             -> Channel.Image
+//	textinput                         -> Channel.TextInput
+//	item/title, link, description     -> Item.Title, Link, Description
+//	item/@rdf:about                   -> Item.GUID (a permalink if equal to link)
+//	item/dc:creator                   -> Item.Author
+//	item/dc:date                      -> Item.PubDate
+//	item/dc:subject                   -> Item.Categories
+//	item/enc:enclosure (first)        -> Item.Enclosure
+//
+// Dates are converted from W3CDTF to RFC 2822, and copied verbatim if they
+// can't be parsed. dc:publisher has no RSS 2.0 equivalent and is dropped.
+func (rdf *RDF) RSS() *RSS {
+	channel := Channel{
+		Title:          rdf.Channel.Title,
+		Link:           rdf.Channel.Link,
+		Description:    Description{Value: rdf.Channel.Description},
+		Language:       rdf.Channel.Language,
+		Copyright:      rdf.Channel.Rights,
+		ManagingEditor: rdf.Channel.Creator,
+		PubDate:        w3cdtfToRFC2822(rdf.Channel.Date),
+		Categories:     subjectsToCategories(rdf.Channel.Subjects),
+	}
+
+	if rdf.Image != nil {
+		channel.Image = &Image{
+			URL:   rdf.Image.URL,
+			Title: rdf.Image.Title,
+			Link:  rdf.Image.Link,
+		}
+	}
+
+	if rdf.TextInput != nil {
+		channel.TextInput = &TextInput{
+			Title:       rdf.TextInput.Title,
+			Description: rdf.TextInput.Description,
+			Name:        rdf.TextInput.Name,
+			Link:        rdf.TextInput.Link,
+		}
+	}
+
+	for _, rdfItem := range rdf.Items {
+		item := Item{
+			Title:      rdfItem.Title,
+			Link:       rdfItem.Link,
+			Author:     rdfItem.Creator,
+			Categories: subjectsToCategories(rdfItem.Subjects),
+			PubDate:    w3cdtfToRFC2822(rdfItem.Date),
+		}
+
+		if rdfItem.Description != "" {
+			item.Description = &Description{Value: rdfItem.Description}
+		}
+
+		if rdfItem.About != "" {
+			isPermaLink := rdfItem.About == rdfItem.Link
+			item.GUID = &GUID{Value: rdfItem.About, IsPermaLink: &isPermaLink}
+		}
+
+		if len(rdfItem.Enclosures) > 0 {
+			enclosure := rdfItem.Enclosures[0]
+			item.Enclosure = &Enclosure{
+				URL:    enclosure.Resource,
+				Length: enclosure.Length,
+				Type:   enclosure.Type,
+			}
+		}
+
+		channel.Items = append(channel.Items, item)
+	}
+
+	return &RSS{
+		Channel: channel,
+		Version: RSS10Version,
+	}
+}
+
+func subjectsToCategories(subjects []string) []Category {
+	var categories []Category
+	for _, subject := range subjects {
+		categories = append(categories, Category{Value: subject})
+	}
+	return categories
+}
+
+// w3cdtfLayouts are the granularities allowed by https://www.w3.org/TR/NOTE-datetime
+var w3cdtfLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func w3cdtfToRFC2822(date string) RFC2822Date {
+	date = strings.TrimSpace(date)
+	for _, layout := range w3cdtfLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return NewRFC2822Date(t)
+		}
+	}
+	return RFC2822Date(date)
+}
@@ -0,0 +1,149 @@
+package rss
+
+import (
+	"bytes"
+	_ "embed"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+//go:embed samples/rss-091.xml
+var RSS091Sample []byte
+
+//go:embed samples/rss-092.xml
+var RSS092Sample []byte
+
+//go:embed samples/rss-10.xml
+var RSS10Sample []byte
+
+func TestDecodeRSS091(t *testing.T) {
+	feed, err := Decode(bytes.NewReader(RSS091Sample))
+	if err != nil {
+		t.Fatalf("failure to decode: %s", err)
+	}
+
+	if feed.Version != RSS091Version {
+		t.Errorf("expected version %s, got %s", RSS091Version, feed.Version)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(feed.Channel.Items))
+	}
+	if feed.Channel.Image == nil || feed.Channel.Image.Width != 88 {
+		t.Errorf("image not decoded: %+v", feed.Channel.Image)
+	}
+
+	expectedTextInput := &TextInput{
+		Title:       "Search",
+		Description: "Search WriteTheWeb",
+		Name:        "q",
+		Link:        "http://writetheweb.com/search",
+	}
+	if !cmp.Equal(expectedTextInput, feed.Channel.TextInput) {
+		t.Errorf("textinput didn't match! %s", cmp.Diff(expectedTextInput, feed.Channel.TextInput))
+	}
+}
+
+func TestDecodeRSS092(t *testing.T) {
+	feed, err := Decode(bytes.NewReader(RSS092Sample))
+	if err != nil {
+		t.Fatalf("failure to decode: %s", err)
+	}
+
+	if feed.Version != RSS092Version {
+		t.Errorf("expected version %s, got %s", RSS092Version, feed.Version)
+	}
+	if feed.Channel.Cloud == nil || feed.Channel.Cloud.Protocol != CloudProtocolXMLRPC {
+		t.Errorf("cloud not decoded: %+v", feed.Channel.Cloud)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(feed.Channel.Items))
+	}
+
+	enclosure := feed.Channel.Items[1].Enclosure
+	if enclosure == nil || enclosure.Length != 18217472 || enclosure.Type != "audio/mpeg" {
+		t.Errorf("enclosure not decoded: %+v", enclosure)
+	}
+}
+
+func TestDecodeRSS10(t *testing.T) {
+	feed, err := Decode(bytes.NewReader(RSS10Sample))
+	if err != nil {
+		t.Fatalf("failure to decode: %s", err)
+	}
+
+	permalink := true
+	notPermalink := false
+	expected := &RSS{
+		Version: RSS10Version,
+		Channel: Channel{
+			Title:          "XML.com",
+			Link:           "http://xml.com/pub",
+			Description:    Description{Value: "XML.com features a rich mix of information and services for the XML community."},
+			Language:       "en-us",
+			Copyright:      "Copyright 2000, O'Reilly & Associates, Inc.",
+			ManagingEditor: "editor@xml.com (Edd Dumbill)",
+			PubDate:        "Sat, 01 Jan 2000 12:00:00 +0000",
+			Categories:     []Category{{Value: "XML"}},
+			Image: &Image{
+				URL:   "http://xml.com/universal/images/xml_tiny.gif",
+				Title: "XML.com",
+				Link:  "http://www.xml.com",
+			},
+			TextInput: &TextInput{
+				Title:       "Search XML.com",
+				Description: "Search XML.com's XML collection",
+				Name:        "s",
+				Link:        "http://search.xml.com",
+			},
+			Items: []Item{
+				{
+					Title:       "Processing Inclusions with XSLT",
+					Link:        "http://xml.com/pub/2000/08/09/xslt/xslt.html",
+					Description: &Description{Value: "Processing document inclusions with general XML tools can be problematic."},
+					Author:      "Bob DuCharme",
+					Categories:  []Category{{Value: "XSLT"}},
+					Enclosure: &Enclosure{
+						URL:    "http://xml.com/pub/2000/08/09/xslt/xslt.mp3",
+						Length: 123456,
+						Type:   "audio/mpeg",
+					},
+					GUID:    &GUID{Value: "http://xml.com/pub/2000/08/09/xslt/xslt.html", IsPermaLink: &permalink},
+					PubDate: "Wed, 09 Aug 2000 00:00:00 +0000",
+				},
+				{
+					Title:       "Putting RDF to Work",
+					Link:        "http://xml.com/pub/2000/08/09/rdfdb/index.html",
+					Description: &Description{Value: "Tool and API support for the Resource Description Framework is slowly coming of age."},
+					GUID:        &GUID{Value: "urn:xml.com:rdfdb", IsPermaLink: &notPermalink},
+				},
+			},
+		},
+	}
+
+	if !cmp.Equal(expected, feed) {
+		t.Errorf("document didn't match! %s", cmp.Diff(expected, feed))
+	}
+}
+
+func TestRFC2822DateTime(t *testing.T) {
+	for _, date := range []RFC2822Date{
+		"Tue, 10 Jun 2003 04:00:00 GMT",
+		"Tue, 10 Jun 2003 04:00:00 +0000",
+		"Tue, 10 Jun 2003 04:00 GMT",
+		"10 Jun 2003 04:00:00 GMT",
+	} {
+		parsed, err := date.Time()
+		if err != nil {
+			t.Errorf("failed to parse %q: %s", date, err)
+			continue
+		}
+		if parsed.Unix() != 1055217600 {
+			t.Errorf("%q parsed as %s", date, parsed)
+		}
+	}
+
+	if _, err := RFC2822Date("yesterday").Time(); err == nil {
+		t.Errorf("expected an error for a nonsense date")
+	}
+}
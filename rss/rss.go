@@ -670,7 +670,7 @@ type Source struct {
 	Value   string   `xml:",chardata"`
 
 	// URL is required
-	URL string `xml:"https://www.rssboard.org/rss-specification url,omitempty"`
+	URL string `xml:"url,attr"`
 }
 
 // # RSS 2.0
@@ -380,7 +380,7 @@ var MoreComplexSampleExpected = RSS{
 						Local: "source",
 					},
 					Value: "https://stuff.com",
-					URL:   "",
+					URL:   "has_url",
 				},
 			}, Item{
 				XMLName: xml.Name{
@@ -0,0 +1,140 @@
+// Package rsscloud implements both sides of the [rssCloud] protocol described
+// by a channel's <cloud> element, in its http-post and xml-rpc variants:
+// subscribers register with a cloud to be notified when feeds change, and
+// clouds notify them when publishers ping.
+//
+// [rssCloud]: https://www.rssboard.org/rsscloud-interface
+package rsscloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// Procedures of the xml-rpc variant
+const (
+	PleaseNotifyProcedure = "rssCloud.pleaseNotify"
+	PingProcedure         = "rssCloud.ping"
+)
+
+// RegistrationLifetime is how long clouds keep registrations. Subscribers
+// should register again within it.
+const RegistrationLifetime = 25 * time.Hour
+
+// ErrUnsupportedProtocol is returned for clouds using protocols other than
+// http-post and xml-rpc, such as soap
+var ErrUnsupportedProtocol = errors.New("rsscloud: unsupported protocol")
+
+// endpoint is the URL of the cloud's responder
+func endpoint(cloud *rss.Cloud) (string, error) {
+	if cloud == nil || cloud.Domain == "" {
+		return "", fmt.Errorf("rsscloud: cloud has no domain")
+	}
+	host := cloud.Domain
+	if port := strings.TrimSpace(cloud.Port); port != "" && port != "80" {
+		host = net.JoinHostPort(cloud.Domain, port)
+	}
+	path := cloud.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "http", Host: host, Path: path}).String(), nil
+}
+
+// notifyResult is the response of an http-post cloud
+type notifyResult struct {
+	XMLName xml.Name `xml:"notifyResult"`
+	Success bool     `xml:"success,attr"`
+	Message string   `xml:"msg,attr"`
+}
+
+// rejectedError is returned by [call] when the other end answered, but with
+// an error, as opposed to not answering at all
+type rejectedError struct {
+	error
+}
+
+func (e rejectedError) Unwrap() error {
+	return e.error
+}
+
+// call makes a request to a cloud or subscriber and checks it succeeded.
+// contentType selects the variant: a form for http-post, or an XML-RPC call.
+func call(ctx context.Context, client *http.Client, method, target, contentType string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("rsscloud: %w", err)
+	}
+	if method == http.MethodPost {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("rsscloud: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return rejectedError{fmt.Errorf("rsscloud: %s: %s", target, response.Status)}
+	}
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<16))
+	if err != nil {
+		return fmt.Errorf("rsscloud: %w", err)
+	}
+
+	switch {
+	case contentType == xmlRPCContentType:
+		result, err := decodeResponse(bytes.NewReader(data))
+		if err != nil {
+			return rejectedError{err}
+		}
+		if ok, isBool := result.(bool); isBool && !ok {
+			return rejectedError{fmt.Errorf("rsscloud: %s returned false", target)}
+		}
+	case bytes.Contains(data, []byte("<notifyResult")):
+		var result notifyResult
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return rejectedError{fmt.Errorf("rsscloud: invalid response: %w", err)}
+		}
+		if !result.Success {
+			return rejectedError{fmt.Errorf("rsscloud: %s", result.Message)}
+		}
+	}
+	return nil
+}
+
+const (
+	formContentType   = "application/x-www-form-urlencoded"
+	xmlRPCContentType = "text/xml"
+)
+
+// Ping tells the cloud that the feed at feedURL changed, so it notifies the
+// feed's subscribers. client defaults to [http.DefaultClient].
+func Ping(ctx context.Context, client *http.Client, cloud *rss.Cloud, feedURL string) error {
+	target, err := endpoint(cloud)
+	if err != nil {
+		return err
+	}
+	switch cloud.Protocol {
+	case rss.CloudProtocolHTTPPost:
+		u, _ := url.Parse(target)
+		u.Path = "/ping"
+		return call(ctx, client, http.MethodPost, u.String(), formContentType, []byte(url.Values{"url": {feedURL}}.Encode()))
+	case rss.CloudProtocolXMLRPC:
+		return call(ctx, client, http.MethodPost, target, xmlRPCContentType, encodeCall(PingProcedure, feedURL))
+	}
+	return fmt.Errorf("%w %q", ErrUnsupportedProtocol, cloud.Protocol)
+}
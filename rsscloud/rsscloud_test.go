@@ -0,0 +1,230 @@
+package rsscloud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+func TestXMLRPC(t *testing.T) {
+	call := encodeCall("rssCloud.pleaseNotify", "river.notify", 5337, "/RPC2", "xml-rpc", []string{"https://example.com/a&b.xml"})
+	method, params, err := decodeCall(bytes.NewReader(call))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []any{"river.notify", 5337, "/RPC2", "xml-rpc", []any{"https://example.com/a&b.xml"}}
+	if method != "rssCloud.pleaseNotify" {
+		t.Errorf("unexpected method %q", method)
+	}
+	if diff := cmp.Diff(expected, params); diff != "" {
+		t.Errorf("unexpected params (-want +got):\n%s", diff)
+	}
+
+	if value, err := decodeResponse(bytes.NewReader(encodeResponse(true))); err != nil || value != true {
+		t.Errorf("expected true, got %v, %v", value, err)
+	}
+	if _, err := decodeResponse(bytes.NewReader(encodeFault(4, "no"))); err == nil || err.Error() != "rsscloud: fault 4: no" {
+		t.Errorf("expected a fault, got %v", err)
+	}
+	// Values without a type are strings
+	untyped := `<methodCall><methodName>rssCloud.ping</methodName><params><param><value>https://example.com/feed.xml</value></param></params></methodCall>`
+	if _, params, err := decodeCall(bytes.NewReader([]byte(untyped))); err != nil || params[0] != "https://example.com/feed.xml" {
+		t.Errorf("unexpected untyped value %v, %v", params, err)
+	}
+}
+
+// hostPort splits the host and port out of a test server's URL
+func hostPort(t *testing.T, serverURL string) (string, int) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(port)
+	return host, n
+}
+
+func TestCloud(t *testing.T) {
+	const feedURL = "https://example.com/feed.xml"
+	cloud := &Server{}
+	cloudServer := httptest.NewServer(cloud)
+	defer cloudServer.Close()
+	cloud.Domain, cloud.Port = hostPort(t, cloudServer.URL)
+
+	testCases := []struct {
+		name     string
+		protocol rss.CloudProtocol
+		domain   bool
+	}{
+		{"http-post with domain", rss.CloudProtocolHTTPPost, true},
+		{"http-post without domain", rss.CloudProtocolHTTPPost, false},
+		{"xml-rpc", rss.CloudProtocolXMLRPC, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var notified []string
+			subscriber := &Subscriber{
+				Path: "/notify",
+				OnNotify: func(feedURL string) {
+					mu.Lock()
+					defer mu.Unlock()
+					notified = append(notified, feedURL)
+				},
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/notify", subscriber)
+			subscriberServer := httptest.NewServer(mux)
+			defer subscriberServer.Close()
+			var domain string
+			domain, subscriber.Port = hostPort(t, subscriberServer.URL)
+			if tc.domain {
+				subscriber.Domain = domain
+			}
+
+			element := cloud.Cloud(tc.protocol)
+			if err := subscriber.Register(context.Background(), element, feedURL); err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			registrationNotices := len(notified)
+			mu.Unlock()
+
+			if err := Ping(context.Background(), nil, element, feedURL); err != nil {
+				t.Fatal(err)
+			}
+			if err := Ping(context.Background(), nil, element, "https://example.com/other.xml"); err != nil {
+				t.Fatal(err)
+			}
+			cloud.Wait()
+			mu.Lock()
+			defer mu.Unlock()
+			if diff := cmp.Diff([]string{feedURL}, notified[registrationNotices:]); diff != "" {
+				t.Errorf("unexpected notifications (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPingSlowSubscribers(t *testing.T) {
+	defer func(timeout time.Duration) { requestTimeout = timeout }(requestTimeout)
+	requestTimeout = 100 * time.Millisecond
+	const feedURL = "https://example.com/feed.xml"
+
+	cloud := &Server{}
+	cloudServer := httptest.NewServer(cloud)
+	defer cloudServer.Close()
+	cloud.Domain, cloud.Port = hostPort(t, cloudServer.URL)
+
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client leaving once the body is read
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unsubscribed", http.StatusGone)
+	}))
+	defer rejecting.Close()
+	notified := make(chan string, 1)
+	listening := httptest.NewServer(&Subscriber{
+		OnNotify:   func(feedURL string) { notified <- feedURL },
+		registered: map[string]bool{feedURL: true},
+	})
+	defer listening.Close()
+
+	expires := time.Now().Add(RegistrationLifetime)
+	subscribers := map[subscriber]time.Time{}
+	for _, target := range []string{hanging.URL, rejecting.URL, listening.URL} {
+		subscribers[subscriber{protocol: rss.CloudProtocolHTTPPost, target: target}] = expires
+	}
+	cloud.subscriptions = map[string]map[subscriber]time.Time{feedURL: subscribers}
+
+	start := time.Now()
+	if err := Ping(context.Background(), nil, cloud.Cloud(rss.CloudProtocolHTTPPost), feedURL); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= requestTimeout {
+		t.Errorf("expected the ping to be answered before notifying, took %s", elapsed)
+	}
+	cloud.Wait()
+
+	if notice := <-notified; notice != feedURL {
+		t.Errorf("unexpected notification %q", notice)
+	}
+	var targets []string
+	for sub := range cloud.subscriptions[feedURL] {
+		targets = append(targets, sub.target)
+	}
+	sort.Strings(targets)
+	expected := []string{hanging.URL, listening.URL}
+	sort.Strings(expected)
+	if diff := cmp.Diff(expected, targets); diff != "" {
+		t.Errorf("expected only the rejecting subscriber to be dropped (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriberUnregisteredFeeds(t *testing.T) {
+	var notified []string
+	subscriber := &Subscriber{
+		OnNotify:   func(feedURL string) { notified = append(notified, feedURL) },
+		registered: map[string]bool{"https://example.com/feed.xml": true},
+	}
+	post := func(contentType string, body []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		recorder := httptest.NewRecorder()
+		subscriber.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for _, feedURL := range []string{"https://example.com/feed.xml", "http://169.254.169.254/latest/meta-data/"} {
+		post(formContentType, []byte(url.Values{"url": {feedURL}}.Encode()))
+		post(xmlRPCContentType, encodeCall(DefaultNotifyProcedure, feedURL))
+	}
+	if response := post(formContentType, []byte("url=https%3A%2F%2Fexample.com%2Fother.xml")); response.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a feed that isn't registered, got %d", response.Code)
+	}
+	if diff := cmp.Diff([]string{"https://example.com/feed.xml", "https://example.com/feed.xml"}, notified); diff != "" {
+		t.Errorf("expected only notifications about registered feeds (-want +got):\n%s", diff)
+	}
+}
+
+func TestCloudErrors(t *testing.T) {
+	cloud := &Server{}
+	cloudServer := httptest.NewServer(cloud)
+	defer cloudServer.Close()
+	cloud.Domain, cloud.Port = hostPort(t, cloudServer.URL)
+
+	// Nobody is listening for notifications
+	closed := httptest.NewServer(http.NotFoundHandler())
+	domain, port := hostPort(t, closed.URL)
+	closed.Close()
+	subscriber := &Subscriber{Domain: domain, Port: port}
+	for _, protocol := range []rss.CloudProtocol{rss.CloudProtocolHTTPPost, rss.CloudProtocolXMLRPC} {
+		if err := subscriber.Register(context.Background(), cloud.Cloud(protocol), "https://example.com/feed.xml"); err == nil {
+			t.Errorf("%s: expected registering an unreachable subscriber to fail", protocol)
+		}
+	}
+
+	if err := subscriber.Register(context.Background(), cloud.Cloud(rss.CloudProtocolSOAP), "https://example.com/feed.xml"); !errors.Is(err, ErrUnsupportedProtocol) {
+		t.Errorf("expected soap to be unsupported, got %v", err)
+	}
+}
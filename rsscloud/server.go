@@ -0,0 +1,323 @@
+package rsscloud
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// requestTimeout limits each notification the server sends, so subscribers
+// that never answer don't hold up the others
+var requestTimeout = 30 * time.Second
+
+// The paths a [Server] responds to
+const (
+	PleaseNotifyPath = "/pleaseNotify"
+	PingPath         = "/ping"
+	RPCPath          = "/RPC2"
+)
+
+// Server is a lightweight cloud. It accepts registrations, and notifies the
+// subscribers of a feed when the feed's publisher pings, or [Server.Notify]
+// is called.
+type Server struct {
+	// Domain and Port are the server's public address, for [Server.Cloud]
+	Domain string
+	Port   int
+
+	// HTTPClient makes requests to subscribers. Defaults to
+	// [http.DefaultClient].
+	HTTPClient *http.Client
+
+	mu sync.Mutex
+	// subscriptions are by feed URL, then subscriber
+	subscriptions map[string]map[subscriber]time.Time
+	work          sync.WaitGroup
+}
+
+// subscriber is where and how to notify a subscriber
+type subscriber struct {
+	protocol  rss.CloudProtocol
+	target    string
+	procedure string
+}
+
+// Cloud is the <cloud> element for feeds using the server with protocol
+func (s *Server) Cloud(protocol rss.CloudProtocol) *rss.Cloud {
+	cloud := &rss.Cloud{
+		Domain:   s.Domain,
+		Port:     strconv.Itoa(s.Port),
+		Path:     PleaseNotifyPath,
+		Protocol: protocol,
+	}
+	if protocol == rss.CloudProtocolXMLRPC {
+		cloud.Path = RPCPath
+		cloud.RegisterProcedure = PleaseNotifyProcedure
+	}
+	return cloud
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.URL.Path {
+	case PleaseNotifyPath:
+		if err := r.ParseForm(); err != nil {
+			writeNotifyResult(w, err)
+			return
+		}
+		var feedURLs []string
+		for i := 1; r.PostForm.Has("url" + strconv.Itoa(i)); i++ {
+			feedURLs = append(feedURLs, r.PostForm.Get("url"+strconv.Itoa(i)))
+		}
+		port, _ := strconv.Atoi(r.PostForm.Get("port"))
+		err := s.register(r.Context(), r, subscriberRequest{
+			protocol:  rss.CloudProtocol(r.PostForm.Get("protocol")),
+			procedure: r.PostForm.Get("notifyProcedure"),
+			domain:    r.PostForm.Get("domain"),
+			port:      port,
+			path:      r.PostForm.Get("path"),
+			feedURLs:  feedURLs,
+		})
+		writeNotifyResult(w, err)
+	case PingPath:
+		writeNotifyResult(w, s.ping(r.PostFormValue("url")))
+	case RPCPath:
+		s.serveXMLRPC(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveXMLRPC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", xmlRPCContentType)
+	method, params, err := decodeCall(io.LimitReader(r.Body, 1<<20))
+	if err == nil {
+		switch method {
+		case PleaseNotifyProcedure:
+			err = s.registerXMLRPC(r, params)
+		case PingProcedure:
+			feedURL, _ := firstParam(params).(string)
+			err = s.ping(feedURL)
+		default:
+			err = fmt.Errorf("rsscloud: unknown procedure %q", method)
+		}
+	}
+	if err != nil {
+		w.Write(encodeFault(1, strings.TrimPrefix(err.Error(), "rsscloud: ")))
+		return
+	}
+	w.Write(encodeResponse(true))
+}
+
+// registerXMLRPC handles rssCloud.pleaseNotify(notifyProcedure, port, path,
+// protocol, urlList[, domain])
+func (s *Server) registerXMLRPC(r *http.Request, params []any) error {
+	if len(params) < 5 {
+		return fmt.Errorf("rsscloud: %s needs at least 5 parameters", PleaseNotifyProcedure)
+	}
+	request := subscriberRequest{}
+	var ok [4]bool
+	request.procedure, ok[0] = params[0].(string)
+	request.port, ok[1] = params[1].(int)
+	request.path, ok[2] = params[2].(string)
+	var protocol string
+	protocol, ok[3] = params[3].(string)
+	request.protocol = rss.CloudProtocol(protocol)
+	urls, _ := params[4].([]any)
+	for _, u := range urls {
+		if feedURL, isString := u.(string); isString {
+			request.feedURLs = append(request.feedURLs, feedURL)
+		}
+	}
+	if len(params) > 5 {
+		request.domain, _ = params[5].(string)
+	}
+	if ok != [4]bool{true, true, true, true} {
+		return fmt.Errorf("rsscloud: invalid %s parameters", PleaseNotifyProcedure)
+	}
+	return s.register(r.Context(), r, request)
+}
+
+type subscriberRequest struct {
+	protocol  rss.CloudProtocol
+	procedure string
+	domain    string
+	port      int
+	path      string
+	feedURLs  []string
+}
+
+// register checks the subscriber can be notified, and subscribes it to the
+// feeds
+func (s *Server) register(ctx context.Context, r *http.Request, request subscriberRequest) error {
+	if len(request.feedURLs) == 0 {
+		return fmt.Errorf("rsscloud: no feeds to be notified about")
+	}
+	if request.port <= 0 || request.port > 65535 {
+		return fmt.Errorf("rsscloud: invalid port %d", request.port)
+	}
+	switch request.protocol {
+	case rss.CloudProtocolHTTPPost:
+	case rss.CloudProtocolXMLRPC:
+		if request.procedure == "" {
+			return fmt.Errorf("rsscloud: missing notifyProcedure")
+		}
+	default:
+		return fmt.Errorf("%w %q", ErrUnsupportedProtocol, request.protocol)
+	}
+
+	host := request.domain
+	if host == "" {
+		host, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}
+	path := request.path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	target := (&url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(request.port)), Path: path}).String()
+	sub := subscriber{request.protocol, target, request.procedure}
+
+	// Subscribers that give a domain prove they asked by answering a
+	// challenge, others by accepting a notification
+	if request.protocol == rss.CloudProtocolHTTPPost && request.domain != "" {
+		if err := challenge(ctx, s.HTTPClient, target, request.feedURLs[0]); err != nil {
+			return err
+		}
+	} else if err := s.notify(ctx, sub, request.feedURLs[0]); err != nil {
+		return fmt.Errorf("rsscloud: couldn't notify %s: %w", target, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscriptions == nil {
+		s.subscriptions = map[string]map[subscriber]time.Time{}
+	}
+	expires := time.Now().Add(RegistrationLifetime)
+	for _, feedURL := range request.feedURLs {
+		if s.subscriptions[feedURL] == nil {
+			s.subscriptions[feedURL] = map[subscriber]time.Time{}
+		}
+		s.subscriptions[feedURL][sub] = expires
+	}
+	return nil
+}
+
+// challenge checks that the http-post subscriber at target echoes a random
+// challenge
+func challenge(ctx context.Context, client *http.Client, target, feedURL string) error {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return fmt.Errorf("rsscloud: %w", err)
+	}
+	expected := hex.EncodeToString(random)
+	u := target + "?" + url.Values{"url": {feedURL}, "challenge": {expected}}.Encode()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("rsscloud: %w", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("rsscloud: %w", err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode/100 != 2 || strings.TrimSpace(string(body)) != expected {
+		return fmt.Errorf("rsscloud: %s didn't answer the challenge", target)
+	}
+	return nil
+}
+
+// ping handles a publisher's ping. The subscribers are notified in the
+// background, since they're the cloud's problem, not the publisher's.
+func (s *Server) ping(feedURL string) error {
+	if feedURL == "" {
+		return fmt.Errorf("rsscloud: missing url")
+	}
+	s.work.Add(1)
+	go func() {
+		defer s.work.Done()
+		s.Notify(context.Background(), feedURL)
+	}()
+	return nil
+}
+
+// Wait waits for the notifications of the pings that have been received
+func (s *Server) Wait() {
+	s.work.Wait()
+}
+
+// Notify tells the subscribers of the feed at feedURL that it changed. It
+// drops expired registrations, and those of subscribers that answer a
+// notification with an error, which must register again. Subscribers that
+// don't answer keep their registrations until they expire.
+func (s *Server) Notify(ctx context.Context, feedURL string) error {
+	if feedURL == "" {
+		return fmt.Errorf("rsscloud: missing url")
+	}
+	now := time.Now()
+	var subscribers []subscriber
+	s.mu.Lock()
+	for sub, expires := range s.subscriptions[feedURL] {
+		if now.After(expires) {
+			delete(s.subscriptions[feedURL], sub)
+		} else {
+			subscribers = append(subscribers, sub)
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, sub := range subscribers {
+		err := s.notify(ctx, sub, feedURL)
+		if err == nil {
+			continue
+		}
+		errs = append(errs, err)
+		if errors.As(err, &rejectedError{}) {
+			s.mu.Lock()
+			delete(s.subscriptions[feedURL], sub)
+			s.mu.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server) notify(ctx context.Context, sub subscriber, feedURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	if sub.protocol == rss.CloudProtocolXMLRPC {
+		return call(ctx, s.HTTPClient, http.MethodPost, sub.target, xmlRPCContentType, encodeCall(sub.procedure, feedURL))
+	}
+	return call(ctx, s.HTTPClient, http.MethodPost, sub.target, formContentType, []byte(url.Values{"url": {feedURL}}.Encode()))
+}
+
+func writeNotifyResult(w http.ResponseWriter, err error) {
+	result := notifyResult{Success: true, Message: "OK"}
+	if err != nil {
+		result = notifyResult{Success: false, Message: strings.TrimPrefix(err.Error(), "rsscloud: ")}
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	data, _ := xml.Marshal(result)
+	io.WriteString(w, xml.Header)
+	w.Write(data)
+}
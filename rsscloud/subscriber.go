@@ -0,0 +1,185 @@
+package rsscloud
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/jaydenmilne/podcast/rss"
+)
+
+// DefaultNotifyProcedure is the XML-RPC procedure xml-rpc clouds call to
+// notify a [Subscriber], unless it sets another
+const DefaultNotifyProcedure = "rssCloud.notify"
+
+// Subscriber is an [http.Handler] that clouds notify when feeds change. Serve
+// it at Path on Port.
+type Subscriber struct {
+	// Domain is the subscriber's public host name. If it's empty, clouds
+	// notify the address registrations come from.
+	Domain string
+
+	// Port and Path are where the subscriber is served. Path defaults to
+	// "/".
+	Port int
+	Path string
+
+	// NotifyProcedure is the procedure xml-rpc clouds call. Defaults to
+	// [DefaultNotifyProcedure].
+	NotifyProcedure string
+
+	// HTTPClient makes requests to clouds. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+
+	// OnNotify is called with the URLs of feeds that changed. Notifications
+	// about feeds the subscriber didn't [Subscriber.Register] for are
+	// ignored.
+	OnNotify func(feedURL string)
+
+	mu         sync.Mutex
+	registered map[string]bool
+}
+
+func (s *Subscriber) path() string {
+	if s.Path == "" {
+		return "/"
+	}
+	return s.Path
+}
+
+func (s *Subscriber) notifyProcedure() string {
+	if s.NotifyProcedure == "" {
+		return DefaultNotifyProcedure
+	}
+	return s.NotifyProcedure
+}
+
+// Register asks the cloud to notify the subscriber when the feeds change.
+// The cloud checks the subscriber is reachable while registering, so it must
+// be serving requests. Registrations expire after [RegistrationLifetime].
+func (s *Subscriber) Register(ctx context.Context, cloud *rss.Cloud, feedURLs ...string) error {
+	target, err := endpoint(cloud)
+	if err != nil {
+		return err
+	}
+	if len(feedURLs) == 0 {
+		return fmt.Errorf("rsscloud: no feeds to register")
+	}
+
+	s.mu.Lock()
+	if s.registered == nil {
+		s.registered = map[string]bool{}
+	}
+	for _, feedURL := range feedURLs {
+		s.registered[feedURL] = true
+	}
+	s.mu.Unlock()
+
+	switch cloud.Protocol {
+	case rss.CloudProtocolHTTPPost:
+		form := url.Values{
+			"notifyProcedure": {""},
+			"port":            {strconv.Itoa(s.Port)},
+			"path":            {s.path()},
+			"protocol":        {string(rss.CloudProtocolHTTPPost)},
+		}
+		if s.Domain != "" {
+			form.Set("domain", s.Domain)
+		}
+		for i, feedURL := range feedURLs {
+			form.Set("url"+strconv.Itoa(i+1), feedURL)
+		}
+		return call(ctx, s.HTTPClient, http.MethodPost, target, formContentType, []byte(form.Encode()))
+	case rss.CloudProtocolXMLRPC:
+		procedure := cloud.RegisterProcedure
+		if procedure == "" {
+			procedure = PleaseNotifyProcedure
+		}
+		params := []any{s.notifyProcedure(), s.Port, s.path(), string(rss.CloudProtocolXMLRPC), feedURLs}
+		if s.Domain != "" {
+			params = append(params, s.Domain)
+		}
+		return call(ctx, s.HTTPClient, http.MethodPost, target, xmlRPCContentType, encodeCall(procedure, params...))
+	}
+	return fmt.Errorf("%w %q", ErrUnsupportedProtocol, cloud.Protocol)
+}
+
+func (s *Subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		// An http-post cloud checking the subscriber asked to register
+		query := r.URL.Query()
+		if !s.isRegistered(query.Get("url")) {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, query.Get("challenge"))
+	case http.MethodPost:
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/xml" || mediaType == "application/xml" {
+			s.serveXMLRPC(w, r)
+			return
+		}
+		feedURL := r.PostFormValue("url")
+		if feedURL == "" {
+			http.Error(w, "missing url", http.StatusBadRequest)
+			return
+		}
+		if !s.isRegistered(feedURL) {
+			http.NotFound(w, r)
+			return
+		}
+		if s.OnNotify != nil {
+			s.OnNotify(feedURL)
+		}
+		io.WriteString(w, "Thanks for the update!")
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Subscriber) serveXMLRPC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", xmlRPCContentType)
+	method, params, err := decodeCall(io.LimitReader(r.Body, 1<<16))
+	if err != nil {
+		w.Write(encodeFault(1, err.Error()))
+		return
+	}
+	if method != s.notifyProcedure() {
+		w.Write(encodeFault(2, fmt.Sprintf("unknown procedure %q", method)))
+		return
+	}
+	feedURL, _ := firstParam(params).(string)
+	if feedURL == "" {
+		w.Write(encodeFault(3, "missing url"))
+		return
+	}
+	if !s.isRegistered(feedURL) {
+		w.Write(encodeFault(4, "not registered for "+feedURL))
+		return
+	}
+	if s.OnNotify != nil {
+		s.OnNotify(feedURL)
+	}
+	w.Write(encodeResponse(true))
+}
+
+// isRegistered reports whether the subscriber registered for the feed, so
+// only its clouds' notifications about it are acted on
+func (s *Subscriber) isRegistered(feedURL string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.registered[feedURL]
+}
+
+func firstParam(params []any) any {
+	if len(params) == 0 {
+		return nil
+	}
+	return params[0]
+}
@@ -0,0 +1,201 @@
+package rsscloud
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The subset of XML-RPC rssCloud needs: strings, ints, booleans, arrays and
+// the structs of faults.
+
+type rpcValue struct {
+	String  *string    `xml:"string"`
+	Int     *string    `xml:"int"`
+	I4      *string    `xml:"i4"`
+	Boolean *string    `xml:"boolean"`
+	Array   *rpcArray  `xml:"array"`
+	Struct  *rpcStruct `xml:"struct"`
+	Text    string     `xml:",chardata"`
+}
+
+type rpcArray struct {
+	Values []rpcValue `xml:"data>value"`
+}
+
+type rpcStruct struct {
+	Members []struct {
+		Name  string   `xml:"name"`
+		Value rpcValue `xml:"value"`
+	} `xml:"member"`
+}
+
+type rpcCall struct {
+	XMLName    xml.Name   `xml:"methodCall"`
+	MethodName string     `xml:"methodName"`
+	Params     []rpcValue `xml:"params>param>value"`
+}
+
+type rpcResponse struct {
+	XMLName xml.Name   `xml:"methodResponse"`
+	Params  []rpcValue `xml:"params>param>value"`
+	Fault   *rpcValue  `xml:"fault>value"`
+}
+
+// decode converts the value to a string, int, bool, []any or map[string]any
+func (v *rpcValue) decode() (any, error) {
+	switch {
+	case v.String != nil:
+		return *v.String, nil
+	case v.Int != nil || v.I4 != nil:
+		text := v.Int
+		if text == nil {
+			text = v.I4
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(*text))
+		if err != nil {
+			return nil, fmt.Errorf("rsscloud: invalid XML-RPC int %q", *text)
+		}
+		return n, nil
+	case v.Boolean != nil:
+		switch strings.TrimSpace(*v.Boolean) {
+		case "1":
+			return true, nil
+		case "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("rsscloud: invalid XML-RPC boolean %q", *v.Boolean)
+	case v.Array != nil:
+		values := make([]any, len(v.Array.Values))
+		for i := range v.Array.Values {
+			value, err := v.Array.Values[i].decode()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case v.Struct != nil:
+		members := map[string]any{}
+		for _, member := range v.Struct.Members {
+			value, err := member.Value.decode()
+			if err != nil {
+				return nil, err
+			}
+			members[member.Name] = value
+		}
+		return members, nil
+	}
+	// Values without a type are strings
+	return v.Text, nil
+}
+
+// writeValue writes a value as XML-RPC
+func writeValue(b *bytes.Buffer, value any) {
+	b.WriteString("<value>")
+	switch value := value.(type) {
+	case string:
+		b.WriteString("<string>")
+		xml.EscapeText(b, []byte(value))
+		b.WriteString("</string>")
+	case int:
+		fmt.Fprintf(b, "<i4>%d</i4>", value)
+	case bool:
+		if value {
+			b.WriteString("<boolean>1</boolean>")
+		} else {
+			b.WriteString("<boolean>0</boolean>")
+		}
+	case []string:
+		b.WriteString("<array><data>")
+		for _, s := range value {
+			writeValue(b, s)
+		}
+		b.WriteString("</data></array>")
+	case map[string]any:
+		b.WriteString("<struct>")
+		for name, member := range value {
+			b.WriteString("<member><name>")
+			xml.EscapeText(b, []byte(name))
+			b.WriteString("</name>")
+			writeValue(b, member)
+			b.WriteString("</member>")
+		}
+		b.WriteString("</struct>")
+	default:
+		panic(fmt.Sprintf("rsscloud: unsupported XML-RPC value %T", value))
+	}
+	b.WriteString("</value>")
+}
+
+func encodeCall(method string, params ...any) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<methodCall><methodName>")
+	xml.EscapeText(&b, []byte(method))
+	b.WriteString("</methodName><params>")
+	for _, param := range params {
+		b.WriteString("<param>")
+		writeValue(&b, param)
+		b.WriteString("</param>")
+	}
+	b.WriteString("</params></methodCall>\n")
+	return b.Bytes()
+}
+
+func encodeResponse(value any) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<methodResponse><params><param>")
+	writeValue(&b, value)
+	b.WriteString("</param></params></methodResponse>\n")
+	return b.Bytes()
+}
+
+func encodeFault(code int, message string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<methodResponse><fault>")
+	writeValue(&b, map[string]any{"faultCode": code, "faultString": message})
+	b.WriteString("</fault></methodResponse>\n")
+	return b.Bytes()
+}
+
+func decodeCall(r io.Reader) (string, []any, error) {
+	var call rpcCall
+	if err := xml.NewDecoder(r).Decode(&call); err != nil {
+		return "", nil, fmt.Errorf("rsscloud: invalid XML-RPC call: %w", err)
+	}
+	params := make([]any, len(call.Params))
+	for i := range call.Params {
+		param, err := call.Params[i].decode()
+		if err != nil {
+			return "", nil, err
+		}
+		params[i] = param
+	}
+	return strings.TrimSpace(call.MethodName), params, nil
+}
+
+// decodeResponse returns the response's value, or its fault as an error
+func decodeResponse(r io.Reader) (any, error) {
+	var response rpcResponse
+	if err := xml.NewDecoder(r).Decode(&response); err != nil {
+		return nil, fmt.Errorf("rsscloud: invalid XML-RPC response: %w", err)
+	}
+	if response.Fault != nil {
+		fault, err := response.Fault.decode()
+		if err != nil {
+			return nil, err
+		}
+		members, _ := fault.(map[string]any)
+		return nil, fmt.Errorf("rsscloud: fault %v: %v", members["faultCode"], members["faultString"])
+	}
+	if len(response.Params) != 1 {
+		return nil, fmt.Errorf("rsscloud: XML-RPC response has %d values", len(response.Params))
+	}
+	return response.Params[0].decode()
+}
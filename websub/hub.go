@@ -0,0 +1,244 @@
+package websub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLeaseSeconds is the longest a [Hub] keeps subscriptions, unless set
+const DefaultLeaseSeconds = 10 * 24 * 60 * 60
+
+// requestTimeout limits each request the hub makes, so [Hub.Wait] returns
+// even if a subscriber or publisher never answers
+var requestTimeout = 30 * time.Second
+
+// Hub is a minimal, in-memory WebSub hub, for tests and small deployments.
+// Serve it at URL.
+type Hub struct {
+	// URL is the public URL the hub is served at
+	URL string
+
+	// HTTPClient verifies subscribers, fetches topics and pushes them.
+	// Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+
+	// LeaseSeconds is the longest lease subscribers get. Defaults to
+	// [DefaultLeaseSeconds].
+	LeaseSeconds int
+
+	mu sync.Mutex
+	// subscriptions are by topic, then callback
+	subscriptions map[string]map[string]*hubSubscription
+	work          sync.WaitGroup
+}
+
+type hubSubscription struct {
+	secret  string
+	expires time.Time
+}
+
+// Wait waits for the verifications and pushes that have been requested
+func (h *Hub) Wait() {
+	h.work.Wait()
+}
+
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch mode := r.PostForm.Get("hub.mode"); mode {
+	case "subscribe", "unsubscribe":
+		topic, callback := r.PostForm.Get("hub.topic"), r.PostForm.Get("hub.callback")
+		if !absoluteURL(topic) || !absoluteURL(callback) {
+			http.Error(w, "hub.topic and hub.callback must be absolute URLs", http.StatusBadRequest)
+			return
+		}
+		lease := h.lease(r.PostForm.Get("hub.lease_seconds"))
+		secret := r.PostForm.Get("hub.secret")
+		h.work.Add(1)
+		go func() {
+			defer h.work.Done()
+			h.verify(mode, topic, callback, secret, lease)
+		}()
+	case "publish":
+		topic := r.PostForm.Get("hub.url")
+		if topic == "" {
+			topic = r.PostForm.Get("hub.topic")
+		}
+		if !absoluteURL(topic) {
+			http.Error(w, "hub.url must be an absolute URL", http.StatusBadRequest)
+			return
+		}
+		h.work.Add(1)
+		go func() {
+			defer h.work.Done()
+			h.distribute(topic)
+		}()
+	default:
+		http.Error(w, fmt.Sprintf("unknown hub.mode %q", mode), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// lease is the lease to give a subscriber that asked for requested
+func (h *Hub) lease(requested string) int {
+	limit := h.LeaseSeconds
+	if limit <= 0 {
+		limit = DefaultLeaseSeconds
+	}
+	if seconds, err := strconv.Atoi(requested); err == nil && seconds > 0 && seconds < limit {
+		return seconds
+	}
+	return limit
+}
+
+func (h *Hub) client() *http.Client {
+	if h.HTTPClient != nil {
+		return h.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// verify checks the subscriber asked for the (un)subscription, and applies
+// it if it did
+func (h *Hub) verify(mode, topic, callback, secret string, lease int) {
+	challenge := make([]byte, 16)
+	if _, err := rand.Read(challenge); err != nil {
+		return
+	}
+	u, err := url.Parse(callback)
+	if err != nil {
+		return
+	}
+	query := u.Query()
+	query.Set("hub.mode", mode)
+	query.Set("hub.topic", topic)
+	query.Set("hub.challenge", hex.EncodeToString(challenge))
+	if mode == "subscribe" {
+		query.Set("hub.lease_seconds", strconv.Itoa(lease))
+	}
+	u.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return
+	}
+	response, err := h.client().Do(request)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if err != nil || response.StatusCode/100 != 2 || string(body) != query.Get("hub.challenge") {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if mode == "unsubscribe" {
+		delete(h.subscriptions[topic], callback)
+		return
+	}
+	if h.subscriptions == nil {
+		h.subscriptions = map[string]map[string]*hubSubscription{}
+	}
+	if h.subscriptions[topic] == nil {
+		h.subscriptions[topic] = map[string]*hubSubscription{}
+	}
+	h.subscriptions[topic][callback] = &hubSubscription{
+		secret:  secret,
+		expires: time.Now().Add(time.Duration(lease) * time.Second),
+	}
+}
+
+// distribute fetches the topic and pushes it to its subscribers
+func (h *Hub) distribute(topic string) {
+	now := time.Now()
+	h.mu.Lock()
+	subscribers := map[string]hubSubscription{}
+	for callback, subscription := range h.subscriptions[topic] {
+		if now.After(subscription.expires) {
+			delete(h.subscriptions[topic], callback)
+		} else {
+			subscribers[callback] = *subscription
+		}
+	}
+	h.mu.Unlock()
+	if len(subscribers) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, topic, nil)
+	if err != nil {
+		return
+	}
+	response, err := h.client().Do(request)
+	if err != nil {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize+1))
+	response.Body.Close()
+	if err != nil || response.StatusCode != http.StatusOK || len(body) > maxBodySize {
+		return
+	}
+	contentType := response.Header.Get("Content-Type")
+
+	for callback, subscription := range subscribers {
+		if h.push(callback, topic, contentType, body, subscription.secret) == http.StatusGone {
+			h.mu.Lock()
+			delete(h.subscriptions[topic], callback)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// push sends the content to a subscriber, and returns the response status
+func (h *Hub) push(callback, topic, contentType string, body []byte, secret string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, callback, bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	request.Header.Add("Link", "<"+h.URL+`>; rel="hub"`)
+	request.Header.Add("Link", "<"+topic+`>; rel="self"`)
+	if secret != "" {
+		request.Header.Set("X-Hub-Signature", sign(secret, body))
+	}
+	response, err := h.client().Do(request)
+	if err != nil {
+		return 0
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+func absoluteURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && !strings.ContainsAny(value, " \t\n")
+}
@@ -0,0 +1,252 @@
+package websub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// ErrBadSignature is passed to [Subscriber.OnError] for pushed content
+// without a valid X-Hub-Signature, which is ignored
+var ErrBadSignature = errors.New("websub: content has a bad signature")
+
+// maxBodySize limits the size of pushed and fetched feeds
+const maxBodySize = 32 << 20
+
+// Subscription is a subscriber's subscription to a topic
+type Subscription struct {
+	Topic string
+	Hub   string
+
+	// Active is set once the hub verified the subscription
+	Active bool
+
+	// Expires is when the hub will stop pushing updates, unless the
+	// subscription is renewed by subscribing again
+	Expires time.Time
+
+	secret string
+
+	// pending is the mode waiting to be verified, if any, and pendingSecret
+	// the secret that comes into use when a subscription is
+	pending       string
+	pendingSecret string
+}
+
+// Subscriber is an [http.Handler] that receives feeds pushed by hubs. Serve
+// it at Callback.
+type Subscriber struct {
+	// Callback is the public URL the subscriber is served at
+	Callback string
+
+	// HTTPClient makes requests to hubs. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+
+	// LeaseSeconds is how long to ask hubs to keep subscriptions for. Zero
+	// leaves it to the hub.
+	LeaseSeconds int
+
+	// OnFeed is called with the feeds pushed by hubs
+	OnFeed func(topic string, feed *podcast.RSSPodcast)
+
+	// OnError, if set, is called with pushed content that couldn't be used,
+	// and subscriptions the hub denied
+	OnError func(topic string, err error)
+
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+}
+
+// Subscribe asks the hub to push updates of topic. The subscription is
+// active once the hub verifies it with the subscriber, which must be
+// serving requests.
+func (s *Subscriber) Subscribe(ctx context.Context, hub, topic string) error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("websub: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.subscriptions == nil {
+		s.subscriptions = map[string]*Subscription{}
+	}
+	subscription := s.subscriptions[topic]
+	if subscription == nil {
+		subscription = &Subscription{Topic: topic}
+		s.subscriptions[topic] = subscription
+	}
+	subscription.Hub = hub
+	subscription.pending = "subscribe"
+	subscription.pendingSecret = hex.EncodeToString(secret)
+	form := url.Values{
+		"hub.mode":     {"subscribe"},
+		"hub.topic":    {topic},
+		"hub.callback": {s.Callback},
+		"hub.secret":   {subscription.pendingSecret},
+	}
+	s.mu.Unlock()
+	if s.LeaseSeconds > 0 {
+		form.Set("hub.lease_seconds", strconv.Itoa(s.LeaseSeconds))
+	}
+
+	if err := post(ctx, s.HTTPClient, hub, form); err != nil {
+		s.mu.Lock()
+		if !subscription.Active {
+			delete(s.subscriptions, topic)
+		}
+		subscription.pending = ""
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unsubscribe asks the hub to stop pushing updates of topic
+func (s *Subscriber) Unsubscribe(ctx context.Context, topic string) error {
+	s.mu.Lock()
+	subscription := s.subscriptions[topic]
+	if subscription == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("websub: not subscribed to %s", topic)
+	}
+	previous := subscription.pending
+	subscription.pending = "unsubscribe"
+	hub := subscription.Hub
+	s.mu.Unlock()
+
+	err := post(ctx, s.HTTPClient, hub, url.Values{
+		"hub.mode":     {"unsubscribe"},
+		"hub.topic":    {topic},
+		"hub.callback": {s.Callback},
+	})
+	if err != nil {
+		// The hub won't verify an unsubscription it never got, so don't
+		// accept one from anyone else
+		s.mu.Lock()
+		if subscription.pending == "unsubscribe" {
+			subscription.pending = previous
+		}
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// Subscriptions returns the subscriptions, including those waiting to be
+// verified
+func (s *Subscriber) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subscriptions []Subscription
+	for _, subscription := range s.subscriptions {
+		subscriptions = append(subscriptions, *subscription)
+	}
+	return subscriptions
+}
+
+func (s *Subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.verify(w, r)
+	case http.MethodPost:
+		s.receive(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify answers the hub's check that the subscriber asked for a
+// subscription or unsubscription
+func (s *Subscriber) verify(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	mode, topic := query.Get("hub.mode"), query.Get("hub.topic")
+
+	s.mu.Lock()
+	subscription := s.subscriptions[topic]
+	switch {
+	case subscription == nil:
+	case mode == "denied" && subscription.pending == "subscribe":
+		// Only a request the subscriber is waiting on can be denied, since
+		// anyone can send this
+		delete(s.subscriptions, topic)
+		s.mu.Unlock()
+		if s.OnError != nil {
+			s.OnError(topic, fmt.Errorf("websub: hub denied the subscription: %s", query.Get("hub.reason")))
+		}
+		return
+	case mode == "subscribe" && (subscription.pending == "subscribe" || subscription.Active):
+		if subscription.pending == "subscribe" {
+			subscription.secret = subscription.pendingSecret
+		}
+		subscription.Active = true
+		subscription.pending = ""
+		subscription.Expires = time.Time{}
+		if lease, err := strconv.Atoi(query.Get("hub.lease_seconds")); err == nil && lease > 0 {
+			subscription.Expires = time.Now().Add(time.Duration(lease) * time.Second)
+		}
+		s.mu.Unlock()
+		io.WriteString(w, query.Get("hub.challenge"))
+		return
+	case mode == "unsubscribe" && subscription.pending == "unsubscribe":
+		delete(s.subscriptions, topic)
+		s.mu.Unlock()
+		io.WriteString(w, query.Get("hub.challenge"))
+		return
+	}
+	s.mu.Unlock()
+	http.NotFound(w, r)
+}
+
+// receive handles content pushed by a hub
+func (s *Subscriber) receive(w http.ResponseWriter, r *http.Request) {
+	topic := links(r.Header)[podcast.RelSelf]
+	s.mu.Lock()
+	subscription := s.subscriptions[topic]
+	var secret string
+	active := subscription != nil && subscription.Active
+	if active {
+		secret = subscription.secret
+	}
+	s.mu.Unlock()
+	if !active {
+		// Tells the hub to stop pushing
+		http.Error(w, "not subscribed", http.StatusGone)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err == nil && len(body) > maxBodySize {
+		err = fmt.Errorf("websub: pushed feed is larger than %d bytes", maxBodySize)
+	}
+	if err == nil && !verifySignature(secret, r.Header.Get("X-Hub-Signature"), body) {
+		err = ErrBadSignature
+	}
+	var feed *podcast.RSSPodcast
+	if err == nil {
+		feed, err = podcast.Decode(bytes.NewReader(body))
+	}
+
+	// The hub is told content was accepted even if it's ignored, so it
+	// can't be used to find the secret
+	w.WriteHeader(http.StatusAccepted)
+	if err != nil {
+		if s.OnError != nil {
+			s.OnError(topic, err)
+		}
+		return
+	}
+	if s.OnFeed != nil {
+		s.OnFeed(topic, feed)
+	}
+}
@@ -0,0 +1,138 @@
+// Package websub delivers feed updates in real time with [WebSub]: publishers
+// ping the hubs their feeds link to, and hubs push the new feed to
+// subscribers.
+//
+// [WebSub]: https://www.w3.org/TR/websub/
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+// ErrNoHub is returned for feeds that don't link to a hub
+var ErrNoHub = errors.New("websub: feed has no hub")
+
+// Hubs returns the URLs of the hubs the feed links to with rel="hub"
+func Hubs(feed *podcast.RSSPodcast) []string {
+	var hubs []string
+	for _, link := range feed.Channel.AtomLinks {
+		if link.Rel == podcast.RelHub && link.Href != "" {
+			hubs = append(hubs, link.Href)
+		}
+	}
+	return hubs
+}
+
+// Publish tells the feed's hubs that it was republished, so they fetch it and
+// push it to subscribers. The topic is the feed's rel="self" link. client
+// defaults to [http.DefaultClient].
+func Publish(ctx context.Context, client *http.Client, feed *podcast.RSSPodcast) error {
+	topic := feed.Channel.AtomLinkHref(podcast.RelSelf)
+	if topic == "" {
+		return fmt.Errorf("websub: feed has no self link")
+	}
+	hubs := Hubs(feed)
+	if len(hubs) == 0 {
+		return ErrNoHub
+	}
+	var errs []error
+	for _, hub := range hubs {
+		err := post(ctx, client, hub, url.Values{"hub.mode": {"publish"}, "hub.url": {topic}})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post sends a form to a hub, which should accept it
+func post(ctx context.Context, client *http.Client, hub string, form url.Values) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("websub: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("websub: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("websub: %s %s: %s %s", form.Get("hub.mode"), hub, response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// signatureHashes are the X-Hub-Signature methods, by name
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// sign returns the X-Hub-Signature of body, with the sha256 method
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks an X-Hub-Signature header of body
+func verifySignature(secret, signature string, body []byte) bool {
+	method, digest, ok := strings.Cut(signature, "=")
+	newHash := signatureHashes[strings.ToLower(method)]
+	if !ok || newHash == nil {
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// links parses the URLs out of Link headers, by relation
+func links(header http.Header) map[string]string {
+	found := map[string]string{}
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, _ := strings.Cut(strings.TrimSpace(link), ";")
+			target = strings.TrimSpace(target)
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "rel") {
+					for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+						if _, ok := found[rel]; !ok {
+							found[rel] = target[1 : len(target)-1]
+						}
+					}
+				}
+			}
+		}
+	}
+	return found
+}
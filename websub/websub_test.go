@@ -0,0 +1,213 @@
+package websub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jaydenmilne/podcast/podcast"
+)
+
+func TestLinks(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://hub.example.com/>; rel="hub", <https://example.com/feed.xml>; rel=self`)
+	header.Add("Link", `<https://other.example.com/>; rel="hub"`)
+	expected := map[string]string{"hub": "https://hub.example.com/", "self": "https://example.com/feed.xml"}
+	if diff := cmp.Diff(expected, links(header)); diff != "" {
+		t.Errorf("unexpected links (-want +got):\n%s", diff)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte("<rss/>")
+	if !verifySignature("secret", sign("secret", body), body) {
+		t.Error("expected the signature to verify")
+	}
+	for _, signature := range []string{"", "sha256=00", "md5=" + strings.TrimPrefix(sign("secret", body), "sha256="), sign("other", body)} {
+		if verifySignature("secret", signature, body) {
+			t.Errorf("expected %q not to verify", signature)
+		}
+	}
+}
+
+func TestEndToEnd(t *testing.T) {
+	hub := &Hub{}
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+	hub.URL = hubServer.URL
+
+	var mu sync.Mutex
+	title := "First"
+	var topic string
+	feed := func() *podcast.RSSPodcast {
+		mu.Lock()
+		defer mu.Unlock()
+		feed := &podcast.RSSPodcast{}
+		feed.Channel.Title = title
+		feed.Channel.AtomLinks = []podcast.AtomLink{
+			{Href: topic, Rel: podcast.RelSelf, Type: "application/rss+xml"},
+			{Href: hub.URL, Rel: podcast.RelHub},
+		}
+		return feed
+	}
+	topicServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		if err := podcast.Encode(w, feed()); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer topicServer.Close()
+	topic = topicServer.URL + "/feed.xml"
+
+	var received []string
+	var errs []error
+	subscriber := &Subscriber{
+		OnFeed: func(topic string, feed *podcast.RSSPodcast) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, feed.Channel.Title)
+		},
+		OnError: func(topic string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	}
+	subscriberServer := httptest.NewServer(subscriber)
+	defer subscriberServer.Close()
+	subscriber.Callback = subscriberServer.URL + "/callback"
+
+	ctx := context.Background()
+	hubs := Hubs(feed())
+	if diff := cmp.Diff([]string{hub.URL}, hubs); diff != "" {
+		t.Fatalf("unexpected hubs (-want +got):\n%s", diff)
+	}
+	if err := subscriber.Subscribe(ctx, hubs[0], topic); err != nil {
+		t.Fatal(err)
+	}
+	hub.Wait()
+	if subscriptions := subscriber.Subscriptions(); len(subscriptions) != 1 || !subscriptions[0].Active || subscriptions[0].Expires.IsZero() {
+		t.Fatalf("expected an active subscription, got %+v", subscriptions)
+	}
+
+	// Only pending subscriptions can be denied
+	response, err := http.Get(subscriber.Callback + "?" + url.Values{"hub.mode": {"denied"}, "hub.topic": {topic}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if subscriptions := subscriber.Subscriptions(); response.StatusCode != http.StatusNotFound || len(subscriptions) != 1 || !subscriptions[0].Active {
+		t.Fatalf("expected denying an active subscription to be ignored, got %s %+v", response.Status, subscriptions)
+	}
+
+	if err := Publish(ctx, nil, feed()); err != nil {
+		t.Fatal(err)
+	}
+	hub.Wait()
+	mu.Lock()
+	title = "Second"
+	mu.Unlock()
+	if err := Publish(ctx, nil, feed()); err != nil {
+		t.Fatal(err)
+	}
+	hub.Wait()
+
+	// Content that isn't signed with the secret is ignored
+	forged, _ := http.NewRequest(http.MethodPost, subscriber.Callback, strings.NewReader("<rss><channel><title>Forged</title></channel></rss>"))
+	forged.Header.Set("Link", "<"+topic+`>; rel="self"`)
+	forged.Header.Set("X-Hub-Signature", sign("guess", []byte("<rss/>")))
+	response, err = http.DefaultClient.Do(forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		t.Errorf("expected forged content to look accepted, got %s", response.Status)
+	}
+
+	if err := subscriber.Unsubscribe(ctx, topic); err != nil {
+		t.Fatal(err)
+	}
+	hub.Wait()
+	if subscriptions := subscriber.Subscriptions(); len(subscriptions) != 0 {
+		t.Errorf("expected no subscriptions, got %+v", subscriptions)
+	}
+	if err := Publish(ctx, nil, feed()); err != nil {
+		t.Fatal(err)
+	}
+	hub.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff([]string{"First", "Second"}, received); diff != "" {
+		t.Errorf("unexpected feeds received (-want +got):\n%s", diff)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrBadSignature) {
+		t.Errorf("expected the forged content to be rejected, got %v", errs)
+	}
+}
+
+func TestUnsubscribeFailure(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer hub.Close()
+	subscriber := &Subscriber{Callback: "https://example.com/callback"}
+	subscriber.subscriptions = map[string]*Subscription{
+		"https://example.com/feed.xml": {Topic: "https://example.com/feed.xml", Hub: hub.URL, Active: true},
+	}
+
+	if err := subscriber.Unsubscribe(context.Background(), "https://example.com/feed.xml"); err == nil {
+		t.Fatal("expected the hub's error")
+	}
+	// Nobody can verify the unsubscription the hub never got
+	recorder := httptest.NewRecorder()
+	subscriber.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/callback?hub.mode=unsubscribe&hub.topic=https://example.com/feed.xml&hub.challenge=forged", nil))
+	if subscriptions := subscriber.Subscriptions(); recorder.Code != http.StatusNotFound || len(subscriptions) != 1 || !subscriptions[0].Active {
+		t.Errorf("expected the forged unsubscription to be ignored, got %d %+v", recorder.Code, subscriptions)
+	}
+}
+
+func TestHubTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { requestTimeout = timeout }(requestTimeout)
+	requestTimeout = 50 * time.Millisecond
+
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+
+	hub := &Hub{}
+	done := make(chan struct{})
+	go func() {
+		hub.verify("subscribe", hanging.URL+"/feed.xml", hanging.URL+"/callback", "", DefaultLeaseSeconds)
+		hub.subscriptions = map[string]map[string]*hubSubscription{
+			hanging.URL + "/feed.xml": {hanging.URL + "/callback": {expires: time.Now().Add(time.Hour)}},
+		}
+		hub.distribute(hanging.URL + "/feed.xml")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the hub's requests to time out")
+	}
+}
+
+func TestPublishErrors(t *testing.T) {
+	feed := &podcast.RSSPodcast{}
+	if err := Publish(context.Background(), nil, feed); err == nil {
+		t.Error("expected an error for a feed without a self link")
+	}
+	feed.Channel.AtomLinks = []podcast.AtomLink{{Href: "https://example.com/feed.xml", Rel: podcast.RelSelf}}
+	if err := Publish(context.Background(), nil, feed); !errors.Is(err, ErrNoHub) {
+		t.Errorf("expected ErrNoHub, got %v", err)
+	}
+}